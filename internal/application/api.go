@@ -0,0 +1,60 @@
+package application
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/cdriehuys/stuff2/internal/validation"
+)
+
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type apiErrorResponse struct {
+	Errors []apiError `json:"errors"`
+}
+
+func makeAPIErrorResponse(errs ...validation.Error) apiErrorResponse {
+	res := apiErrorResponse{Errors: make([]apiError, 0, len(errs))}
+	for _, err := range errs {
+		res.Errors = append(res.Errors, apiError{Code: err.Code(), Message: err.Message()})
+	}
+
+	return res
+}
+
+func (a *Application) writeJSON(w http.ResponseWriter, r *http.Request, status int, data any) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		a.apiServerError(w, r, "Failed to encode API response.", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+func (a *Application) apiServerError(w http.ResponseWriter, r *http.Request, message string, err error, attrs ...any) {
+	attrs = append(attrs, "error", err)
+	a.Logger.ErrorContext(r.Context(), message, attrs...)
+
+	t := a.translator(r)
+	a.writeJSON(w, r, http.StatusInternalServerError, makeAPIErrorResponse(validation.MakeError("server", t.T("api.error.server"))))
+}
+
+func (a *Application) apiUnauthorized(w http.ResponseWriter, r *http.Request) {
+	t := a.translator(r)
+
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	a.writeJSON(w, r, http.StatusUnauthorized, makeAPIErrorResponse(validation.MakeError("unauthorized", t.T("api.error.unauthorized"))))
+}
+
+func (a *Application) apiForbidden(w http.ResponseWriter, r *http.Request, scope string) {
+	t := a.translator(r)
+
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="insufficient_scope", scope="`+scope+`"`)
+	a.writeJSON(w, r, http.StatusForbidden, makeAPIErrorResponse(validation.MakeError("scope", t.T("api.error.scope", scope))))
+}
@@ -2,21 +2,28 @@ package application
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/cdriehuys/stuff2/internal/forms"
 	"github.com/cdriehuys/stuff2/internal/i18n"
 	"github.com/cdriehuys/stuff2/internal/models"
+	"github.com/cdriehuys/stuff2/internal/oidc"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/google/uuid"
 	"github.com/justinas/nosurf"
 )
 
 type SessionManager interface {
+	Destroy(ctx context.Context) error
 	Get(ctx context.Context, key string) any
+	Iterate(ctx context.Context, fn func(context.Context) error) error
 	LoadAndSave(http.Handler) http.Handler
+	PopString(ctx context.Context, key string) string
 	Put(ctx context.Context, key string, value any)
 }
 
@@ -24,9 +31,52 @@ type TemplateEngine interface {
 	Render(io.Writer, string, any) error
 }
 
+type APITokenModel interface {
+	Authenticate(ctx context.Context, token string) (models.APIToken, error)
+	Create(ctx context.Context, userID uuid.UUID, token models.NewAPIToken) (string, error)
+	ListForUser(ctx context.Context, userID uuid.UUID) ([]models.APIToken, error)
+	Revoke(ctx context.Context, userID uuid.UUID, id int32) error
+}
+
+type AuditModel interface {
+	ListForEntity(ctx context.Context, householdID uuid.UUID, entityType string, entityID uuid.UUID) ([]models.AuditEvent, error)
+	ListSecurityLog(ctx context.Context, userID uuid.UUID) ([]models.AuditEvent, error)
+}
+
+type HouseholdModel interface {
+	AcceptInvitation(ctx context.Context, userID uuid.UUID, token string) (models.Household, error)
+	Create(ctx context.Context, userID uuid.UUID, household models.NewHousehold) (models.Household, error)
+	GetForUser(ctx context.Context, userID uuid.UUID, householdID uuid.UUID) (models.Household, error)
+	Invite(ctx context.Context, userID uuid.UUID, householdID uuid.UUID, invitation models.NewHouseholdInvitation) error
+	ListForUser(ctx context.Context, userID uuid.UUID) ([]models.Household, error)
+	ListMembers(ctx context.Context, userID uuid.UUID, householdID uuid.UUID) ([]models.HouseholdMember, error)
+}
+
+type LocationModel interface {
+	Create(ctx context.Context, userID uuid.UUID, householdID uuid.UUID, location models.NewLocation) (models.Location, error)
+	Get(ctx context.Context, householdID uuid.UUID, id uuid.UUID) (models.LocationDetail, error)
+	GetHouseholdID(ctx context.Context, userID uuid.UUID, id uuid.UUID) (uuid.UUID, error)
+	ListTree(ctx context.Context, householdID uuid.UUID) ([]models.LocationNode, error)
+	Move(ctx context.Context, userID uuid.UUID, householdID uuid.UUID, id uuid.UUID, move models.LocationMove) error
+}
+
+// OIDCProvider is an external identity provider that users can sign in with.
+type OIDCProvider interface {
+	AuthCodeURL(state string, nonce string, verifier string) string
+	DisplayName() string
+	Exchange(ctx context.Context, code string, verifier string, nonce string) (oidc.Claims, error)
+	Name() string
+}
+
 type UserModel interface {
 	Authenticate(ctx context.Context, email string, password string) (models.User, error)
+	AuthenticateExternalIdentity(ctx context.Context, identity models.ExternalIdentity) (models.User, error)
+	AuthenticateLoginLink(ctx context.Context, token string) (models.User, error)
+	Delete(ctx context.Context, userID uuid.UUID, password string) error
+	GetDataExport(ctx context.Context, userID uuid.UUID, token string) (models.DataExport, error)
 	Register(context.Context, models.NewUser) error
+	RequestDataExport(ctx context.Context, userID uuid.UUID) error
+	RequestLoginLink(ctx context.Context, email string) error
 	VerifyEmail(ctx context.Context, token string) error
 }
 
@@ -37,16 +87,54 @@ type TemplateData struct {
 	Translator i18n.Translator
 
 	Form forms.Form
+
+	OIDCProviders []OIDCProvider
+
+	// Households are the households the user belongs to, and ActiveHousehold is the one they are
+	// currently working in. Both are only populated for authenticated requests.
+	Households      []models.Household
+	ActiveHousehold models.Household
+
+	Household        models.Household
+	HouseholdMembers []models.HouseholdMember
+	HouseholdRoles   []models.HouseholdRole
+
+	Location     models.LocationDetail
+	LocationTree []models.LocationNode
+
+	LabelLayouts []LabelLayout
+	// LabelLocations are the locations chosen to print labels for.
+	LabelLocations map[uuid.UUID]bool
+	LabelSheet     LabelSheet
+
+	// AuditEvents are the history of the page's subject, newest first.
+	AuditEvents []models.AuditEvent
+
+	APITokens []models.APIToken
+	// CreatedAPIToken is the plaintext of a newly created API token. It is only available in the
+	// response to the request that created it.
+	CreatedAPIToken string
 }
 
 type Application struct {
 	Logger *slog.Logger
 
+	// BaseURL is where the application is served from. It is used to build links that are used
+	// outside of the application, such as the QR codes on labels.
+	BaseURL *url.URL
+
 	Session    SessionManager
 	Templates  TemplateEngine
 	Translator *ut.UniversalTranslator
 
-	Users UserModel
+	// OIDCProviders are offered on the login page, in order.
+	OIDCProviders []OIDCProvider
+
+	APITokens  APITokenModel
+	Audit      AuditModel
+	Households HouseholdModel
+	Locations  LocationModel
+	Users      UserModel
 }
 
 func (a *Application) translator(r *http.Request) i18n.Translator {
@@ -55,16 +143,27 @@ func (a *Application) translator(r *http.Request) i18n.Translator {
 
 func (a *Application) templateData(r *http.Request) TemplateData {
 	data := TemplateData{
-		CSRFToken: nosurf.Token(r),
+		CSRFToken:     nosurf.Token(r),
+		OIDCProviders: a.OIDCProviders,
 	}
 
-	if t, ok := r.Context().Value(translatorContextKey).(i18n.Translator); ok {
+	if t, ok := i18n.Lookup(r.Context()); ok {
 		data.Translator = t
 	}
 
+	if households, ok := r.Context().Value(householdsContextKey).(requestHouseholds); ok {
+		data.Households = households.all
+		data.ActiveHousehold = households.active
+	}
+
 	return data
 }
 
+// LocationContents describes how many locations are inside another location.
+func (d TemplateData) LocationContents(count int) string {
+	return d.Translator.C("location.contents.count", float64(count), 0, d.Translator.FmtNumber(float64(count), 0))
+}
+
 func (a *Application) serverError(w http.ResponseWriter, r *http.Request, message string, err error, attrs ...any) {
 	attrs = append(attrs, "error", err)
 	a.Logger.ErrorContext(r.Context(), message, attrs...)
@@ -94,6 +193,21 @@ func (a *Application) setAuthenticatedUser(r *http.Request, userID uuid.UUID) {
 	a.Session.Put(r.Context(), sessionKeyUserID, userID.String())
 }
 
+const sessionKeyRedirectAfterLogin = "redirect_after_login"
+
+// redirectAfterLogin sends a user who just logged in to the page they were trying to view before
+// logging in, or to the app if there isn't one.
+func (a *Application) redirectAfterLogin(w http.ResponseWriter, r *http.Request) {
+	target := a.Session.PopString(r.Context(), sessionKeyRedirectAfterLogin)
+
+	// Only paths on this site are followed so the redirect can't be used to send users elsewhere.
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") {
+		target = "/app"
+	}
+
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
 func (a *Application) getAuthenticatedUserID(r *http.Request) uuid.UUID {
 	rawID, ok := a.Session.Get(r.Context(), sessionKeyUserID).(string)
 	if !ok {
@@ -111,3 +225,40 @@ func (a *Application) getAuthenticatedUserID(r *http.Request) uuid.UUID {
 func (a *Application) isAuthenticated(r *http.Request) bool {
 	return a.getAuthenticatedUserID(r) != uuid.Nil
 }
+
+const sessionKeyHouseholdID = "household_id"
+
+// activeHousehold returns the household the user is currently working in. A user who doesn't
+// belong to any households has no active household.
+func (a *Application) activeHousehold(r *http.Request) (models.Household, bool) {
+	households, ok := r.Context().Value(householdsContextKey).(requestHouseholds)
+	if !ok || households.active.ID == uuid.Nil {
+		return models.Household{}, false
+	}
+
+	return households.active, true
+}
+
+// apiToken returns the API token used to authenticate an API request.
+func (a *Application) apiToken(r *http.Request) (models.APIToken, bool) {
+	token, ok := r.Context().Value(apiTokenContextKey).(models.APIToken)
+
+	return token, ok
+}
+
+// destroyUserSessions logs the given user out of every session, including the current request's.
+func (a *Application) destroyUserSessions(r *http.Request, userID uuid.UUID) error {
+	destroyIfOwned := func(ctx context.Context) error {
+		if rawID, ok := a.Session.Get(ctx, sessionKeyUserID).(string); ok && rawID == userID.String() {
+			return a.Session.Destroy(ctx)
+		}
+
+		return nil
+	}
+
+	if err := a.Session.Iterate(r.Context(), destroyIfOwned); err != nil {
+		return fmt.Errorf("destroying sessions for user %s: %v", userID.String(), err)
+	}
+
+	return a.Session.Destroy(r.Context())
+}
@@ -13,6 +13,10 @@ type Emailer interface {
 }
 
 type EmailTemplateData struct {
+	DataExportLink   string
+	HouseholdName    string
+	InvitationLink   string
+	LoginLink        string
 	VerificationLink string
 }
 
@@ -36,6 +40,18 @@ func NewEmailVerifier(logger *slog.Logger, emailer Emailer, templates TemplateEn
 	}
 }
 
+func (v *EmailVerifier) DataExport(ctx context.Context, email string, token string) error {
+	exportLink := v.baseDomain.JoinPath("account", "export", token).String()
+	data := EmailTemplateData{DataExportLink: exportLink}
+
+	body, err := v.render("data-export.txt", data)
+	if err != nil {
+		return fmt.Errorf("rendering data export template: %v", err)
+	}
+
+	return v.emailer.Send(ctx, email, v.sender, "Your Data Export", body)
+}
+
 func (v *EmailVerifier) DuplicateRegistration(ctx context.Context, email string) error {
 	body, err := v.render("duplicate-email.txt", EmailTemplateData{})
 	if err != nil {
@@ -45,6 +61,30 @@ func (v *EmailVerifier) DuplicateRegistration(ctx context.Context, email string)
 	return v.emailer.Send(ctx, email, v.sender, "Duplicate Registration", body)
 }
 
+func (v *EmailVerifier) HouseholdInvitation(ctx context.Context, email string, householdName string, token string) error {
+	invitationLink := v.baseDomain.JoinPath("invitations", token).String()
+	data := EmailTemplateData{HouseholdName: householdName, InvitationLink: invitationLink}
+
+	body, err := v.render("household-invitation.txt", data)
+	if err != nil {
+		return fmt.Errorf("rendering household invitation template: %v", err)
+	}
+
+	return v.emailer.Send(ctx, email, v.sender, "You're Invited to a Household", body)
+}
+
+func (v *EmailVerifier) LoginLink(ctx context.Context, email string, token string) error {
+	loginLink := v.baseDomain.JoinPath("login", "link", token).String()
+	data := EmailTemplateData{LoginLink: loginLink}
+
+	body, err := v.render("login-link.txt", data)
+	if err != nil {
+		return fmt.Errorf("rendering login link template: %v", err)
+	}
+
+	return v.emailer.Send(ctx, email, v.sender, "Your Sign-In Link", body)
+}
+
 func (v *EmailVerifier) NewEmail(ctx context.Context, email string, token string) error {
 	verificationLink := v.baseDomain.JoinPath("verify-email", token).String()
 	data := EmailTemplateData{VerificationLink: verificationLink}
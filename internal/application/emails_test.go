@@ -13,6 +13,9 @@ import (
 )
 
 const (
+	expectedDataExportPath          = "account/export"
+	expectedInvitationPath          = "invitations"
+	expectedLoginLinkPath           = "login/link"
 	expectedVerificationPathSegment = "verify-email"
 )
 
@@ -58,6 +61,67 @@ func (m *capturingMailer) Send(ctx context.Context, to string, from string, subj
 	return m.sendError
 }
 
+func TestEmailVerifier_DataExport(t *testing.T) {
+	baseDomain, err := url.Parse("https://example.com")
+	if err != nil {
+		t.Fatalf("Invalid base domain: %v", err)
+	}
+
+	testCases := []struct {
+		name             string
+		mailer           capturingMailer
+		templates        mockEmailTemplateEngine
+		email            string
+		token            string
+		wantEmailTo      string
+		wantEmailSubject string
+		wantLink         string
+		wantErr          bool
+	}{
+		{
+			name:             "successful send",
+			email:            "user@example.com",
+			token:            "secret-token",
+			wantEmailTo:      "user@example.com",
+			wantEmailSubject: "Your Data Export",
+			wantLink:         baseDomain.JoinPath(expectedDataExportPath, "secret-token").String(),
+		},
+		{
+			name: "rendering error",
+			templates: mockEmailTemplateEngine{
+				renderError: errors.New("rendering failed"),
+			},
+			email:   "user@example.com",
+			token:   "secret-token",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := application.NewEmailVerifier(slog.New(slog.DiscardHandler), &tt.mailer, &tt.templates, baseDomain, "admin@localhost")
+
+			err := verifier.DataExport(t.Context(), tt.email, tt.token)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error presence %v, got error %#v", tt.wantErr, err)
+			}
+
+			if got := tt.mailer.sendTo; got != tt.wantEmailTo {
+				t.Errorf("Expected email to be sent to %q, got %q", tt.wantEmailTo, got)
+			}
+
+			if got := tt.mailer.sendSubject; got != tt.wantEmailSubject {
+				t.Errorf("Expected email subject %q, got %q", tt.wantEmailSubject, got)
+			}
+
+			if got := tt.templates.renderedData.DataExportLink; got != tt.wantLink {
+				t.Errorf("Expected export link %q, got %q", tt.wantLink, got)
+			}
+		})
+	}
+}
+
 func TestEmailVerifier_DuplicateRegistration(t *testing.T) {
 	baseDomain, err := url.Parse("https://example.com")
 	if err != nil {
@@ -119,6 +183,134 @@ func TestEmailVerifier_DuplicateRegistration(t *testing.T) {
 	}
 }
 
+func TestEmailVerifier_HouseholdInvitation(t *testing.T) {
+	baseDomain, err := url.Parse("https://example.com")
+	if err != nil {
+		t.Fatalf("Invalid base domain: %v", err)
+	}
+
+	testCases := []struct {
+		name              string
+		mailer            capturingMailer
+		templates         mockEmailTemplateEngine
+		email             string
+		token             string
+		wantEmailTo       string
+		wantEmailSubject  string
+		wantHouseholdName string
+		wantLink          string
+		wantErr           bool
+	}{
+		{
+			name:              "successful send",
+			email:             "friend@example.com",
+			token:             "secret-token",
+			wantEmailTo:       "friend@example.com",
+			wantEmailSubject:  "You're Invited to a Household",
+			wantHouseholdName: "The Cabin",
+			wantLink:          baseDomain.JoinPath(expectedInvitationPath, "secret-token").String(),
+		},
+		{
+			name: "rendering error",
+			templates: mockEmailTemplateEngine{
+				renderError: errors.New("rendering failed"),
+			},
+			email:   "friend@example.com",
+			token:   "secret-token",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := application.NewEmailVerifier(slog.New(slog.DiscardHandler), &tt.mailer, &tt.templates, baseDomain, "admin@localhost")
+
+			err := verifier.HouseholdInvitation(t.Context(), tt.email, "The Cabin", tt.token)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error presence %v, got error %#v", tt.wantErr, err)
+			}
+
+			if got := tt.mailer.sendTo; got != tt.wantEmailTo {
+				t.Errorf("Expected email to be sent to %q, got %q", tt.wantEmailTo, got)
+			}
+
+			if got := tt.mailer.sendSubject; got != tt.wantEmailSubject {
+				t.Errorf("Expected email subject %q, got %q", tt.wantEmailSubject, got)
+			}
+
+			if got := tt.templates.renderedData.HouseholdName; got != tt.wantHouseholdName {
+				t.Errorf("Expected household name %q, got %q", tt.wantHouseholdName, got)
+			}
+
+			if got := tt.templates.renderedData.InvitationLink; got != tt.wantLink {
+				t.Errorf("Expected invitation link %q, got %q", tt.wantLink, got)
+			}
+		})
+	}
+}
+
+func TestEmailVerifier_LoginLink(t *testing.T) {
+	baseDomain, err := url.Parse("https://example.com")
+	if err != nil {
+		t.Fatalf("Invalid base domain: %v", err)
+	}
+
+	testCases := []struct {
+		name             string
+		mailer           capturingMailer
+		templates        mockEmailTemplateEngine
+		email            string
+		token            string
+		wantEmailTo      string
+		wantEmailSubject string
+		wantLink         string
+		wantErr          bool
+	}{
+		{
+			name:             "successful send",
+			email:            "user@example.com",
+			token:            "secret-token",
+			wantEmailTo:      "user@example.com",
+			wantEmailSubject: "Your Sign-In Link",
+			wantLink:         baseDomain.JoinPath(expectedLoginLinkPath, "secret-token").String(),
+		},
+		{
+			name: "rendering error",
+			templates: mockEmailTemplateEngine{
+				renderError: errors.New("rendering failed"),
+			},
+			email:   "user@example.com",
+			token:   "secret-token",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := application.NewEmailVerifier(slog.New(slog.DiscardHandler), &tt.mailer, &tt.templates, baseDomain, "admin@localhost")
+
+			err := verifier.LoginLink(t.Context(), tt.email, tt.token)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error presence %v, got error %#v", tt.wantErr, err)
+			}
+
+			if got := tt.mailer.sendTo; got != tt.wantEmailTo {
+				t.Errorf("Expected email to be sent to %q, got %q", tt.wantEmailTo, got)
+			}
+
+			if got := tt.mailer.sendSubject; got != tt.wantEmailSubject {
+				t.Errorf("Expected email subject %q, got %q", tt.wantEmailSubject, got)
+			}
+
+			if got := tt.templates.renderedData.LoginLink; got != tt.wantLink {
+				t.Errorf("Expected login link %q, got %q", tt.wantLink, got)
+			}
+		})
+	}
+}
+
 func TestEmailVerifier_NewEmail(t *testing.T) {
 	testCases := []struct {
 		name             string
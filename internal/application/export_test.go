@@ -0,0 +1,19 @@
+package application
+
+import "net/http"
+
+type patternRecorder struct {
+	patterns []string
+}
+
+func (r *patternRecorder) Handle(pattern string, _ http.Handler) {
+	r.patterns = append(r.patterns, pattern)
+}
+
+// RegisteredPatterns lists the pattern of every route registered by [Application.Routes].
+func (a *Application) RegisteredPatterns() []string {
+	recorder := &patternRecorder{}
+	a.registerRoutes(recorder)
+
+	return recorder.patterns
+}
@@ -0,0 +1,232 @@
+package application
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/cdriehuys/stuff2/internal/forms"
+	"github.com/cdriehuys/stuff2/internal/models"
+	"github.com/cdriehuys/stuff2/internal/validation"
+)
+
+func accountForm() forms.Form {
+	return forms.Form{
+		Fields: map[string]forms.Field{
+			"lifetime": {Name: "lifetime"},
+			"name":     {Name: "name"},
+			"password": {Name: "password"},
+			"scopes":   {Name: "scopes"},
+		},
+	}
+}
+
+// renderAccount renders the account page, which also lists the user's API tokens and their
+// security log.
+func (a *Application) renderAccount(w http.ResponseWriter, r *http.Request, status int, data TemplateData) {
+	userID := a.getAuthenticatedUserID(r)
+
+	tokens, err := a.APITokens.ListForUser(r.Context(), userID)
+	if err != nil {
+		a.serverError(w, r, "Failed to list API tokens.", err)
+		return
+	}
+
+	securityLog, err := a.Audit.ListSecurityLog(r.Context(), userID)
+	if err != nil {
+		a.serverError(w, r, "Failed to list security log.", err)
+		return
+	}
+
+	data.APITokens = tokens
+	data.AuditEvents = securityLog
+
+	w.WriteHeader(status)
+	a.render(w, r, "account.html", data)
+}
+
+func (a *Application) accountGet(w http.ResponseWriter, r *http.Request) {
+	data := a.templateData(r)
+	data.Form = accountForm()
+
+	a.renderAccount(w, r, http.StatusOK, data)
+}
+
+func (a *Application) accountDeletePost(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	password := r.PostFormValue("password")
+	userID := a.getAuthenticatedUserID(r)
+
+	if err := a.Users.Delete(r.Context(), userID, password); err != nil {
+		if errors.Is(err, models.ErrInvalidCredentials) {
+			t := a.translator(r)
+
+			form := accountForm()
+			form.Fields["password"] = forms.Field{
+				Name:   "password",
+				Errors: []validation.Error{validation.MakeError("credentials", t.T("account.delete.password.invalid"))},
+			}
+
+			data := a.templateData(r)
+			data.Form = form
+
+			a.renderAccount(w, r, http.StatusUnauthorized, data)
+			return
+		}
+
+		a.serverError(w, r, "Failed to delete user.", err)
+		return
+	}
+
+	if err := a.destroyUserSessions(r, userID); err != nil {
+		a.serverError(w, r, "Failed to log out deleted user.", err)
+		return
+	}
+
+	http.Redirect(w, r, "/account/deleted", http.StatusSeeOther)
+}
+
+func (a *Application) accountDeleted(w http.ResponseWriter, r *http.Request) {
+	a.render(w, r, "account-deleted.html", a.templateData(r))
+}
+
+func (a *Application) accountExportPost(w http.ResponseWriter, r *http.Request) {
+	if err := a.Users.RequestDataExport(r.Context(), a.getAuthenticatedUserID(r)); err != nil {
+		a.serverError(w, r, "Failed to request data export.", err)
+		return
+	}
+
+	http.Redirect(w, r, "/account/export-requested", http.StatusSeeOther)
+}
+
+func (a *Application) accountExportRequested(w http.ResponseWriter, r *http.Request) {
+	a.render(w, r, "account-export-requested.html", a.templateData(r))
+}
+
+func (a *Application) accountExportGet(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+
+	export, err := a.Users.GetDataExport(r.Context(), a.getAuthenticatedUserID(r), token)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidDataExportToken) {
+			t := a.translator(r)
+
+			form := accountForm()
+			form.Errors = []validation.Error{validation.MakeError("invalid", t.T("account.export.token.invalid"))}
+
+			data := a.templateData(r)
+			data.Form = form
+
+			a.renderAccount(w, r, http.StatusNotFound, data)
+			return
+		}
+
+		a.serverError(w, r, "Failed to retrieve data export.", err)
+		return
+	}
+
+	// Build the archive up front so a failure can still produce an error page.
+	var archive bytes.Buffer
+	if err := writeDataExport(&archive, export); err != nil {
+		a.serverError(w, r, "Failed to build data export archive.", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="stuff-export.zip"`)
+	w.Write(archive.Bytes())
+}
+
+func (a *Application) accountTokensPost(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	rawName := r.PostFormValue("name")
+	rawLifetime := r.PostFormValue("lifetime")
+	rawScopes := r.PostForm["scopes"]
+
+	newToken, err := models.MakeNewAPIToken(r.Context(), rawName, rawScopes, rawLifetime)
+	if err != nil {
+		tokenErrors := models.NewAPITokenErrors{}
+		if errors.As(err, &tokenErrors) {
+			form := accountForm()
+			form.Fields["name"] = forms.Field{Name: "name", Value: rawName, Errors: tokenErrors.Name}
+			form.Fields["scopes"] = forms.Field{Name: "scopes", Errors: tokenErrors.Scopes}
+			form.Fields["lifetime"] = forms.Field{Name: "lifetime", Value: rawLifetime, Errors: tokenErrors.Lifetime}
+
+			data := a.templateData(r)
+			data.Form = form
+
+			a.renderAccount(w, r, http.StatusOK, data)
+			return
+		}
+
+		a.serverError(w, r, "Failed to validate API token.", err)
+		return
+	}
+
+	plaintext, err := a.APITokens.Create(r.Context(), a.getAuthenticatedUserID(r), newToken)
+	if err != nil {
+		a.serverError(w, r, "Failed to create API token.", err)
+		return
+	}
+
+	// The token is rendered directly instead of redirecting because this is the only time the
+	// plaintext token is available.
+	data := a.templateData(r)
+	data.Form = accountForm()
+	data.CreatedAPIToken = plaintext
+
+	a.renderAccount(w, r, http.StatusCreated, data)
+}
+
+func (a *Application) accountTokenRevokePost(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 32)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if err := a.APITokens.Revoke(r.Context(), a.getAuthenticatedUserID(r), int32(id)); err != nil {
+		a.serverError(w, r, "Failed to revoke API token.", err)
+		return
+	}
+
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
+
+func writeDataExport(w io.Writer, export models.DataExport) error {
+	archive := zip.NewWriter(w)
+
+	files := []struct {
+		name string
+		data any
+	}{
+		{name: "user.json", data: export.User},
+		{name: "api_tokens.json", data: export.APITokens},
+		{name: "external_identities.json", data: export.ExternalIdentities},
+		{name: "household_memberships.json", data: export.HouseholdMemberships},
+		{name: "household_invitations.json", data: export.HouseholdInvitations},
+		{name: "security_log.json", data: export.SecurityLog},
+	}
+
+	for _, file := range files {
+		f, err := archive.Create(file.name)
+		if err != nil {
+			return fmt.Errorf("adding %s to archive: %v", file.name, err)
+		}
+
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return fmt.Errorf("encoding %s: %v", file.name, err)
+		}
+	}
+
+	return archive.Close()
+}
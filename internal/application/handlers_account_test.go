@@ -0,0 +1,710 @@
+package application_test
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cdriehuys/stuff2/internal/application"
+	"github.com/cdriehuys/stuff2/internal/application/testutils"
+	"github.com/cdriehuys/stuff2/internal/models"
+	"github.com/cdriehuys/stuff2/internal/models/mocks"
+	"github.com/cdriehuys/stuff2/internal/validation"
+	"github.com/google/uuid"
+)
+
+func TestApplication_accountGet(t *testing.T) {
+	testCases := []struct {
+		name          string
+		authenticated bool
+		wantStatus    int
+	}{
+		{
+			name:       "unauthenticated",
+			wantStatus: http.StatusSeeOther,
+		},
+		{
+			name:          "authenticated",
+			authenticated: true,
+			wantStatus:    http.StatusOK,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testutils.NewTestApplication(t)
+			app.APITokens = &mocks.APITokenModel{}
+			app.Users = &mocks.UserModel{}
+
+			ts := testutils.NewTestServer(t, app.Routes())
+			defer ts.Close()
+
+			if tt.authenticated {
+				logIn(t, app, ts, uuid.New())
+			}
+
+			res := ts.Get(t, "/account")
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+		})
+	}
+}
+
+func TestApplication_accountDeletePost(t *testing.T) {
+	userID := uuid.New()
+
+	testCases := []struct {
+		name              string
+		users             mocks.UserModel
+		templates         CapturingTemplateEngine[application.TemplateData]
+		password          string
+		wantStatus        int
+		wantErrorCode     string
+		wantRedirect      *WantRedirect
+		wantAuthenticated bool
+	}{
+		{
+			name: "incorrect password",
+			users: mocks.UserModel{
+				DeleteError: models.ErrInvalidCredentials,
+			},
+			password:          "wrong",
+			wantStatus:        http.StatusUnauthorized,
+			wantErrorCode:     "credentials",
+			wantAuthenticated: true,
+		},
+		{
+			name: "deletion error",
+			users: mocks.UserModel{
+				DeleteError: errors.New("everything broke"),
+			},
+			password:          "password",
+			wantStatus:        http.StatusInternalServerError,
+			wantAuthenticated: true,
+		},
+		{
+			name:     "success",
+			password: "password",
+			wantRedirect: &WantRedirect{
+				Status:   http.StatusSeeOther,
+				Location: "/account/deleted",
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testutils.NewTestApplication(t)
+			app.APITokens = &mocks.APITokenModel{}
+			app.Templates = &tt.templates
+			app.Users = &tt.users
+
+			ts := testutils.NewTestServer(t, app.Routes())
+			defer ts.Close()
+
+			logIn(t, app, ts, userID)
+
+			form := csrfFormValues(t, app, ts, "/account")
+			form.Add("password", tt.password)
+
+			res := ts.PostForm(t, "/account/delete", form)
+
+			if tt.wantStatus != 0 && res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			if got := tt.users.DeletedUserID; got != userID {
+				t.Errorf("Expected deletion of user %v, got %v", userID, got)
+			}
+
+			if got := tt.users.DeletedPassword; got != tt.password {
+				t.Errorf("Expected deletion password %q, got %q", tt.password, got)
+			}
+
+			if tt.wantErrorCode != "" {
+				errs := tt.templates.RenderedData.Form.Fields["password"].Errors
+				if !slices.ContainsFunc(errs, func(e validation.Error) bool { return e.Code() == tt.wantErrorCode }) {
+					t.Errorf("Expected error with code %q, got errors %v", tt.wantErrorCode, errs)
+				}
+			}
+
+			if want := tt.wantRedirect; want != nil {
+				if res.Status != want.Status {
+					t.Errorf("Expected status %d, got %d", want.Status, res.Status)
+				}
+
+				if got := res.Headers.Get("Location"); got != want.Location {
+					t.Errorf("Expected redirect location %q, got %q", want.Location, got)
+				}
+			}
+
+			authRes := ts.Get(t, "/app")
+
+			if tt.wantAuthenticated && authRes.Status != http.StatusOK {
+				t.Errorf("Expected user to be authenticated, but got a %d status for '/app'", authRes.Status)
+			}
+
+			if !tt.wantAuthenticated && authRes.Status == http.StatusOK {
+				t.Errorf("Expected user to be logged out, but they were able to retrieve '/app'")
+			}
+		})
+	}
+}
+
+func TestApplication_accountDeletePost_OtherSessions(t *testing.T) {
+	userID := uuid.New()
+	otherUserID := uuid.New()
+
+	app := testutils.NewTestApplication(t)
+	app.APITokens = &mocks.APITokenModel{}
+	app.Users = &mocks.UserModel{}
+
+	routes := app.Routes()
+
+	deleting := testutils.NewTestServer(t, routes)
+	defer deleting.Close()
+
+	sameUser := testutils.NewTestServer(t, routes)
+	defer sameUser.Close()
+
+	otherUser := testutils.NewTestServer(t, routes)
+	defer otherUser.Close()
+
+	logIn(t, app, deleting, userID)
+	logIn(t, app, sameUser, userID)
+	logIn(t, app, otherUser, otherUserID)
+
+	form := csrfFormValues(t, app, deleting, "/account")
+	form.Add("password", "password")
+
+	if res := deleting.PostForm(t, "/account/delete", form); res.Status != http.StatusSeeOther {
+		t.Fatalf("Expected status %d, got %d", http.StatusSeeOther, res.Status)
+	}
+
+	if res := sameUser.Get(t, "/app"); res.Status == http.StatusOK {
+		t.Error("Expected the deleted user's other sessions to be logged out.")
+	}
+
+	if res := otherUser.Get(t, "/app"); res.Status != http.StatusOK {
+		t.Errorf("Expected other users to stay logged in, got status %d", res.Status)
+	}
+}
+
+func TestApplication_accountDeleted(t *testing.T) {
+	app := testutils.NewTestApplication(t)
+	ts := testutils.NewTestServer(t, app.Routes())
+	defer ts.Close()
+
+	res := ts.Get(t, "/account/deleted")
+
+	if res.Status != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, res.Status)
+	}
+}
+
+func TestApplication_accountExportPost(t *testing.T) {
+	userID := uuid.New()
+
+	testCases := []struct {
+		name         string
+		users        mocks.UserModel
+		wantStatus   int
+		wantRedirect *WantRedirect
+	}{
+		{
+			name: "request error",
+			users: mocks.UserModel{
+				RequestDataExportError: errors.New("everything broke"),
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			wantRedirect: &WantRedirect{
+				Status:   http.StatusSeeOther,
+				Location: "/account/export-requested",
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testutils.NewTestApplication(t)
+			app.APITokens = &mocks.APITokenModel{}
+			app.Templates = &CapturingTemplateEngine[application.TemplateData]{}
+			app.Users = &tt.users
+
+			ts := testutils.NewTestServer(t, app.Routes())
+			defer ts.Close()
+
+			logIn(t, app, ts, userID)
+
+			form := csrfFormValues(t, app, ts, "/account")
+			res := ts.PostForm(t, "/account/export", form)
+
+			if tt.wantStatus != 0 && res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			if got := tt.users.RequestDataExportUserID; got != userID {
+				t.Errorf("Expected export for user %v, got %v", userID, got)
+			}
+
+			if want := tt.wantRedirect; want != nil {
+				if res.Status != want.Status {
+					t.Errorf("Expected status %d, got %d", want.Status, res.Status)
+				}
+
+				if got := res.Headers.Get("Location"); got != want.Location {
+					t.Errorf("Expected redirect location %q, got %q", want.Location, got)
+				}
+			}
+		})
+	}
+}
+
+func TestApplication_accountExportRequested(t *testing.T) {
+	app := testutils.NewTestApplication(t)
+	app.Users = &mocks.UserModel{}
+
+	ts := testutils.NewTestServer(t, app.Routes())
+	defer ts.Close()
+
+	logIn(t, app, ts, uuid.New())
+
+	res := ts.Get(t, "/account/export-requested")
+
+	if res.Status != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, res.Status)
+	}
+}
+
+func TestApplication_accountExportGet(t *testing.T) {
+	userID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		users         mocks.UserModel
+		token         string
+		wantStatus    int
+		wantErrorCode string
+		wantEmail     string
+		wantFiles     []string
+	}{
+		{
+			name: "invalid token",
+			users: mocks.UserModel{
+				GetDataExportError: models.ErrInvalidDataExportToken,
+			},
+			token:         "invalid",
+			wantStatus:    http.StatusNotFound,
+			wantErrorCode: "invalid",
+		},
+		{
+			name: "export error",
+			users: mocks.UserModel{
+				GetDataExportError: errors.New("everything broke"),
+			},
+			token:      "valid",
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			users: mocks.UserModel{
+				GetDataExportReturn: models.DataExport{
+					User:                 models.UserData{ID: userID, Email: "test@example.com"},
+					APITokens:            []models.APITokenData{{ID: 1, Name: "Reports"}},
+					ExternalIdentities:   []models.ExternalIdentityData{{Issuer: "https://id.example.com", Subject: "subject"}},
+					HouseholdMemberships: []models.HouseholdMembershipData{{HouseholdID: uuid.New(), HouseholdName: "Home"}},
+					HouseholdInvitations: []models.HouseholdInvitationData{{ID: 2, Email: "friend@example.com"}},
+					SecurityLog:          []models.SecurityEventData{{ID: 3, Action: models.AuditActionAPITokenCreated}},
+				},
+			},
+			token:      "valid",
+			wantStatus: http.StatusOK,
+			wantEmail:  "test@example.com",
+			wantFiles: []string{
+				"user.json",
+				"api_tokens.json",
+				"external_identities.json",
+				"household_memberships.json",
+				"household_invitations.json",
+				"security_log.json",
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			templates := &CapturingTemplateEngine[application.TemplateData]{}
+
+			app := testutils.NewTestApplication(t)
+			app.APITokens = &mocks.APITokenModel{}
+			app.Templates = templates
+			app.Users = &tt.users
+
+			ts := testutils.NewTestServer(t, app.Routes())
+			defer ts.Close()
+
+			logIn(t, app, ts, userID)
+
+			res := ts.Get(t, "/account/export/"+tt.token)
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			if got := tt.users.GetDataExportUserID; got != userID {
+				t.Errorf("Expected export for user %v, got %v", userID, got)
+			}
+
+			if got := tt.users.GetDataExportToken; got != tt.token {
+				t.Errorf("Expected export token %q, got %q", tt.token, got)
+			}
+
+			if tt.wantErrorCode != "" {
+				if !slices.ContainsFunc(templates.RenderedData.Form.Errors, func(e validation.Error) bool { return e.Code() == tt.wantErrorCode }) {
+					t.Errorf("Expected error with code %q, got errors %v", tt.wantErrorCode, templates.RenderedData.Form.Errors)
+				}
+			}
+
+			if tt.wantEmail != "" {
+				assertExportedEmail(t, res.Body, tt.wantEmail)
+			}
+
+			if tt.wantFiles != nil {
+				assertExportedFiles(t, res.Body, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func assertExportedFiles(t *testing.T, body string, wantFiles []string) {
+	archive, err := zip.NewReader(strings.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("Failed to read export archive: %v", err)
+	}
+
+	var gotFiles []string
+	for _, f := range archive.File {
+		gotFiles = append(gotFiles, f.Name)
+
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open exported file %s: %v", f.Name, err)
+		}
+
+		var data any
+		if err := json.NewDecoder(r).Decode(&data); err != nil {
+			t.Errorf("Failed to decode exported file %s: %v", f.Name, err)
+		}
+
+		r.Close()
+	}
+
+	if !slices.Equal(gotFiles, wantFiles) {
+		t.Errorf("Expected exported files %v, got %v", wantFiles, gotFiles)
+	}
+}
+
+func assertExportedEmail(t *testing.T, body string, wantEmail string) {
+	archive, err := zip.NewReader(strings.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("Failed to read export archive: %v", err)
+	}
+
+	f, err := archive.Open("user.json")
+	if err != nil {
+		t.Fatalf("Failed to open exported user: %v", err)
+	}
+
+	defer f.Close()
+
+	var user models.UserData
+	if err := json.NewDecoder(f).Decode(&user); err != nil {
+		t.Fatalf("Failed to decode exported user: %v", err)
+	}
+
+	if user.Email != wantEmail {
+		t.Errorf("Expected exported email %q, got %q", wantEmail, user.Email)
+	}
+}
+
+func TestApplication_accountGet_APITokens(t *testing.T) {
+	userID := uuid.New()
+
+	testCases := []struct {
+		name       string
+		apiTokens  mocks.APITokenModel
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "list error",
+			apiTokens: mocks.APITokenModel{
+				ListForUserError: errors.New("everything broke"),
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "tokens listed",
+			apiTokens: mocks.APITokenModel{
+				ListForUserList: []models.APIToken{
+					{ID: 1, Name: "Nightly Report", Scopes: []string{models.ScopeItemsRead}, ExpiresAt: time.Now()},
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "Nightly Report",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testutils.NewTestApplication(t)
+			app.APITokens = &tt.apiTokens
+			app.Users = &mocks.UserModel{}
+
+			ts := testutils.NewTestServer(t, app.Routes())
+			defer ts.Close()
+
+			logIn(t, app, ts, userID)
+
+			res := ts.Get(t, "/account")
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			if got := tt.apiTokens.ListedUserID; got != userID {
+				t.Errorf("Expected tokens listed for user %v, got %v", userID, got)
+			}
+
+			if !strings.Contains(res.Body, tt.wantBody) {
+				t.Errorf("Expected body to contain %q", tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestApplication_accountGet_SecurityLog(t *testing.T) {
+	userID := uuid.New()
+
+	testCases := []struct {
+		name       string
+		audit      mocks.AuditModel
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "list error",
+			audit: mocks.AuditModel{
+				ListSecurityLogError: errors.New("everything broke"),
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "events listed",
+			audit: mocks.AuditModel{
+				ListSecurityLogList: []models.AuditEvent{
+					{
+						ActorID:    userID,
+						ActorEmail: "test@example.com",
+						Action:     models.AuditActionLoggedInWithPassword,
+						CreatedAt:  time.Now(),
+					},
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "logged in with their password",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testutils.NewTestApplication(t)
+			app.APITokens = &mocks.APITokenModel{}
+			app.Audit = &tt.audit
+			app.Users = &mocks.UserModel{}
+
+			ts := testutils.NewTestServer(t, app.Routes())
+			defer ts.Close()
+
+			logIn(t, app, ts, userID)
+
+			res := ts.Get(t, "/account")
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			if got := tt.audit.ListedSecurityUserID; got != userID {
+				t.Errorf("Expected security log listed for user %v, got %v", userID, got)
+			}
+
+			if !strings.Contains(res.Body, tt.wantBody) {
+				t.Errorf("Expected body to contain %q", tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestApplication_accountTokensPost(t *testing.T) {
+	userID := uuid.New()
+
+	testCases := []struct {
+		name              string
+		apiTokens         mocks.APITokenModel
+		tokenName         string
+		scopes            []string
+		lifetime          string
+		wantStatus        int
+		wantErroredFields []string
+		wantCreated       bool
+		wantPlaintext     string
+	}{
+		{
+			name:              "validation errors",
+			wantStatus:        http.StatusOK,
+			wantErroredFields: []string{"name", "scopes", "lifetime"},
+		},
+		{
+			name: "create error",
+			apiTokens: mocks.APITokenModel{
+				CreateError: errors.New("everything broke"),
+			},
+			tokenName:   "Reports",
+			scopes:      []string{models.ScopeItemsRead},
+			lifetime:    "30",
+			wantStatus:  http.StatusInternalServerError,
+			wantCreated: true,
+		},
+		{
+			name: "success",
+			apiTokens: mocks.APITokenModel{
+				CreateReturn: "stuff_secret",
+			},
+			tokenName:     "Reports",
+			scopes:        []string{models.ScopeItemsRead},
+			lifetime:      "30",
+			wantStatus:    http.StatusCreated,
+			wantCreated:   true,
+			wantPlaintext: "stuff_secret",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			templates := &CapturingTemplateEngine[application.TemplateData]{}
+
+			app := testutils.NewTestApplication(t)
+			app.APITokens = &tt.apiTokens
+			app.Templates = templates
+			app.Users = &mocks.UserModel{}
+
+			ts := testutils.NewTestServer(t, app.Routes())
+			defer ts.Close()
+
+			logIn(t, app, ts, userID)
+
+			form := csrfFormValues(t, app, ts, "/account")
+			form.Add("name", tt.tokenName)
+			form.Add("lifetime", tt.lifetime)
+			for _, scope := range tt.scopes {
+				form.Add("scopes", scope)
+			}
+
+			res := ts.PostForm(t, "/account/tokens", form)
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			for _, field := range tt.wantErroredFields {
+				if len(templates.RenderedData.Form.Fields[field].Errors) == 0 {
+					t.Errorf("Expected %q to have errors", field)
+				}
+			}
+
+			if tt.wantCreated != (tt.apiTokens.CreatedUserID == userID) {
+				t.Errorf("Expected token created for user %v: %v, got user %v", userID, tt.wantCreated, tt.apiTokens.CreatedUserID)
+			}
+
+			if tt.wantCreated && tt.apiTokens.CreatedToken.Name != tt.tokenName {
+				t.Errorf("Expected created token name %q, got %q", tt.tokenName, tt.apiTokens.CreatedToken.Name)
+			}
+
+			if got := templates.RenderedData.CreatedAPIToken; got != tt.wantPlaintext {
+				t.Errorf("Expected rendered token %q, got %q", tt.wantPlaintext, got)
+			}
+		})
+	}
+}
+
+func TestApplication_accountTokenRevokePost(t *testing.T) {
+	userID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		apiTokens     mocks.APITokenModel
+		id            string
+		wantStatus    int
+		wantRevokedID int32
+	}{
+		{
+			name:       "invalid ID",
+			id:         "not-a-number",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "revoke error",
+			apiTokens: mocks.APITokenModel{
+				RevokeError: errors.New("everything broke"),
+			},
+			id:            "12",
+			wantStatus:    http.StatusInternalServerError,
+			wantRevokedID: 12,
+		},
+		{
+			name:          "success",
+			id:            "12",
+			wantStatus:    http.StatusSeeOther,
+			wantRevokedID: 12,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testutils.NewTestApplication(t)
+			app.APITokens = &tt.apiTokens
+			app.Templates = &CapturingTemplateEngine[application.TemplateData]{}
+			app.Users = &mocks.UserModel{}
+
+			ts := testutils.NewTestServer(t, app.Routes())
+			defer ts.Close()
+
+			logIn(t, app, ts, userID)
+
+			form := csrfFormValues(t, app, ts, "/account")
+			res := ts.PostForm(t, "/account/tokens/"+tt.id+"/revoke", form)
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			if got := tt.apiTokens.RevokedID; got != tt.wantRevokedID {
+				t.Errorf("Expected revoked token %d, got %d", tt.wantRevokedID, got)
+			}
+
+			if tt.wantRevokedID != 0 && tt.apiTokens.RevokedUserID != userID {
+				t.Errorf("Expected token revoked for user %v, got %v", userID, tt.apiTokens.RevokedUserID)
+			}
+		})
+	}
+}
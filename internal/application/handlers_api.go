@@ -0,0 +1,29 @@
+package application
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type apiTokenResponse struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// apiTokenGet describes the token used to make the request so scripts can check their credentials.
+func (a *Application) apiTokenGet(w http.ResponseWriter, r *http.Request) {
+	token, _ := a.apiToken(r)
+
+	res := apiTokenResponse{
+		UserID:    token.UserID,
+		Name:      token.Name,
+		Scopes:    token.Scopes,
+		ExpiresAt: token.ExpiresAt,
+	}
+
+	a.writeJSON(w, r, http.StatusOK, res)
+}
@@ -0,0 +1,57 @@
+package application_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cdriehuys/stuff2/internal/application/testutils"
+	"github.com/cdriehuys/stuff2/internal/models"
+	"github.com/cdriehuys/stuff2/internal/models/mocks"
+	"github.com/google/uuid"
+)
+
+func TestApplication_apiTokenGet(t *testing.T) {
+	token := models.APIToken{
+		UserID:    uuid.New(),
+		Name:      "Reports",
+		Scopes:    []string{models.ScopeItemsRead},
+		ExpiresAt: time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+	}
+
+	app := testutils.NewTestApplication(t)
+	app.APITokens = &mocks.APITokenModel{AuthenticateReturn: token}
+
+	ts := testutils.NewTestServer(t, app.Routes())
+	defer ts.Close()
+
+	res := ts.GetWithToken(t, "/api/v1/token", "stuff_secret")
+
+	if res.Status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, res.Status)
+	}
+
+	if got := res.Headers.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Expected JSON content type, got %q", got)
+	}
+
+	var body struct {
+		UserID    uuid.UUID `json:"user_id"`
+		Name      string    `json:"name"`
+		Scopes    []string  `json:"scopes"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.Unmarshal([]byte(res.Body), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if body.UserID != token.UserID || body.Name != token.Name || !body.ExpiresAt.Equal(token.ExpiresAt) {
+		t.Errorf("Expected token %#v, got %#v", token, body)
+	}
+
+	if !slices.Equal(body.Scopes, token.Scopes) {
+		t.Errorf("Expected scopes %v, got %v", token.Scopes, body.Scopes)
+	}
+}
@@ -56,7 +56,60 @@ func (a *Application) loginPost(w http.ResponseWriter, r *http.Request) {
 
 	a.setAuthenticatedUser(r, user.ID)
 
-	http.Redirect(w, r, "/app", http.StatusSeeOther)
+	a.redirectAfterLogin(w, r)
+}
+
+func (a *Application) loginLinkPost(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	email := r.PostFormValue("email")
+
+	// The response is the same whether or not the email belongs to a user so that the form
+	// can't be used to discover which emails have accounts.
+	if err := a.Users.RequestLoginLink(r.Context(), email); err != nil {
+		a.serverError(w, r, "Failed to request login link.", err)
+		return
+	}
+
+	http.Redirect(w, r, "/login/link-sent", http.StatusSeeOther)
+}
+
+func (a *Application) loginLinkSent(w http.ResponseWriter, r *http.Request) {
+	a.render(w, r, "login-link-sent.html", a.templateData(r))
+}
+
+// loginLinkGet renders a confirmation page rather than logging the user in directly. Email
+// scanners that follow links with GET requests would otherwise consume the link.
+func (a *Application) loginLinkGet(w http.ResponseWriter, r *http.Request) {
+	a.render(w, r, "login-link.html", a.templateData(r))
+}
+
+func (a *Application) loginLinkConfirmPost(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+
+	user, err := a.Users.AuthenticateLoginLink(r.Context(), token)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidLoginLink) {
+			t := a.translator(r)
+			form := forms.Form{
+				Errors: []validation.Error{validation.MakeError("invalid", t.T("login.link.invalid"))},
+			}
+
+			data := a.templateData(r)
+			data.Form = form
+
+			w.WriteHeader(http.StatusBadRequest)
+			a.render(w, r, "login-link.html", data)
+			return
+		}
+
+		a.serverError(w, r, "Failed to authenticate login link.", err)
+		return
+	}
+
+	a.setAuthenticatedUser(r, user.ID)
+
+	a.redirectAfterLogin(w, r)
 }
 
 func (a *Application) registerGet(w http.ResponseWriter, r *http.Request) {
@@ -160,6 +160,219 @@ func TestApplication_loginPost(t *testing.T) {
 	}
 }
 
+func TestApplication_loginPost_RedirectAfterLogin(t *testing.T) {
+	app := testutils.NewTestApplication(t)
+	app.Locations = &mocks.LocationModel{}
+	app.Users = &mocks.UserModel{
+		AuthenticateUser: models.User{ID: uuid.New()},
+	}
+
+	ts := testutils.NewTestServer(t, app.Routes())
+	defer ts.Close()
+
+	// Visiting a protected page while logged out remembers the page.
+	if res := ts.Get(t, "/locations?sort=name"); res.Headers.Get("Location") != "/login" {
+		t.Fatalf("Expected redirect to login, got status %d", res.Status)
+	}
+
+	form := csrfFormValues(t, app, ts, "/login")
+	res := ts.PostForm(t, "/login", form)
+
+	if res.Status != http.StatusSeeOther {
+		t.Errorf("Expected status %d, got %d", http.StatusSeeOther, res.Status)
+	}
+
+	if got := res.Headers.Get("Location"); got != "/locations?sort=name" {
+		t.Errorf("Expected redirect to %q, got %q", "/locations?sort=name", got)
+	}
+}
+
+func TestApplication_loginLinkPost(t *testing.T) {
+	testCases := []struct {
+		name         string
+		users        mocks.UserModel
+		email        string
+		wantStatus   int
+		wantRedirect *WantRedirect
+	}{
+		{
+			name: "request error",
+			users: mocks.UserModel{
+				RequestLoginLinkError: errors.New("broken"),
+			},
+			email:      "test@example.com",
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:  "success",
+			email: "test@example.com",
+			wantRedirect: &WantRedirect{
+				Status:   http.StatusSeeOther,
+				Location: "/login/link-sent",
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testutils.NewTestApplication(t)
+			app.Users = &tt.users
+
+			ts := testutils.NewTestServer(t, app.Routes())
+			defer ts.Close()
+
+			form := csrfFormValues(t, app, ts, "/login")
+			form.Add("email", tt.email)
+
+			res := ts.PostForm(t, "/login/link", form)
+
+			if tt.wantStatus != 0 && res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			if got := tt.users.RequestLoginLinkEmail; got != tt.email {
+				t.Errorf("Expected login link requested for %q, got %q", tt.email, got)
+			}
+
+			if want := tt.wantRedirect; want != nil {
+				if res.Status != want.Status {
+					t.Errorf("Expected status %d, got %d", want.Status, res.Status)
+				}
+
+				if got := res.Headers.Get("Location"); got != want.Location {
+					t.Errorf("Expected redirect location %q, got %q", want.Location, got)
+				}
+			}
+		})
+	}
+}
+
+func TestApplication_loginLinkSent(t *testing.T) {
+	app := testutils.NewTestApplication(t)
+	ts := testutils.NewTestServer(t, app.Routes())
+	defer ts.Close()
+
+	res := ts.Get(t, "/login/link-sent")
+
+	if res.Status != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, res.Status)
+	}
+}
+
+func TestApplication_loginLinkGet(t *testing.T) {
+	users := mocks.UserModel{}
+
+	app := testutils.NewTestApplication(t)
+	app.Users = &users
+
+	ts := testutils.NewTestServer(t, app.Routes())
+	defer ts.Close()
+
+	res := ts.Get(t, "/login/link/some-token")
+
+	if res.Status != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, res.Status)
+	}
+
+	if users.AuthenticateLoginLinkToken != "" {
+		t.Errorf("Expected GET request not to consume the login link, got %q", users.AuthenticateLoginLinkToken)
+	}
+}
+
+func TestApplication_loginLinkConfirmPost(t *testing.T) {
+	defaultUserID := uuid.New()
+
+	testCases := []struct {
+		name              string
+		users             mocks.UserModel
+		templates         CapturingTemplateEngine[application.TemplateData]
+		token             string
+		wantErrorCode     string
+		wantStatus        int
+		wantRedirect      *WantRedirect
+		wantAuthenticated bool
+	}{
+		{
+			name: "invalid token",
+			users: mocks.UserModel{
+				AuthenticateLoginLinkError: models.ErrInvalidLoginLink,
+			},
+			token:         "invalid",
+			wantErrorCode: "invalid",
+			wantStatus:    http.StatusBadRequest,
+		},
+		{
+			name: "authentication error",
+			users: mocks.UserModel{
+				AuthenticateLoginLinkError: errors.New("everything broke"),
+			},
+			token:      "valid",
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			users: mocks.UserModel{
+				AuthenticateLoginLinkUser: models.User{ID: defaultUserID},
+			},
+			token: "valid",
+			wantRedirect: &WantRedirect{
+				Status:   http.StatusSeeOther,
+				Location: "/app",
+			},
+			wantAuthenticated: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testutils.NewTestApplication(t)
+			app.Templates = &tt.templates
+			app.Users = &tt.users
+
+			ts := testutils.NewTestServer(t, app.Routes())
+			defer ts.Close()
+
+			form := csrfFormValues(t, app, ts, "/login/link/"+tt.token)
+
+			res := ts.PostForm(t, "/login/link/"+tt.token, form)
+
+			if tt.wantStatus != 0 && res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			if tt.wantErrorCode != "" {
+				if !slices.ContainsFunc(tt.templates.RenderedData.Form.Errors, func(e validation.Error) bool { return e.Code() == tt.wantErrorCode }) {
+					t.Errorf("Expected error with code %q, got errors %v", tt.wantErrorCode, tt.templates.RenderedData.Form.Errors)
+				}
+			}
+
+			if got := tt.users.AuthenticateLoginLinkToken; got != tt.token {
+				t.Errorf("Expected login link token %q, got %q", tt.token, got)
+			}
+
+			if want := tt.wantRedirect; want != nil {
+				if res.Status != want.Status {
+					t.Errorf("Expected status %d, got %d", want.Status, res.Status)
+				}
+
+				if got := res.Headers.Get("Location"); got != want.Location {
+					t.Errorf("Expected redirect location %q, got %q", want.Location, got)
+				}
+			}
+
+			authRes := ts.Get(t, "/app")
+
+			if tt.wantAuthenticated && authRes.Status != http.StatusOK {
+				t.Errorf("Expected user to be authenticated, but got a %d status for '/app'", authRes.Status)
+			}
+
+			if !tt.wantAuthenticated && authRes.Status == http.StatusOK {
+				t.Errorf("Expected user to not be authenticated, but they were able to retrieve '/app'")
+			}
+		})
+	}
+}
+
 func TestApplication_registerGet(t *testing.T) {
 	app := testutils.NewTestApplication(t)
 	ts := testutils.NewTestServer(t, app.Routes())
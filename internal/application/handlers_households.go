@@ -0,0 +1,236 @@
+package application
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/cdriehuys/stuff2/internal/forms"
+	"github.com/cdriehuys/stuff2/internal/models"
+	"github.com/cdriehuys/stuff2/internal/validation"
+	"github.com/google/uuid"
+)
+
+func householdsForm() forms.Form {
+	return forms.Form{
+		Fields: map[string]forms.Field{
+			"name": {Name: "name"},
+		},
+	}
+}
+
+func householdInvitationForm() forms.Form {
+	return forms.Form{
+		Fields: map[string]forms.Field{
+			"email": {Name: "email"},
+			"role":  {Name: "role", Value: string(models.HouseholdRoleViewer)},
+		},
+	}
+}
+
+func (a *Application) householdsGet(w http.ResponseWriter, r *http.Request) {
+	data := a.templateData(r)
+	data.Form = householdsForm()
+
+	a.render(w, r, "households.html", data)
+}
+
+func (a *Application) householdsPost(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	rawName := r.PostFormValue("name")
+
+	newHousehold, err := models.MakeNewHousehold(r.Context(), rawName)
+	if err != nil {
+		householdErrors := models.NewHouseholdErrors{}
+		if errors.As(err, &householdErrors) {
+			form := householdsForm()
+			form.Fields["name"] = forms.Field{Name: "name", Value: rawName, Errors: householdErrors.Name}
+
+			data := a.templateData(r)
+			data.Form = form
+
+			a.render(w, r, "households.html", data)
+			return
+		}
+
+		a.serverError(w, r, "Failed to validate household.", err)
+		return
+	}
+
+	household, err := a.Households.Create(r.Context(), a.getAuthenticatedUserID(r), newHousehold)
+	if err != nil {
+		a.serverError(w, r, "Failed to create household.", err)
+		return
+	}
+
+	a.Session.Put(r.Context(), sessionKeyHouseholdID, household.ID.String())
+
+	http.Redirect(w, r, "/households/"+household.ID.String(), http.StatusSeeOther)
+}
+
+// householdSwitchPost changes the household the user is working in.
+func (a *Application) householdSwitchPost(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	householdID, err := uuid.Parse(r.PostFormValue("household_id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	household, err := a.Households.GetForUser(r.Context(), a.getAuthenticatedUserID(r), householdID)
+	if err != nil {
+		if errors.Is(err, models.ErrHouseholdNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+
+		a.serverError(w, r, "Failed to retrieve household.", err)
+		return
+	}
+
+	a.Session.Put(r.Context(), sessionKeyHouseholdID, household.ID.String())
+
+	http.Redirect(w, r, "/households/"+household.ID.String(), http.StatusSeeOther)
+}
+
+// renderHousehold renders the page for a single household, which also lists its members and the
+// history of its membership.
+func (a *Application) renderHousehold(w http.ResponseWriter, r *http.Request, status int, data TemplateData) {
+	householdID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	userID := a.getAuthenticatedUserID(r)
+
+	household, err := a.Households.GetForUser(r.Context(), userID, householdID)
+	if err != nil {
+		if errors.Is(err, models.ErrHouseholdNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+
+		a.serverError(w, r, "Failed to retrieve household.", err)
+		return
+	}
+
+	members, err := a.Households.ListMembers(r.Context(), userID, householdID)
+	if err != nil {
+		a.serverError(w, r, "Failed to list household members.", err)
+		return
+	}
+
+	history, err := a.Audit.ListForEntity(r.Context(), household.ID, models.AuditEntityHousehold, household.ID)
+	if err != nil {
+		a.serverError(w, r, "Failed to list household history.", err)
+		return
+	}
+
+	data.Household = household
+	data.HouseholdMembers = members
+	data.AuditEvents = history
+	data.HouseholdRoles = models.HouseholdRoles
+
+	w.WriteHeader(status)
+	a.render(w, r, "household.html", data)
+}
+
+func (a *Application) householdGet(w http.ResponseWriter, r *http.Request) {
+	data := a.templateData(r)
+	data.Form = householdInvitationForm()
+
+	a.renderHousehold(w, r, http.StatusOK, data)
+}
+
+func (a *Application) householdInvitationsPost(w http.ResponseWriter, r *http.Request) {
+	householdID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	r.ParseForm()
+
+	rawEmail := r.PostFormValue("email")
+	rawRole := r.PostFormValue("role")
+
+	invitation, err := models.MakeNewHouseholdInvitation(r.Context(), rawEmail, rawRole)
+	if err != nil {
+		invitationErrors := models.NewHouseholdInvitationErrors{}
+		if errors.As(err, &invitationErrors) {
+			form := householdInvitationForm()
+			form.Fields["email"] = forms.Field{Name: "email", Value: rawEmail, Errors: invitationErrors.Email}
+			form.Fields["role"] = forms.Field{Name: "role", Value: rawRole, Errors: invitationErrors.Role}
+
+			data := a.templateData(r)
+			data.Form = form
+
+			a.renderHousehold(w, r, http.StatusOK, data)
+			return
+		}
+
+		a.serverError(w, r, "Failed to validate household invitation.", err)
+		return
+	}
+
+	if err := a.Households.Invite(r.Context(), a.getAuthenticatedUserID(r), householdID, invitation); err != nil {
+		if errors.Is(err, models.ErrHouseholdNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+
+		if errors.Is(err, models.ErrHouseholdForbidden) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		a.serverError(w, r, "Failed to invite household member.", err)
+		return
+	}
+
+	http.Redirect(w, r, "/households/"+householdID.String(), http.StatusSeeOther)
+}
+
+// householdInvitationGet shows a confirmation page rather than accepting the invitation
+// immediately, since email clients may follow links to preview them.
+func (a *Application) householdInvitationGet(w http.ResponseWriter, r *http.Request) {
+	a.render(w, r, "household-invitation.html", a.templateData(r))
+}
+
+func (a *Application) householdInvitationPost(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+
+	household, err := a.Households.AcceptInvitation(r.Context(), a.getAuthenticatedUserID(r), token)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidHouseholdInvitation) {
+			t := a.translator(r)
+
+			data := a.templateData(r)
+			data.Form.Errors = []validation.Error{validation.MakeError("invalid", t.T("household.invitation.invalid"))}
+
+			w.WriteHeader(http.StatusBadRequest)
+			a.render(w, r, "household-invitation.html", data)
+			return
+		}
+
+		if errors.Is(err, models.ErrAlreadyHouseholdMember) {
+			t := a.translator(r)
+
+			data := a.templateData(r)
+			data.Form.Errors = []validation.Error{validation.MakeError("member", t.T("household.invitation.member"))}
+
+			w.WriteHeader(http.StatusConflict)
+			a.render(w, r, "household-invitation.html", data)
+			return
+		}
+
+		a.serverError(w, r, "Failed to accept household invitation.", err)
+		return
+	}
+
+	a.Session.Put(r.Context(), sessionKeyHouseholdID, household.ID.String())
+
+	http.Redirect(w, r, "/households/"+household.ID.String(), http.StatusSeeOther)
+}
@@ -0,0 +1,695 @@
+package application_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cdriehuys/stuff2/internal/application"
+	"github.com/cdriehuys/stuff2/internal/application/testutils"
+	"github.com/cdriehuys/stuff2/internal/models"
+	"github.com/cdriehuys/stuff2/internal/models/mocks"
+	"github.com/google/uuid"
+)
+
+func TestApplication_householdsGet(t *testing.T) {
+	userID := uuid.New()
+	home := models.Household{ID: uuid.New(), Name: "Home", Role: models.HouseholdRoleOwner}
+	cabin := models.Household{ID: uuid.New(), Name: "The Cabin", Role: models.HouseholdRoleViewer}
+
+	testCases := []struct {
+		name       string
+		households mocks.HouseholdModel
+		wantStatus int
+		wantActive models.Household
+	}{
+		{
+			name: "list error",
+			households: mocks.HouseholdModel{
+				ListForUserError: errors.New("everything broke"),
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "no households",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "defaults to first household",
+			households: mocks.HouseholdModel{
+				ListForUserList: []models.Household{home, cabin},
+			},
+			wantStatus: http.StatusOK,
+			wantActive: home,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			templates := &CapturingTemplateEngine[application.TemplateData]{}
+
+			app := testutils.NewTestApplication(t)
+			app.Households = &tt.households
+			app.Templates = templates
+			app.Users = &mocks.UserModel{}
+
+			ts := testutils.NewTestServer(t, app.Routes())
+			defer ts.Close()
+
+			logIn(t, app, ts, userID)
+
+			res := ts.Get(t, "/households")
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			if got := tt.households.ListedUserID; got != userID {
+				t.Errorf("Expected households listed for user %v, got %v", userID, got)
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			if got := templates.RenderedData.ActiveHousehold; got != tt.wantActive {
+				t.Errorf("Expected active household %v, got %v", tt.wantActive, got)
+			}
+
+			if got := len(templates.RenderedData.Households); got != len(tt.households.ListForUserList) {
+				t.Errorf("Expected %d households, got %d", len(tt.households.ListForUserList), got)
+			}
+		})
+	}
+}
+
+func TestApplication_householdsGet_Switcher(t *testing.T) {
+	home := models.Household{ID: uuid.New(), Name: "Home", Role: models.HouseholdRoleOwner}
+
+	app := testutils.NewTestApplication(t)
+	app.Households = &mocks.HouseholdModel{ListForUserList: []models.Household{home}}
+	app.Users = &mocks.UserModel{}
+
+	ts := testutils.NewTestServer(t, app.Routes())
+	defer ts.Close()
+
+	logIn(t, app, ts, uuid.New())
+
+	res := ts.Get(t, "/households")
+
+	if want := `action="/households/switch"`; !strings.Contains(res.Body, want) {
+		t.Errorf("Expected layout to contain household switcher with %s", want)
+	}
+
+	if want := `<option value="` + home.ID.String() + `" selected>`; !strings.Contains(res.Body, want) {
+		t.Errorf("Expected active household to be selected with %s", want)
+	}
+}
+
+func TestApplication_householdsPost(t *testing.T) {
+	userID := uuid.New()
+	created := models.Household{ID: uuid.New(), Name: "Home", Role: models.HouseholdRoleOwner}
+
+	testCases := []struct {
+		name              string
+		households        mocks.HouseholdModel
+		householdName     string
+		wantStatus        int
+		wantErroredFields []string
+		wantCreated       bool
+		wantLocation      string
+	}{
+		{
+			name:              "validation errors",
+			wantStatus:        http.StatusOK,
+			wantErroredFields: []string{"name"},
+		},
+		{
+			name: "create error",
+			households: mocks.HouseholdModel{
+				CreateError: errors.New("everything broke"),
+			},
+			householdName: "Home",
+			wantStatus:    http.StatusInternalServerError,
+			wantCreated:   true,
+		},
+		{
+			name: "success",
+			households: mocks.HouseholdModel{
+				CreateReturn: created,
+			},
+			householdName: "Home",
+			wantStatus:    http.StatusSeeOther,
+			wantCreated:   true,
+			wantLocation:  "/households/" + created.ID.String(),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			templates := &CapturingTemplateEngine[application.TemplateData]{}
+
+			app := testutils.NewTestApplication(t)
+			app.Households = &tt.households
+			app.Templates = templates
+			app.Users = &mocks.UserModel{}
+
+			ts := testutils.NewTestServer(t, app.Routes())
+			defer ts.Close()
+
+			logIn(t, app, ts, userID)
+
+			form := csrfFormValues(t, app, ts, "/households")
+			form.Add("name", tt.householdName)
+
+			res := ts.PostForm(t, "/households", form)
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			for _, field := range tt.wantErroredFields {
+				if len(templates.RenderedData.Form.Fields[field].Errors) == 0 {
+					t.Errorf("Expected %q to have errors", field)
+				}
+			}
+
+			if tt.wantCreated != (tt.households.CreatedUserID == userID) {
+				t.Errorf("Expected household created for user %v: %v, got user %v", userID, tt.wantCreated, tt.households.CreatedUserID)
+			}
+
+			if got := res.Headers.Get("Location"); got != tt.wantLocation {
+				t.Errorf("Expected redirect location %q, got %q", tt.wantLocation, got)
+			}
+		})
+	}
+}
+
+func TestApplication_householdsPost_BecomesActive(t *testing.T) {
+	home := models.Household{ID: uuid.New(), Name: "Home", Role: models.HouseholdRoleOwner}
+	cabin := models.Household{ID: uuid.New(), Name: "The Cabin", Role: models.HouseholdRoleOwner}
+
+	households := mocks.HouseholdModel{
+		CreateReturn:    cabin,
+		ListForUserList: []models.Household{home, cabin},
+	}
+
+	templates := &CapturingTemplateEngine[application.TemplateData]{}
+
+	app := testutils.NewTestApplication(t)
+	app.Households = &households
+	app.Templates = templates
+	app.Users = &mocks.UserModel{}
+
+	ts := testutils.NewTestServer(t, app.Routes())
+	defer ts.Close()
+
+	logIn(t, app, ts, uuid.New())
+
+	form := csrfFormValues(t, app, ts, "/households")
+	form.Add("name", cabin.Name)
+
+	if res := ts.PostForm(t, "/households", form); res.Status != http.StatusSeeOther {
+		t.Fatalf("Expected household to be created, got status %d", res.Status)
+	}
+
+	ts.Get(t, "/households")
+
+	if got := templates.RenderedData.ActiveHousehold; got != cabin {
+		t.Errorf("Expected new household %v to be active, got %v", cabin, got)
+	}
+}
+
+func TestApplication_householdSwitchPost(t *testing.T) {
+	userID := uuid.New()
+	home := models.Household{ID: uuid.New(), Name: "Home", Role: models.HouseholdRoleOwner}
+	cabin := models.Household{ID: uuid.New(), Name: "The Cabin", Role: models.HouseholdRoleViewer}
+
+	testCases := []struct {
+		name         string
+		households   mocks.HouseholdModel
+		householdID  string
+		wantStatus   int
+		wantLocation string
+		wantActive   models.Household
+	}{
+		{
+			name:        "invalid ID",
+			householdID: "not-a-uuid",
+			wantStatus:  http.StatusNotFound,
+			wantActive:  home,
+		},
+		{
+			name: "not a member",
+			households: mocks.HouseholdModel{
+				GetForUserError: models.ErrHouseholdNotFound,
+			},
+			householdID: uuid.NewString(),
+			wantStatus:  http.StatusNotFound,
+			wantActive:  home,
+		},
+		{
+			name: "error retrieving household",
+			households: mocks.HouseholdModel{
+				GetForUserError: errors.New("everything broke"),
+			},
+			householdID: cabin.ID.String(),
+			wantStatus:  http.StatusInternalServerError,
+			wantActive:  home,
+		},
+		{
+			name: "success",
+			households: mocks.HouseholdModel{
+				GetForUserReturn: cabin,
+			},
+			householdID:  cabin.ID.String(),
+			wantStatus:   http.StatusSeeOther,
+			wantLocation: "/households/" + cabin.ID.String(),
+			wantActive:   cabin,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.households.ListForUserList = []models.Household{home, cabin}
+			templates := &CapturingTemplateEngine[application.TemplateData]{}
+
+			app := testutils.NewTestApplication(t)
+			app.Households = &tt.households
+			app.Templates = templates
+			app.Users = &mocks.UserModel{}
+
+			ts := testutils.NewTestServer(t, app.Routes())
+			defer ts.Close()
+
+			logIn(t, app, ts, userID)
+
+			form := csrfFormValues(t, app, ts, "/households")
+			form.Add("household_id", tt.householdID)
+
+			res := ts.PostForm(t, "/households/switch", form)
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			if got := res.Headers.Get("Location"); got != tt.wantLocation {
+				t.Errorf("Expected redirect location %q, got %q", tt.wantLocation, got)
+			}
+
+			ts.Get(t, "/households")
+
+			if got := templates.RenderedData.ActiveHousehold; got != tt.wantActive {
+				t.Errorf("Expected active household %v, got %v", tt.wantActive, got)
+			}
+		})
+	}
+}
+
+func TestApplication_householdGet(t *testing.T) {
+	userID := uuid.New()
+	household := models.Household{ID: uuid.New(), Name: "Home", Role: models.HouseholdRoleOwner}
+
+	testCases := []struct {
+		name        string
+		households  mocks.HouseholdModel
+		householdID string
+		wantStatus  int
+		wantBody    []string
+	}{
+		{
+			name:        "invalid ID",
+			householdID: "not-a-uuid",
+			wantStatus:  http.StatusNotFound,
+		},
+		{
+			name: "not a member",
+			households: mocks.HouseholdModel{
+				GetForUserError: models.ErrHouseholdNotFound,
+			},
+			householdID: household.ID.String(),
+			wantStatus:  http.StatusNotFound,
+		},
+		{
+			name: "members error",
+			households: mocks.HouseholdModel{
+				GetForUserReturn: household,
+				ListMembersError: errors.New("everything broke"),
+			},
+			householdID: household.ID.String(),
+			wantStatus:  http.StatusInternalServerError,
+		},
+		{
+			name: "owner",
+			households: mocks.HouseholdModel{
+				GetForUserReturn: household,
+				ListMembersList: []models.HouseholdMember{
+					{UserID: userID, Email: "owner@example.com", Role: models.HouseholdRoleOwner},
+				},
+			},
+			householdID: household.ID.String(),
+			wantStatus:  http.StatusOK,
+			wantBody:    []string{"owner@example.com", "Invite a Member"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testutils.NewTestApplication(t)
+			app.Households = &tt.households
+			app.Users = &mocks.UserModel{}
+
+			ts := testutils.NewTestServer(t, app.Routes())
+			defer ts.Close()
+
+			logIn(t, app, ts, userID)
+
+			res := ts.Get(t, "/households/"+tt.householdID)
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			for _, want := range tt.wantBody {
+				if !strings.Contains(res.Body, want) {
+					t.Errorf("Expected body to contain %q", want)
+				}
+			}
+		})
+	}
+}
+
+func TestApplication_householdGet_History(t *testing.T) {
+	household := models.Household{ID: uuid.New(), Name: "Home", Role: models.HouseholdRoleViewer}
+
+	testCases := []struct {
+		name       string
+		audit      mocks.AuditModel
+		wantStatus int
+		wantBody   []string
+	}{
+		{
+			name: "list error",
+			audit: mocks.AuditModel{
+				ListForEntityError: errors.New("everything broke"),
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "no history",
+			wantStatus: http.StatusOK,
+			wantBody:   []string{"Nothing has been recorded yet."},
+		},
+		{
+			name: "history listed",
+			audit: mocks.AuditModel{
+				ListForEntityList: []models.AuditEvent{
+					{
+						ActorEmail: "member@example.com",
+						Action:     models.AuditActionMemberJoined,
+						Changes:    map[string]models.FieldChange{"role": {New: "viewer"}},
+						CreatedAt:  time.Now(),
+					},
+					{
+						ActorEmail: "owner@example.com",
+						Action:     models.AuditActionMemberInvited,
+						Changes:    map[string]models.FieldChange{"role": {New: "viewer"}},
+						CreatedAt:  time.Now(),
+					},
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantBody: []string{
+				"member@example.com",
+				"joined",
+				"owner@example.com",
+				"invited a member",
+				"<code>role</code>",
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testutils.NewTestApplication(t)
+			app.Audit = &tt.audit
+			app.Households = &mocks.HouseholdModel{GetForUserReturn: household}
+			app.Users = &mocks.UserModel{}
+
+			ts := testutils.NewTestServer(t, app.Routes())
+			defer ts.Close()
+
+			logIn(t, app, ts, uuid.New())
+
+			res := ts.Get(t, "/households/"+household.ID.String())
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			for _, want := range tt.wantBody {
+				if !strings.Contains(res.Body, want) {
+					t.Errorf("Expected body to contain %q", want)
+				}
+			}
+
+			if tt.audit.ListedHouseholdID != household.ID {
+				t.Errorf("Expected history listed for household %v, got %v", household.ID, tt.audit.ListedHouseholdID)
+			}
+
+			if tt.audit.ListedEntityType != models.AuditEntityHousehold || tt.audit.ListedEntityID != household.ID {
+				t.Errorf("Expected history of household %v, got %s %v", household.ID, tt.audit.ListedEntityType, tt.audit.ListedEntityID)
+			}
+		})
+	}
+}
+
+func TestApplication_householdGet_Viewer(t *testing.T) {
+	household := models.Household{ID: uuid.New(), Name: "Home", Role: models.HouseholdRoleViewer}
+
+	app := testutils.NewTestApplication(t)
+	app.Households = &mocks.HouseholdModel{GetForUserReturn: household}
+	app.Users = &mocks.UserModel{}
+
+	ts := testutils.NewTestServer(t, app.Routes())
+	defer ts.Close()
+
+	logIn(t, app, ts, uuid.New())
+
+	res := ts.Get(t, "/households/"+household.ID.String())
+
+	if strings.Contains(res.Body, "Invite a Member") {
+		t.Error("Expected viewers to not be offered the invitation form")
+	}
+}
+
+func TestApplication_householdInvitationsPost(t *testing.T) {
+	userID := uuid.New()
+	householdID := uuid.New()
+
+	testCases := []struct {
+		name              string
+		households        mocks.HouseholdModel
+		email             string
+		role              string
+		wantStatus        int
+		wantErroredFields []string
+		wantInvitation    models.NewHouseholdInvitation
+	}{
+		{
+			name: "validation errors",
+			households: mocks.HouseholdModel{
+				GetForUserReturn: models.Household{ID: householdID, Role: models.HouseholdRoleOwner},
+			},
+			role:              "admin",
+			wantStatus:        http.StatusOK,
+			wantErroredFields: []string{"email", "role"},
+		},
+		{
+			name: "not a member",
+			households: mocks.HouseholdModel{
+				InviteError: models.ErrHouseholdNotFound,
+			},
+			email:          "friend@example.com",
+			role:           "editor",
+			wantStatus:     http.StatusNotFound,
+			wantInvitation: models.NewHouseholdInvitation{Email: "friend@example.com", Role: models.HouseholdRoleEditor},
+		},
+		{
+			name: "not an owner",
+			households: mocks.HouseholdModel{
+				InviteError: models.ErrHouseholdForbidden,
+			},
+			email:          "friend@example.com",
+			role:           "editor",
+			wantStatus:     http.StatusForbidden,
+			wantInvitation: models.NewHouseholdInvitation{Email: "friend@example.com", Role: models.HouseholdRoleEditor},
+		},
+		{
+			name: "invite error",
+			households: mocks.HouseholdModel{
+				InviteError: errors.New("everything broke"),
+			},
+			email:          "friend@example.com",
+			role:           "editor",
+			wantStatus:     http.StatusInternalServerError,
+			wantInvitation: models.NewHouseholdInvitation{Email: "friend@example.com", Role: models.HouseholdRoleEditor},
+		},
+		{
+			name:           "success",
+			email:          "friend@example.com",
+			role:           "viewer",
+			wantStatus:     http.StatusSeeOther,
+			wantInvitation: models.NewHouseholdInvitation{Email: "friend@example.com", Role: models.HouseholdRoleViewer},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			templates := &CapturingTemplateEngine[application.TemplateData]{}
+
+			app := testutils.NewTestApplication(t)
+			app.Households = &tt.households
+			app.Templates = templates
+			app.Users = &mocks.UserModel{}
+
+			ts := testutils.NewTestServer(t, app.Routes())
+			defer ts.Close()
+
+			logIn(t, app, ts, userID)
+
+			form := csrfFormValues(t, app, ts, "/households")
+			form.Add("email", tt.email)
+			form.Add("role", tt.role)
+
+			res := ts.PostForm(t, "/households/"+householdID.String()+"/invitations", form)
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			for _, field := range tt.wantErroredFields {
+				if len(templates.RenderedData.Form.Fields[field].Errors) == 0 {
+					t.Errorf("Expected %q to have errors", field)
+				}
+			}
+
+			if got := tt.households.InvitedInvitation; got != tt.wantInvitation {
+				t.Errorf("Expected invitation %v, got %v", tt.wantInvitation, got)
+			}
+
+			if tt.wantInvitation.Email != "" && tt.households.InvitedHouseholdID != householdID {
+				t.Errorf("Expected invitation to household %v, got %v", householdID, tt.households.InvitedHouseholdID)
+			}
+		})
+	}
+}
+
+func TestApplication_householdInvitationGet(t *testing.T) {
+	app := testutils.NewTestApplication(t)
+	app.Users = &mocks.UserModel{}
+
+	ts := testutils.NewTestServer(t, app.Routes())
+	defer ts.Close()
+
+	logIn(t, app, ts, uuid.New())
+
+	res := ts.Get(t, "/invitations/some-token")
+
+	if res.Status != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, res.Status)
+	}
+
+	if want := "Accept Invitation"; !strings.Contains(res.Body, want) {
+		t.Errorf("Expected body to contain %q", want)
+	}
+}
+
+func TestApplication_householdInvitationPost(t *testing.T) {
+	userID := uuid.New()
+	household := models.Household{ID: uuid.New(), Name: "Home", Role: models.HouseholdRoleViewer}
+
+	testCases := []struct {
+		name          string
+		households    mocks.HouseholdModel
+		wantStatus    int
+		wantErrorCode string
+		wantLocation  string
+	}{
+		{
+			name: "invalid invitation",
+			households: mocks.HouseholdModel{
+				AcceptInvitationError: models.ErrInvalidHouseholdInvitation,
+			},
+			wantStatus:    http.StatusBadRequest,
+			wantErrorCode: "invalid",
+		},
+		{
+			name: "already a member",
+			households: mocks.HouseholdModel{
+				AcceptInvitationError: models.ErrAlreadyHouseholdMember,
+			},
+			wantStatus:    http.StatusConflict,
+			wantErrorCode: "member",
+		},
+		{
+			name: "accept error",
+			households: mocks.HouseholdModel{
+				AcceptInvitationError: errors.New("everything broke"),
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			households: mocks.HouseholdModel{
+				AcceptInvitationReturn: household,
+			},
+			wantStatus:   http.StatusSeeOther,
+			wantLocation: "/households/" + household.ID.String(),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			templates := &CapturingTemplateEngine[application.TemplateData]{}
+
+			app := testutils.NewTestApplication(t)
+			app.Households = &tt.households
+			app.Templates = templates
+			app.Users = &mocks.UserModel{}
+
+			ts := testutils.NewTestServer(t, app.Routes())
+			defer ts.Close()
+
+			logIn(t, app, ts, userID)
+
+			form := csrfFormValues(t, app, ts, "/invitations/the-token")
+			res := ts.PostForm(t, "/invitations/the-token", form)
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			if got := tt.households.AcceptedToken; got != "the-token" {
+				t.Errorf("Expected invitation %q to be accepted, got %q", "the-token", got)
+			}
+
+			if got := tt.households.AcceptedUserID; got != userID {
+				t.Errorf("Expected invitation accepted by %v, got %v", userID, got)
+			}
+
+			if tt.wantErrorCode != "" {
+				errs := templates.RenderedData.Form.Errors
+				if len(errs) != 1 || errs[0].Code() != tt.wantErrorCode {
+					t.Errorf("Expected a single %q error, got %v", tt.wantErrorCode, errs)
+				}
+			}
+
+			if got := res.Headers.Get("Location"); got != tt.wantLocation {
+				t.Errorf("Expected redirect location %q, got %q", tt.wantLocation, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,371 @@
+package application
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/cdriehuys/stuff2/internal/forms"
+	"github.com/cdriehuys/stuff2/internal/models"
+	"github.com/cdriehuys/stuff2/internal/validation"
+	"github.com/google/uuid"
+)
+
+func locationsForm() forms.Form {
+	return forms.Form{
+		Fields: map[string]forms.Field{
+			"name":      {Name: "name"},
+			"parent_id": {Name: "parent_id"},
+		},
+	}
+}
+
+// locationHousehold returns the active household that locations are managed in. Users without a
+// household are sent to create one, and it returns false.
+func (a *Application) locationHousehold(w http.ResponseWriter, r *http.Request) (models.Household, bool) {
+	household, ok := a.activeHousehold(r)
+	if !ok {
+		http.Redirect(w, r, "/households", http.StatusSeeOther)
+		return models.Household{}, false
+	}
+
+	return household, true
+}
+
+// renderLocations renders the page listing every location in the active household.
+func (a *Application) renderLocations(w http.ResponseWriter, r *http.Request, household models.Household, data TemplateData) {
+	tree, err := a.Locations.ListTree(r.Context(), household.ID)
+	if err != nil {
+		a.serverError(w, r, "Failed to list locations.", err)
+		return
+	}
+
+	data.LocationTree = tree
+
+	a.render(w, r, "locations.html", data)
+}
+
+func (a *Application) locationsGet(w http.ResponseWriter, r *http.Request) {
+	household, ok := a.locationHousehold(w, r)
+	if !ok {
+		return
+	}
+
+	data := a.templateData(r)
+	data.Form = locationsForm()
+
+	a.renderLocations(w, r, household, data)
+}
+
+func (a *Application) locationsPost(w http.ResponseWriter, r *http.Request) {
+	household, ok := a.locationHousehold(w, r)
+	if !ok {
+		return
+	}
+
+	if !household.Role.CanEdit() {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	r.ParseForm()
+
+	rawName := r.PostFormValue("name")
+	rawParentID := r.PostFormValue("parent_id")
+
+	renderErrors := func(nameErrors []validation.Error, parentErrors []validation.Error) {
+		form := locationsForm()
+		form.Fields["name"] = forms.Field{Name: "name", Value: rawName, Errors: nameErrors}
+		form.Fields["parent_id"] = forms.Field{Name: "parent_id", Value: rawParentID, Errors: parentErrors}
+
+		data := a.templateData(r)
+		data.Form = form
+
+		a.renderLocations(w, r, household, data)
+	}
+
+	newLocation, err := models.MakeNewLocation(r.Context(), rawName, rawParentID)
+	if err != nil {
+		locationErrors := models.NewLocationErrors{}
+		if errors.As(err, &locationErrors) {
+			renderErrors(locationErrors.Name, locationErrors.Parent)
+			return
+		}
+
+		a.serverError(w, r, "Failed to validate location.", err)
+		return
+	}
+
+	location, err := a.Locations.Create(r.Context(), a.getAuthenticatedUserID(r), household.ID, newLocation)
+	if err != nil {
+		if errors.Is(err, models.ErrLocationParentNotFound) {
+			t := a.translator(r)
+			renderErrors(nil, []validation.Error{validation.MakeError("invalid", t.T("location.parent.invalid"))})
+			return
+		}
+
+		a.serverError(w, r, "Failed to create location.", err)
+		return
+	}
+
+	http.Redirect(w, r, "/locations/"+location.ID.String(), http.StatusSeeOther)
+}
+
+// renderLocation renders the page for a single location, which also offers every location it can
+// be moved into.
+func (a *Application) renderLocation(w http.ResponseWriter, r *http.Request, household models.Household, data TemplateData) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	location, err := a.Locations.Get(r.Context(), household.ID, id)
+	if err != nil {
+		if errors.Is(err, models.ErrLocationNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+
+		a.serverError(w, r, "Failed to retrieve location.", err)
+		return
+	}
+
+	tree, err := a.Locations.ListTree(r.Context(), household.ID)
+	if err != nil {
+		a.serverError(w, r, "Failed to list locations.", err)
+		return
+	}
+
+	// A location can't be moved into itself or anything inside it.
+	excluded := map[uuid.UUID]bool{location.ID: true}
+	for _, descendant := range location.Descendants {
+		excluded[descendant.ID] = true
+	}
+
+	for _, node := range tree {
+		if !excluded[node.ID] {
+			data.LocationTree = append(data.LocationTree, node)
+		}
+	}
+
+	history, err := a.Audit.ListForEntity(r.Context(), household.ID, models.AuditEntityLocation, location.ID)
+	if err != nil {
+		a.serverError(w, r, "Failed to list location history.", err)
+		return
+	}
+
+	data.Location = location
+	data.AuditEvents = history
+
+	a.render(w, r, "location.html", data)
+}
+
+func (a *Application) locationGet(w http.ResponseWriter, r *http.Request) {
+	household, ok := a.locationHousehold(w, r)
+	if !ok {
+		return
+	}
+
+	data := a.templateData(r)
+	data.Form = locationsForm()
+
+	a.renderLocation(w, r, household, data)
+}
+
+func (a *Application) locationMovePost(w http.ResponseWriter, r *http.Request) {
+	household, ok := a.locationHousehold(w, r)
+	if !ok {
+		return
+	}
+
+	if !household.Role.CanEdit() {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	r.ParseForm()
+
+	rawParentID := r.PostFormValue("parent_id")
+
+	renderErrors := func(parentErrors []validation.Error) {
+		form := locationsForm()
+		form.Fields["parent_id"] = forms.Field{Name: "parent_id", Value: rawParentID, Errors: parentErrors}
+
+		data := a.templateData(r)
+		data.Form = form
+
+		a.renderLocation(w, r, household, data)
+	}
+
+	move, err := models.MakeLocationMove(r.Context(), rawParentID)
+	if err != nil {
+		moveErrors := models.LocationMoveErrors{}
+		if errors.As(err, &moveErrors) {
+			renderErrors(moveErrors.Parent)
+			return
+		}
+
+		a.serverError(w, r, "Failed to validate location move.", err)
+		return
+	}
+
+	if err := a.Locations.Move(r.Context(), a.getAuthenticatedUserID(r), household.ID, id, move); err != nil {
+		t := a.translator(r)
+
+		switch {
+		case errors.Is(err, models.ErrLocationNotFound):
+			http.NotFound(w, r)
+		case errors.Is(err, models.ErrLocationParentNotFound):
+			renderErrors([]validation.Error{validation.MakeError("invalid", t.T("location.parent.invalid"))})
+		case errors.Is(err, models.ErrLocationCycle):
+			renderErrors([]validation.Error{validation.MakeError("cycle", t.T("location.move.cycle"))})
+		default:
+			a.serverError(w, r, "Failed to move location.", err)
+		}
+
+		return
+	}
+
+	http.Redirect(w, r, "/locations/"+id.String(), http.StatusSeeOther)
+}
+
+// locationLabelsGet offers a form to choose locations to print labels for. Submitting the form
+// renders a printable sheet of the chosen labels.
+func (a *Application) locationLabelsGet(w http.ResponseWriter, r *http.Request) {
+	household, ok := a.locationHousehold(w, r)
+	if !ok {
+		return
+	}
+
+	tree, err := a.Locations.ListTree(r.Context(), household.ID)
+	if err != nil {
+		a.serverError(w, r, "Failed to list locations.", err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	rawLayout := query.Get("layout")
+	rawLocationIDs := query["location"]
+
+	renderForm := func(form forms.Form) {
+		data := a.templateData(r)
+		data.Form = form
+		data.LabelLayouts = labelLayouts
+		data.LocationTree = tree
+
+		data.LabelLocations = make(map[uuid.UUID]bool, len(rawLocationIDs))
+		for _, rawID := range rawLocationIDs {
+			if id, err := uuid.Parse(rawID); err == nil {
+				data.LabelLocations[id] = true
+			}
+		}
+
+		a.render(w, r, "location-labels.html", data)
+	}
+
+	// The form hasn't been submitted yet.
+	if !query.Has("layout") {
+		renderForm(forms.Form{
+			Fields: map[string]forms.Field{
+				"layout":   {Name: "layout", Value: labelLayouts[0].Name},
+				"location": {Name: "location"},
+			},
+		})
+		return
+	}
+
+	t := a.translator(r)
+	form := forms.Form{
+		Fields: map[string]forms.Field{
+			"layout":   {Name: "layout", Value: rawLayout},
+			"location": {Name: "location"},
+		},
+	}
+
+	layout, layoutFound := findLabelLayout(rawLayout)
+	if !layoutFound {
+		form.Fields["layout"] = forms.Field{
+			Name:   "layout",
+			Value:  rawLayout,
+			Errors: []validation.Error{validation.MakeError("invalid", t.T("location.labels.layout.invalid"))},
+		}
+	}
+
+	if len(rawLocationIDs) == 0 {
+		form.Fields["location"] = forms.Field{
+			Name:   "location",
+			Errors: []validation.Error{validation.MakeError("required", t.T("location.labels.required"))},
+		}
+	}
+
+	if !layoutFound || len(rawLocationIDs) == 0 {
+		renderForm(form)
+		return
+	}
+
+	names := make(map[uuid.UUID]string, len(tree))
+	for _, node := range tree {
+		names[node.ID] = node.Name
+	}
+
+	labels := make([]Label, 0, len(rawLocationIDs))
+	for _, rawID := range rawLocationIDs {
+		id, err := uuid.Parse(rawID)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		name, ok := names[id]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		label, err := a.locationLabel(id, name)
+		if err != nil {
+			a.serverError(w, r, "Failed to create location label.", err)
+			return
+		}
+
+		labels = append(labels, label)
+	}
+
+	data := a.templateData(r)
+	data.LabelSheet = newLabelSheet(layout, labels)
+
+	a.render(w, r, "location-label-sheet.html", data)
+}
+
+// locationShortLinkGet sends the user to the location that a label's short link refers to. The
+// link doesn't say which household the location is in, so the household containing it becomes
+// the active household.
+func (a *Application) locationShortLinkGet(w http.ResponseWriter, r *http.Request) {
+	id, err := decodeShortLinkCode(r.PathValue("code"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	householdID, err := a.Locations.GetHouseholdID(r.Context(), a.getAuthenticatedUserID(r), id)
+	if err != nil {
+		if errors.Is(err, models.ErrLocationNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+
+		a.serverError(w, r, "Failed to find household of location.", err)
+		return
+	}
+
+	a.Session.Put(r.Context(), sessionKeyHouseholdID, householdID.String())
+
+	http.Redirect(w, r, "/locations/"+id.String(), http.StatusSeeOther)
+}
@@ -0,0 +1,833 @@
+package application_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cdriehuys/stuff2/internal/application"
+	"github.com/cdriehuys/stuff2/internal/application/testutils"
+	"github.com/cdriehuys/stuff2/internal/models"
+	"github.com/cdriehuys/stuff2/internal/models/mocks"
+	"github.com/google/uuid"
+)
+
+// newLocationsTestServer returns a test server where the user is logged in and working in the
+// given household.
+func newLocationsTestServer(t *testing.T, app *application.Application, household models.Household) *testutils.TestServer {
+	app.Households = &mocks.HouseholdModel{ListForUserList: []models.Household{household}}
+	app.Users = &mocks.UserModel{}
+
+	ts := testutils.NewTestServer(t, app.Routes())
+	t.Cleanup(ts.Close)
+
+	logIn(t, app, ts, uuid.New())
+
+	return ts
+}
+
+func TestApplication_locationsGet_NoHousehold(t *testing.T) {
+	app := testutils.NewTestApplication(t)
+	app.Users = &mocks.UserModel{}
+
+	ts := testutils.NewTestServer(t, app.Routes())
+	defer ts.Close()
+
+	logIn(t, app, ts, uuid.New())
+
+	res := ts.Get(t, "/locations")
+
+	if res.Status != http.StatusSeeOther {
+		t.Errorf("Expected status %d, got %d", http.StatusSeeOther, res.Status)
+	}
+
+	if got := res.Headers.Get("Location"); got != "/households" {
+		t.Errorf("Expected redirect to %q, got %q", "/households", got)
+	}
+}
+
+func TestApplication_locationsGet(t *testing.T) {
+	household := models.Household{ID: uuid.New(), Name: "Home", Role: models.HouseholdRoleViewer}
+	garage := models.Location{ID: uuid.New(), Name: "Garage"}
+	shelf := models.Location{ID: uuid.New(), ParentID: garage.ID, Name: "Shelf 3"}
+	bin := models.Location{ID: uuid.New(), ParentID: shelf.ID, Name: "Bin B"}
+
+	testCases := []struct {
+		name       string
+		locations  mocks.LocationModel
+		wantStatus int
+		wantBody   []string
+	}{
+		{
+			name: "list error",
+			locations: mocks.LocationModel{
+				ListTreeError: errors.New("everything broke"),
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "tree",
+			locations: mocks.LocationModel{
+				ListTreeList: []models.LocationNode{
+					{Location: garage, Depth: 1, DescendantCount: 2},
+					{Location: shelf, Depth: 2, DescendantCount: 1},
+					{Location: bin, Depth: 3},
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   []string{"Garage", "(2 locations inside)", "(1 location inside)", "Bin B"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testutils.NewTestApplication(t)
+			app.Locations = &tt.locations
+
+			ts := newLocationsTestServer(t, app, household)
+
+			res := ts.Get(t, "/locations")
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			if got := tt.locations.ListedHouseholdID; got != household.ID {
+				t.Errorf("Expected locations listed for household %v, got %v", household.ID, got)
+			}
+
+			for _, want := range tt.wantBody {
+				if !strings.Contains(res.Body, want) {
+					t.Errorf("Expected body to contain %q", want)
+				}
+			}
+
+			if strings.Contains(res.Body, "Add a Location") {
+				t.Error("Expected viewers to not be offered the form to add locations")
+			}
+		})
+	}
+}
+
+func TestApplication_locationsPost(t *testing.T) {
+	editor := models.Household{ID: uuid.New(), Name: "Home", Role: models.HouseholdRoleEditor}
+	viewer := models.Household{ID: uuid.New(), Name: "Home", Role: models.HouseholdRoleViewer}
+	created := models.Location{ID: uuid.New(), Name: "Garage"}
+	parentID := uuid.New()
+
+	testCases := []struct {
+		name              string
+		household         models.Household
+		locations         mocks.LocationModel
+		locationName      string
+		parentID          string
+		wantStatus        int
+		wantErroredFields []string
+		wantCreated       models.NewLocation
+		wantLocation      string
+	}{
+		{
+			name:         "viewer",
+			household:    viewer,
+			locationName: "Garage",
+			wantStatus:   http.StatusForbidden,
+		},
+		{
+			name:              "validation errors",
+			household:         editor,
+			parentID:          "not-a-uuid",
+			wantStatus:        http.StatusOK,
+			wantErroredFields: []string{"name", "parent_id"},
+		},
+		{
+			name:      "parent not found",
+			household: editor,
+			locations: mocks.LocationModel{
+				CreateError: models.ErrLocationParentNotFound,
+			},
+			locationName:      "Shelf 3",
+			parentID:          parentID.String(),
+			wantStatus:        http.StatusOK,
+			wantErroredFields: []string{"parent_id"},
+			wantCreated:       models.NewLocation{Name: "Shelf 3", ParentID: parentID},
+		},
+		{
+			name:      "create error",
+			household: editor,
+			locations: mocks.LocationModel{
+				CreateError: errors.New("everything broke"),
+			},
+			locationName: "Garage",
+			wantStatus:   http.StatusInternalServerError,
+			wantCreated:  models.NewLocation{Name: "Garage"},
+		},
+		{
+			name:      "success",
+			household: editor,
+			locations: mocks.LocationModel{
+				CreateReturn: created,
+			},
+			locationName: "Garage",
+			wantStatus:   http.StatusSeeOther,
+			wantCreated:  models.NewLocation{Name: "Garage"},
+			wantLocation: "/locations/" + created.ID.String(),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			templates := &CapturingTemplateEngine[application.TemplateData]{}
+
+			app := testutils.NewTestApplication(t)
+			app.Locations = &tt.locations
+
+			ts := newLocationsTestServer(t, app, tt.household)
+			form := csrfFormValues(t, app, ts, "/locations")
+			form.Add("name", tt.locationName)
+			form.Add("parent_id", tt.parentID)
+
+			app.Templates = templates
+			res := ts.PostForm(t, "/locations", form)
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			for _, field := range tt.wantErroredFields {
+				if len(templates.RenderedData.Form.Fields[field].Errors) == 0 {
+					t.Errorf("Expected %q to have errors", field)
+				}
+			}
+
+			if got := tt.locations.CreatedLocation; got != tt.wantCreated {
+				t.Errorf("Expected created location %v, got %v", tt.wantCreated, got)
+			}
+
+			if tt.wantCreated.Name != "" && tt.locations.CreatedHouseholdID != tt.household.ID {
+				t.Errorf("Expected location created in household %v, got %v", tt.household.ID, tt.locations.CreatedHouseholdID)
+			}
+
+			if got := res.Headers.Get("Location"); got != tt.wantLocation {
+				t.Errorf("Expected redirect location %q, got %q", tt.wantLocation, got)
+			}
+		})
+	}
+}
+
+func TestApplication_locationGet(t *testing.T) {
+	household := models.Household{ID: uuid.New(), Name: "Home", Role: models.HouseholdRoleOwner}
+	house := models.Location{ID: uuid.New(), Name: "House"}
+	garage := models.Location{ID: uuid.New(), ParentID: house.ID, Name: "Garage"}
+	shelf := models.Location{ID: uuid.New(), ParentID: garage.ID, Name: "Shelf 3"}
+	bin := models.Location{ID: uuid.New(), ParentID: shelf.ID, Name: "Bin B"}
+	attic := models.Location{ID: uuid.New(), Name: "Attic"}
+
+	detail := models.LocationDetail{
+		Location:    garage,
+		Breadcrumbs: []models.Location{house, garage},
+		Descendants: []models.LocationNode{
+			{Location: shelf, Depth: 1, DescendantCount: 1},
+			{Location: bin, Depth: 2},
+		},
+	}
+
+	testCases := []struct {
+		name       string
+		locations  mocks.LocationModel
+		id         string
+		wantStatus int
+		wantBody   []string
+	}{
+		{
+			name:       "invalid ID",
+			id:         "not-a-uuid",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "not found",
+			locations: mocks.LocationModel{
+				GetError: models.ErrLocationNotFound,
+			},
+			id:         garage.ID.String(),
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "get error",
+			locations: mocks.LocationModel{
+				GetError: errors.New("everything broke"),
+			},
+			id:         garage.ID.String(),
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			locations: mocks.LocationModel{
+				GetReturn: detail,
+			},
+			id:         garage.ID.String(),
+			wantStatus: http.StatusOK,
+			wantBody: []string{
+				`<a href="/locations/` + house.ID.String() + `">House</a>`,
+				`<span aria-current="page">Garage</span>`,
+				"<p>2 locations inside</p>",
+				"(1 location inside)",
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.locations.ListTreeList = []models.LocationNode{
+				{Location: attic, Depth: 1},
+				{Location: house, Depth: 1, DescendantCount: 3},
+				{Location: garage, Depth: 2, DescendantCount: 2},
+				{Location: shelf, Depth: 3, DescendantCount: 1},
+				{Location: bin, Depth: 4},
+			}
+
+			templates := &CapturingTemplateEngine[application.TemplateData]{}
+
+			app := testutils.NewTestApplication(t)
+			app.Locations = &tt.locations
+
+			ts := newLocationsTestServer(t, app, household)
+
+			res := ts.Get(t, "/locations/"+tt.id)
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			for _, want := range tt.wantBody {
+				if !strings.Contains(res.Body, want) {
+					t.Errorf("Expected body to contain %q", want)
+				}
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			if tt.locations.GotHouseholdID != household.ID {
+				t.Errorf("Expected location retrieved from household %v, got %v", household.ID, tt.locations.GotHouseholdID)
+			}
+
+			// Rendering again with a capturing engine exposes which locations are offered as
+			// destinations to move to.
+			app.Templates = templates
+			ts.Get(t, "/locations/"+tt.id)
+
+			var destinations []uuid.UUID
+			for _, node := range templates.RenderedData.LocationTree {
+				destinations = append(destinations, node.ID)
+			}
+
+			if want := []uuid.UUID{attic.ID, house.ID}; !slices.Equal(destinations, want) {
+				t.Errorf("Expected move destinations %v, got %v", want, destinations)
+			}
+		})
+	}
+}
+
+func TestApplication_locationGet_History(t *testing.T) {
+	household := models.Household{ID: uuid.New(), Name: "Home", Role: models.HouseholdRoleOwner}
+	garage := models.Location{ID: uuid.New(), Name: "Garage"}
+
+	testCases := []struct {
+		name       string
+		audit      mocks.AuditModel
+		wantStatus int
+		wantBody   []string
+	}{
+		{
+			name: "list error",
+			audit: mocks.AuditModel{
+				ListForEntityError: errors.New("everything broke"),
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "no history",
+			wantStatus: http.StatusOK,
+			wantBody:   []string{"Nothing has been recorded yet."},
+		},
+		{
+			name: "history listed",
+			audit: mocks.AuditModel{
+				ListForEntityList: []models.AuditEvent{
+					{
+						ActorEmail: "test@example.com",
+						Action:     models.AuditActionMoved,
+						Changes:    map[string]models.FieldChange{"parent_id": {New: "House"}},
+						CreatedAt:  time.Now(),
+					},
+					{
+						Action:    models.AuditActionCreated,
+						CreatedAt: time.Now(),
+					},
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantBody: []string{
+				"test@example.com",
+				"moved this",
+				"<code>parent_id</code>",
+				"A deleted user",
+				"created this",
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testutils.NewTestApplication(t)
+			app.Audit = &tt.audit
+			app.Locations = &mocks.LocationModel{
+				GetReturn: models.LocationDetail{Location: garage, Breadcrumbs: []models.Location{garage}},
+			}
+
+			ts := newLocationsTestServer(t, app, household)
+
+			res := ts.Get(t, "/locations/"+garage.ID.String())
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			for _, want := range tt.wantBody {
+				if !strings.Contains(res.Body, want) {
+					t.Errorf("Expected body to contain %q", want)
+				}
+			}
+
+			if tt.audit.ListedHouseholdID != household.ID {
+				t.Errorf("Expected history listed for household %v, got %v", household.ID, tt.audit.ListedHouseholdID)
+			}
+
+			if tt.audit.ListedEntityType != models.AuditEntityLocation || tt.audit.ListedEntityID != garage.ID {
+				t.Errorf("Expected history of location %v, got %s %v", garage.ID, tt.audit.ListedEntityType, tt.audit.ListedEntityID)
+			}
+		})
+	}
+}
+
+func TestApplication_locationMovePost(t *testing.T) {
+	editor := models.Household{ID: uuid.New(), Name: "Home", Role: models.HouseholdRoleEditor}
+	viewer := models.Household{ID: uuid.New(), Name: "Home", Role: models.HouseholdRoleViewer}
+	locationID := uuid.New()
+	parentID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		household     models.Household
+		locations     mocks.LocationModel
+		id            string
+		parentID      string
+		wantStatus    int
+		wantErrorCode string
+		wantMove      models.LocationMove
+		wantMoved     bool
+		wantLocation  string
+	}{
+		{
+			name:       "viewer",
+			household:  viewer,
+			id:         locationID.String(),
+			parentID:   parentID.String(),
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "invalid ID",
+			household:  editor,
+			id:         "not-a-uuid",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:          "invalid parent",
+			household:     editor,
+			locations:     mocks.LocationModel{GetReturn: models.LocationDetail{Location: models.Location{ID: locationID}}},
+			id:            locationID.String(),
+			parentID:      "not-a-uuid",
+			wantStatus:    http.StatusOK,
+			wantErrorCode: "invalid",
+		},
+		{
+			name:       "location not found",
+			household:  editor,
+			locations:  mocks.LocationModel{MoveError: models.ErrLocationNotFound},
+			id:         locationID.String(),
+			parentID:   parentID.String(),
+			wantStatus: http.StatusNotFound,
+			wantMove:   models.LocationMove{ParentID: parentID},
+			wantMoved:  true,
+		},
+		{
+			name:      "cycle",
+			household: editor,
+			locations: mocks.LocationModel{
+				GetReturn: models.LocationDetail{Location: models.Location{ID: locationID}},
+				MoveError: models.ErrLocationCycle,
+			},
+			id:            locationID.String(),
+			parentID:      parentID.String(),
+			wantStatus:    http.StatusOK,
+			wantErrorCode: "cycle",
+			wantMove:      models.LocationMove{ParentID: parentID},
+			wantMoved:     true,
+		},
+		{
+			name:       "move error",
+			household:  editor,
+			locations:  mocks.LocationModel{MoveError: errors.New("everything broke")},
+			id:         locationID.String(),
+			parentID:   parentID.String(),
+			wantStatus: http.StatusInternalServerError,
+			wantMove:   models.LocationMove{ParentID: parentID},
+			wantMoved:  true,
+		},
+		{
+			name:         "move to top level",
+			household:    editor,
+			id:           locationID.String(),
+			wantStatus:   http.StatusSeeOther,
+			wantMoved:    true,
+			wantLocation: "/locations/" + locationID.String(),
+		},
+		{
+			name:         "success",
+			household:    editor,
+			id:           locationID.String(),
+			parentID:     parentID.String(),
+			wantStatus:   http.StatusSeeOther,
+			wantMove:     models.LocationMove{ParentID: parentID},
+			wantMoved:    true,
+			wantLocation: "/locations/" + locationID.String(),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			templates := &CapturingTemplateEngine[application.TemplateData]{}
+
+			app := testutils.NewTestApplication(t)
+			app.Locations = &tt.locations
+
+			ts := newLocationsTestServer(t, app, tt.household)
+			form := csrfFormValues(t, app, ts, "/locations")
+			form.Add("parent_id", tt.parentID)
+
+			app.Templates = templates
+			res := ts.PostForm(t, "/locations/"+tt.id+"/move", form)
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			if tt.wantErrorCode != "" {
+				errs := templates.RenderedData.Form.Fields["parent_id"].Errors
+				if len(errs) != 1 || errs[0].Code() != tt.wantErrorCode {
+					t.Errorf("Expected a single %q error, got %v", tt.wantErrorCode, errs)
+				}
+			}
+
+			if got := tt.locations.MovedID == locationID; got != tt.wantMoved {
+				t.Errorf("Expected location moved %v, got %v", tt.wantMoved, got)
+			}
+
+			if got := tt.locations.MovedMove; got != tt.wantMove {
+				t.Errorf("Expected move %v, got %v", tt.wantMove, got)
+			}
+
+			if tt.wantMoved && tt.locations.MovedHouseholdID != tt.household.ID {
+				t.Errorf("Expected location moved in household %v, got %v", tt.household.ID, tt.locations.MovedHouseholdID)
+			}
+
+			if got := res.Headers.Get("Location"); got != tt.wantLocation {
+				t.Errorf("Expected redirect location %q, got %q", tt.wantLocation, got)
+			}
+		})
+	}
+}
+
+func TestApplication_locationLabelsGet(t *testing.T) {
+	household := models.Household{ID: uuid.New(), Name: "Home", Role: models.HouseholdRoleViewer}
+	garage := models.Location{ID: uuid.New(), Name: "Garage"}
+	shelf := models.Location{ID: uuid.New(), ParentID: garage.ID, Name: "Shelf 3"}
+
+	tree := []models.LocationNode{
+		{Location: garage, Depth: 1, DescendantCount: 1},
+		{Location: shelf, Depth: 2},
+	}
+
+	testCases := []struct {
+		name        string
+		locations   mocks.LocationModel
+		query       string
+		wantStatus  int
+		wantBody    []string
+		wantNotBody []string
+	}{
+		{
+			name: "list error",
+			locations: mocks.LocationModel{
+				ListTreeError: errors.New("everything broke"),
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "form",
+			locations:  mocks.LocationModel{ListTreeList: tree},
+			wantStatus: http.StatusOK,
+			wantBody:   []string{"Avery 5160", "Avery L7163", "Garage", "Shelf 3"},
+			wantNotBody: []string{
+				"checked",
+			},
+		},
+		{
+			name:       "form with location chosen",
+			locations:  mocks.LocationModel{ListTreeList: tree},
+			query:      "?location=" + shelf.ID.String(),
+			wantStatus: http.StatusOK,
+			wantBody:   []string{`value="` + shelf.ID.String() + `" checked`},
+		},
+		{
+			name:       "no locations chosen",
+			locations:  mocks.LocationModel{ListTreeList: tree},
+			query:      "?layout=avery-5160",
+			wantStatus: http.StatusOK,
+			wantBody:   []string{"Choose at least one location to print a label for."},
+		},
+		{
+			name:       "invalid layout",
+			locations:  mocks.LocationModel{ListTreeList: tree},
+			query:      "?layout=napkin&location=" + garage.ID.String(),
+			wantStatus: http.StatusOK,
+			wantBody: []string{
+				"Please choose one of the listed label sheets.",
+				`value="` + garage.ID.String() + `" checked`,
+			},
+		},
+		{
+			name:       "malformed location",
+			locations:  mocks.LocationModel{ListTreeList: tree},
+			query:      "?layout=avery-5160&location=not-a-uuid",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "location in another household",
+			locations:  mocks.LocationModel{ListTreeList: tree},
+			query:      "?layout=avery-5160&location=" + uuid.NewString(),
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "sheet",
+			locations:  mocks.LocationModel{ListTreeList: tree},
+			query:      "?layout=avery-l7160&location=" + garage.ID.String() + "&location=" + shelf.ID.String(),
+			wantStatus: http.StatusOK,
+			wantBody: []string{
+				"size: A4",
+				"grid-template-columns: repeat(3, 63.5mm)",
+				"<svg",
+				"Garage",
+				"Shelf 3",
+			},
+			wantNotBody: []string{"<fieldset"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testutils.NewTestApplication(t)
+			app.Locations = &tt.locations
+
+			ts := newLocationsTestServer(t, app, household)
+
+			res := ts.Get(t, "/locations/labels"+tt.query)
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			if got := tt.locations.ListedHouseholdID; got != household.ID {
+				t.Errorf("Expected locations listed for household %v, got %v", household.ID, got)
+			}
+
+			for _, want := range tt.wantBody {
+				if !strings.Contains(res.Body, want) {
+					t.Errorf("Expected body to contain %q", want)
+				}
+			}
+
+			for _, unwanted := range tt.wantNotBody {
+				if strings.Contains(res.Body, unwanted) {
+					t.Errorf("Expected body to not contain %q", unwanted)
+				}
+			}
+		})
+	}
+}
+
+func TestApplication_locationLabelsGet_Pages(t *testing.T) {
+	household := models.Household{ID: uuid.New(), Name: "Home", Role: models.HouseholdRoleViewer}
+
+	// One more location than fits on an Avery 5160 sheet.
+	query := url.Values{"layout": {"avery-5160"}}
+	locations := &mocks.LocationModel{}
+	for i := range 31 {
+		location := models.Location{ID: uuid.New(), Name: fmt.Sprintf("Bin %d", i)}
+
+		locations.ListTreeList = append(locations.ListTreeList, models.LocationNode{Location: location, Depth: 1})
+		query.Add("location", location.ID.String())
+	}
+
+	app := testutils.NewTestApplication(t)
+	app.Locations = locations
+
+	ts := newLocationsTestServer(t, app, household)
+
+	res := ts.Get(t, "/locations/labels?"+query.Encode())
+
+	if res.Status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, res.Status)
+	}
+
+	if got := strings.Count(res.Body, `<div class="label-sheet">`); got != 2 {
+		t.Errorf("Expected 2 pages of labels, got %d", got)
+	}
+
+	if got := strings.Count(res.Body, `<div class="label">`); got != 31 {
+		t.Errorf("Expected 31 labels, got %d", got)
+	}
+}
+
+func TestApplication_locationShortLinkGet(t *testing.T) {
+	home := models.Household{ID: uuid.New(), Name: "Home", Role: models.HouseholdRoleOwner}
+	cabin := models.Household{ID: uuid.New(), Name: "Cabin", Role: models.HouseholdRoleViewer}
+	userID := uuid.New()
+	locationID := uuid.New()
+	code := base64.RawURLEncoding.EncodeToString(locationID[:])
+
+	testCases := []struct {
+		name          string
+		locations     mocks.LocationModel
+		code          string
+		wantStatus    int
+		wantLocation  string
+		wantHousehold uuid.UUID
+	}{
+		{
+			name:       "malformed code",
+			code:       "not-a-code",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "not found",
+			locations: mocks.LocationModel{
+				GetHouseholdIDError: models.ErrLocationNotFound,
+			},
+			code:       code,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "lookup error",
+			locations: mocks.LocationModel{
+				GetHouseholdIDError: errors.New("everything broke"),
+			},
+			code:       code,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "active household",
+			locations: mocks.LocationModel{
+				GetHouseholdIDReturn: home.ID,
+			},
+			code:          code,
+			wantStatus:    http.StatusSeeOther,
+			wantLocation:  "/locations/" + locationID.String(),
+			wantHousehold: home.ID,
+		},
+		{
+			name: "other household",
+			locations: mocks.LocationModel{
+				GetHouseholdIDReturn: cabin.ID,
+			},
+			code:          code,
+			wantStatus:    http.StatusSeeOther,
+			wantLocation:  "/locations/" + locationID.String(),
+			wantHousehold: cabin.ID,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testutils.NewTestApplication(t)
+			app.Households = &mocks.HouseholdModel{ListForUserList: []models.Household{home, cabin}}
+			app.Locations = &tt.locations
+			app.Users = &mocks.UserModel{}
+
+			ts := testutils.NewTestServer(t, app.Routes())
+			defer ts.Close()
+
+			logIn(t, app, ts, userID)
+
+			res := ts.Get(t, "/l/"+tt.code)
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			if tt.code == code {
+				if tt.locations.GotHouseholdIDForUserID != userID || tt.locations.GotHouseholdIDForID != locationID {
+					t.Errorf("Expected household lookup of location %v for user %v, got %v for %v", locationID, userID, tt.locations.GotHouseholdIDForID, tt.locations.GotHouseholdIDForUserID)
+				}
+			}
+
+			if got := res.Headers.Get("Location"); got != tt.wantLocation {
+				t.Errorf("Expected redirect to %q, got %q", tt.wantLocation, got)
+			}
+
+			if tt.wantHousehold != uuid.Nil {
+				ts.Get(t, "/locations")
+
+				if got := tt.locations.ListedHouseholdID; got != tt.wantHousehold {
+					t.Errorf("Expected active household %v, got %v", tt.wantHousehold, got)
+				}
+			}
+		})
+	}
+}
+
+func TestApplication_locationShortLinkGet_LoggedOut(t *testing.T) {
+	household := models.Household{ID: uuid.New(), Name: "Home", Role: models.HouseholdRoleViewer}
+	locationID := uuid.New()
+	shortLink := "/l/" + base64.RawURLEncoding.EncodeToString(locationID[:])
+
+	app := testutils.NewTestApplication(t)
+	app.Households = &mocks.HouseholdModel{ListForUserList: []models.Household{household}}
+	app.Locations = &mocks.LocationModel{GetHouseholdIDReturn: household.ID}
+	app.Users = &mocks.UserModel{AuthenticateUser: models.User{ID: uuid.New()}}
+
+	ts := testutils.NewTestServer(t, app.Routes())
+	defer ts.Close()
+
+	if res := ts.Get(t, shortLink); res.Headers.Get("Location") != "/login" {
+		t.Fatalf("Expected scanning a label while logged out to redirect to login, got status %d", res.Status)
+	}
+
+	form := csrfFormValues(t, app, ts, "/login")
+	if res := ts.PostForm(t, "/login", form); res.Headers.Get("Location") != shortLink {
+		t.Fatalf("Expected login to redirect to %q, got %q", shortLink, res.Headers.Get("Location"))
+	}
+
+	res := ts.Get(t, shortLink)
+
+	if got := res.Headers.Get("Location"); got != "/locations/"+locationID.String() {
+		t.Errorf("Expected redirect to %q, got %q", "/locations/"+locationID.String(), got)
+	}
+}
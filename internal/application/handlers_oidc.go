@@ -0,0 +1,135 @@
+package application
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/cdriehuys/stuff2/internal/forms"
+	"github.com/cdriehuys/stuff2/internal/models"
+	"github.com/cdriehuys/stuff2/internal/oidc"
+	"github.com/cdriehuys/stuff2/internal/validation"
+)
+
+// The values for an in-progress OIDC login are kept in the session until the provider sends the
+// user back to the callback.
+const (
+	sessionKeyOIDCProvider = "oidc_provider"
+	sessionKeyOIDCState    = "oidc_state"
+	sessionKeyOIDCNonce    = "oidc_nonce"
+	sessionKeyOIDCVerifier = "oidc_verifier"
+)
+
+func (a *Application) oidcProvider(name string) (OIDCProvider, bool) {
+	for _, provider := range a.OIDCProviders {
+		if provider.Name() == name {
+			return provider, true
+		}
+	}
+
+	return nil, false
+}
+
+func (a *Application) oidcLoginGet(w http.ResponseWriter, r *http.Request) {
+	provider, ok := a.oidcProvider(r.PathValue("provider"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	state := rand.Text()
+	nonce := rand.Text()
+	verifier := oidc.NewVerifier()
+
+	a.Session.Put(r.Context(), sessionKeyOIDCProvider, provider.Name())
+	a.Session.Put(r.Context(), sessionKeyOIDCState, state)
+	a.Session.Put(r.Context(), sessionKeyOIDCNonce, nonce)
+	a.Session.Put(r.Context(), sessionKeyOIDCVerifier, verifier)
+
+	http.Redirect(w, r, provider.AuthCodeURL(state, nonce, verifier), http.StatusSeeOther)
+}
+
+func (a *Application) oidcCallbackGet(w http.ResponseWriter, r *http.Request) {
+	provider, ok := a.oidcProvider(r.PathValue("provider"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Popping the values ensures that each login attempt can only be completed once.
+	providerName := a.Session.PopString(r.Context(), sessionKeyOIDCProvider)
+	state := a.Session.PopString(r.Context(), sessionKeyOIDCState)
+	nonce := a.Session.PopString(r.Context(), sessionKeyOIDCNonce)
+	verifier := a.Session.PopString(r.Context(), sessionKeyOIDCVerifier)
+
+	query := r.URL.Query()
+
+	if providerName != provider.Name() || state == "" || subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(state)) != 1 {
+		a.Logger.WarnContext(r.Context(), "OIDC callback does not match a login attempt.", "provider", provider.Name())
+		a.renderLoginError(w, r, http.StatusBadRequest, "login.oidc.failed")
+		return
+	}
+
+	if errorCode := query.Get("error"); errorCode != "" {
+		a.Logger.InfoContext(r.Context(), "OIDC provider returned an error.", "provider", provider.Name(), "error", errorCode)
+		a.renderLoginError(w, r, http.StatusBadRequest, "login.oidc.failed")
+		return
+	}
+
+	claims, err := provider.Exchange(r.Context(), query.Get("code"), verifier, nonce)
+	if err != nil {
+		// A rejected code or an invalid ID token is a failed login rather than a bug, so only
+		// problems reaching the provider are server errors.
+		if errors.Is(err, oidc.ErrInvalidIDToken) || errors.Is(err, oidc.ErrTokenRequestRejected) {
+			a.Logger.WarnContext(r.Context(), "OIDC login failed.", "provider", provider.Name(), "error", err)
+			a.renderLoginError(w, r, http.StatusBadRequest, "login.oidc.failed")
+			return
+		}
+
+		a.serverError(w, r, "Failed to exchange OIDC authorization code.", err, "provider", provider.Name())
+		return
+	}
+
+	identity := models.ExternalIdentity{
+		Issuer:        claims.Issuer,
+		Provider:      provider.Name(),
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+	}
+
+	user, err := a.Users.AuthenticateExternalIdentity(r.Context(), identity)
+	if err != nil {
+		if errors.Is(err, models.ErrExternalIdentityNotLinked) {
+			a.renderLoginError(w, r, http.StatusForbidden, "login.oidc.unlinked")
+			return
+		}
+
+		a.serverError(w, r, "Failed to authenticate external identity.", err, "provider", provider.Name())
+		return
+	}
+
+	a.setAuthenticatedUser(r, user.ID)
+
+	a.redirectAfterLogin(w, r)
+}
+
+// renderLoginError renders the login page with a single error that isn't tied to a field.
+func (a *Application) renderLoginError(w http.ResponseWriter, r *http.Request, status int, key string) {
+	t := a.translator(r)
+
+	form := forms.Form{
+		Errors: []validation.Error{validation.MakeError("oidc", t.T(key))},
+		Fields: map[string]forms.Field{
+			"email":    {Name: "email"},
+			"password": {Name: "password"},
+		},
+	}
+
+	data := a.templateData(r)
+	data.Form = form
+
+	w.WriteHeader(status)
+	a.render(w, r, "login.html", data)
+}
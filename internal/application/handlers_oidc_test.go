@@ -0,0 +1,321 @@
+package application_test
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cdriehuys/stuff2/internal/application"
+	"github.com/cdriehuys/stuff2/internal/application/testutils"
+	"github.com/cdriehuys/stuff2/internal/models"
+	"github.com/cdriehuys/stuff2/internal/models/mocks"
+	"github.com/cdriehuys/stuff2/internal/oidc"
+	"github.com/cdriehuys/stuff2/internal/oidc/oidctest"
+	"github.com/cdriehuys/stuff2/internal/validation"
+	"github.com/google/uuid"
+)
+
+// newOIDCTestServer returns a test server for the application with a single OIDC provider named
+// "test" that is backed by a fake provider.
+func newOIDCTestServer(t *testing.T, app *application.Application) (*testutils.TestServer, *oidctest.Provider) {
+	fake := oidctest.NewProvider(t, "stuff", "client-secret")
+
+	ts := testutils.NewTestServer(t, app.Routes())
+	t.Cleanup(ts.Close)
+
+	config := oidc.Config{
+		Name:         "test",
+		DisplayName:  "Test SSO",
+		Issuer:       fake.Issuer(),
+		ClientID:     "stuff",
+		ClientSecret: "client-secret",
+	}
+
+	provider, err := oidc.Discover(t.Context(), fake.Client(), config, ts.URL+"/login/oidc/test/callback")
+	if err != nil {
+		t.Fatalf("Failed to discover fake provider: %v", err)
+	}
+
+	app.OIDCProviders = []application.OIDCProvider{provider}
+
+	return ts, fake
+}
+
+// startOIDCLogin starts logging in with the "test" provider and returns the callback URL that the
+// provider sends the user back to.
+func startOIDCLogin(t *testing.T, ts *testutils.TestServer, fake *oidctest.Provider) *url.URL {
+	res := ts.Get(t, "/login/oidc/test")
+	if res.Status != http.StatusSeeOther {
+		t.Fatalf("Expected redirect to provider, got status %d", res.Status)
+	}
+
+	client := *fake.Client()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	providerRes, err := client.Get(res.Headers.Get("Location"))
+	if err != nil {
+		t.Fatalf("Failed to visit provider: %v", err)
+	}
+
+	defer providerRes.Body.Close()
+
+	callback, err := url.Parse(providerRes.Header.Get("Location"))
+	if err != nil || providerRes.StatusCode != http.StatusFound {
+		t.Fatalf("Expected redirect from provider, got status %d", providerRes.StatusCode)
+	}
+
+	return callback
+}
+
+func callbackPath(callback *url.URL) string {
+	return callback.Path + "?" + callback.RawQuery
+}
+
+func TestApplication_loginGet_OIDCProviders(t *testing.T) {
+	app := testutils.NewTestApplication(t)
+	ts, _ := newOIDCTestServer(t, app)
+
+	res := ts.Get(t, "/login")
+
+	if res.Status != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, res.Status)
+	}
+
+	if want := `href="/login/oidc/test"`; !strings.Contains(res.Body, want) {
+		t.Errorf("Expected login page to link to provider with %s", want)
+	}
+}
+
+func TestApplication_oidcLoginGet_UnknownProvider(t *testing.T) {
+	app := testutils.NewTestApplication(t)
+	ts, _ := newOIDCTestServer(t, app)
+
+	for _, path := range []string{"/login/oidc/unknown", "/login/oidc/unknown/callback"} {
+		if res := ts.Get(t, path); res.Status != http.StatusNotFound {
+			t.Errorf("Expected status %d for %q, got %d", http.StatusNotFound, path, res.Status)
+		}
+	}
+}
+
+func TestApplication_oidcCallbackGet(t *testing.T) {
+	defaultUserID := uuid.New()
+
+	testCases := []struct {
+		name              string
+		users             mocks.UserModel
+		forge             bool
+		claims            map[string]any
+		tokenError        int
+		closeProvider     bool
+		modifyCallback    func(*url.URL)
+		wantStatus        int
+		wantErrorCode     string
+		wantIdentity      models.ExternalIdentity
+		wantRedirect      *WantRedirect
+		wantAuthenticated bool
+	}{
+		{
+			name: "success",
+			users: mocks.UserModel{
+				AuthenticateExternalIdentityUser: models.User{ID: defaultUserID},
+			},
+			wantIdentity: models.ExternalIdentity{
+				Provider:      "test",
+				Subject:       "test-subject",
+				Email:         "test@example.com",
+				EmailVerified: true,
+			},
+			wantRedirect: &WantRedirect{
+				Status:   http.StatusSeeOther,
+				Location: "/app",
+			},
+			wantAuthenticated: true,
+		},
+		{
+			name: "unlinked identity",
+			users: mocks.UserModel{
+				AuthenticateExternalIdentityError: models.ErrExternalIdentityNotLinked,
+			},
+			wantStatus:    http.StatusForbidden,
+			wantErrorCode: "oidc",
+			wantIdentity: models.ExternalIdentity{
+				Provider:      "test",
+				Subject:       "test-subject",
+				Email:         "test@example.com",
+				EmailVerified: true,
+			},
+		},
+		{
+			name: "error authenticating identity",
+			users: mocks.UserModel{
+				AuthenticateExternalIdentityError: errors.New("broken"),
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantIdentity: models.ExternalIdentity{
+				Provider:      "test",
+				Subject:       "test-subject",
+				Email:         "test@example.com",
+				EmailVerified: true,
+			},
+		},
+		{
+			name: "state mismatch",
+			modifyCallback: func(u *url.URL) {
+				query := u.Query()
+				query.Set("state", "forged")
+				u.RawQuery = query.Encode()
+			},
+			wantStatus:    http.StatusBadRequest,
+			wantErrorCode: "oidc",
+		},
+		{
+			name: "provider error",
+			modifyCallback: func(u *url.URL) {
+				query := u.Query()
+				query.Del("code")
+				query.Set("error", "access_denied")
+				u.RawQuery = query.Encode()
+			},
+			wantStatus:    http.StatusBadRequest,
+			wantErrorCode: "oidc",
+		},
+		{
+			name:          "invalid ID token",
+			forge:         true,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorCode: "oidc",
+		},
+		{
+			name:          "nonce mismatch",
+			claims:        map[string]any{"nonce": "forged"},
+			wantStatus:    http.StatusBadRequest,
+			wantErrorCode: "oidc",
+		},
+		{
+			name: "code rejected",
+			modifyCallback: func(u *url.URL) {
+				query := u.Query()
+				query.Set("code", "forged")
+				u.RawQuery = query.Encode()
+			},
+			wantStatus:    http.StatusBadRequest,
+			wantErrorCode: "oidc",
+		},
+		{
+			name:          "token endpoint error page",
+			tokenError:    http.StatusBadGateway,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorCode: "oidc",
+		},
+		{
+			name:          "provider unreachable",
+			closeProvider: true,
+			wantStatus:    http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			templates := CapturingTemplateEngine[application.TemplateData]{}
+
+			app := testutils.NewTestApplication(t)
+			app.Templates = &templates
+			app.Users = &tt.users
+
+			ts, fake := newOIDCTestServer(t, app)
+			fake.ForgeSignatures(tt.forge)
+			fake.SetClaims(tt.claims)
+
+			// The fake provider's issuer is only known once it is running.
+			if tt.wantIdentity != (models.ExternalIdentity{}) {
+				tt.wantIdentity.Issuer = fake.Issuer()
+			}
+
+			callback := startOIDCLogin(t, ts, fake)
+			if tt.modifyCallback != nil {
+				tt.modifyCallback(callback)
+			}
+
+			fake.FailTokenRequests(tt.tokenError)
+
+			if tt.closeProvider {
+				fake.Server.Close()
+			}
+
+			res := ts.Get(t, callbackPath(callback))
+
+			if tt.wantStatus != 0 && res.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.Status)
+			}
+
+			if tt.wantErrorCode != "" {
+				if !slices.ContainsFunc(templates.RenderedData.Form.Errors, func(e validation.Error) bool { return e.Code() == tt.wantErrorCode }) {
+					t.Errorf("Expected error with code %q, got errors %v", tt.wantErrorCode, templates.RenderedData.Form.Errors)
+				}
+			}
+
+			if got := tt.users.AuthenticatedExternalIdentity; got != tt.wantIdentity {
+				t.Errorf("Expected authenticated identity %+v, got %+v", tt.wantIdentity, got)
+			}
+
+			if want := tt.wantRedirect; want != nil {
+				if res.Status != want.Status {
+					t.Errorf("Expected status %d, got %d", want.Status, res.Status)
+				}
+
+				if got := res.Headers.Get("Location"); got != want.Location {
+					t.Errorf("Expected redirect location %q, got %q", want.Location, got)
+				}
+			}
+
+			authRes := ts.Get(t, "/app")
+
+			if tt.wantAuthenticated && authRes.Status != http.StatusOK {
+				t.Errorf("Expected user to be authenticated, but got a %d status for '/app'", authRes.Status)
+			}
+
+			if !tt.wantAuthenticated && authRes.Status == http.StatusOK {
+				t.Errorf("Expected user to not be authenticated, but they were able to retrieve '/app'")
+			}
+		})
+	}
+}
+
+func TestApplication_oidcCallbackGet_Replay(t *testing.T) {
+	users := mocks.UserModel{
+		AuthenticateExternalIdentityUser: models.User{ID: uuid.New()},
+	}
+
+	app := testutils.NewTestApplication(t)
+	app.Users = &users
+
+	ts, fake := newOIDCTestServer(t, app)
+
+	callback := startOIDCLogin(t, ts, fake)
+
+	if res := ts.Get(t, callbackPath(callback)); res.Status != http.StatusSeeOther {
+		t.Fatalf("Expected first callback to log in, got status %d", res.Status)
+	}
+
+	if res := ts.Get(t, callbackPath(callback)); res.Status != http.StatusBadRequest {
+		t.Errorf("Expected replayed callback to be rejected with status %d, got %d", http.StatusBadRequest, res.Status)
+	}
+}
+
+func TestApplication_oidcCallbackGet_WithoutLogin(t *testing.T) {
+	app := testutils.NewTestApplication(t)
+	app.Users = &mocks.UserModel{}
+
+	ts, _ := newOIDCTestServer(t, app)
+
+	res := ts.Get(t, "/login/oidc/test/callback?code=some-code&state=some-state")
+
+	if res.Status != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, res.Status)
+	}
+}
@@ -0,0 +1,149 @@
+package application
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+
+	"github.com/cdriehuys/stuff2/internal/qr"
+	"github.com/google/uuid"
+)
+
+// LabelLayout describes a sheet of labels. Lengths are CSS lengths so the sheet can be printed at
+// its actual size.
+type LabelLayout struct {
+	Name        string
+	DisplayName string
+
+	// PageSize is the CSS page size the sheet is printed on.
+	PageSize string
+
+	Columns int
+	Rows    int
+
+	LabelWidth  string
+	LabelHeight string
+
+	// MarginTop and MarginLeft are the distances from the edge of the page to the first label.
+	MarginTop  string
+	MarginLeft string
+
+	// ColumnGap is the space between adjacent labels in a row.
+	ColumnGap string
+}
+
+// labelLayouts are the label sheets that labels can be printed on, in the order they are offered.
+var labelLayouts = []LabelLayout{
+	{
+		Name:        "avery-5160",
+		DisplayName: "Avery 5160 (Letter, 30 per sheet)",
+		PageSize:    "letter",
+		Columns:     3,
+		Rows:        10,
+		LabelWidth:  "2.625in",
+		LabelHeight: "1in",
+		MarginTop:   "0.5in",
+		MarginLeft:  "0.1875in",
+		ColumnGap:   "0.125in",
+	},
+	{
+		Name:        "avery-5163",
+		DisplayName: "Avery 5163 (Letter, 10 per sheet)",
+		PageSize:    "letter",
+		Columns:     2,
+		Rows:        5,
+		LabelWidth:  "4in",
+		LabelHeight: "2in",
+		MarginTop:   "0.5in",
+		MarginLeft:  "0.15625in",
+		ColumnGap:   "0.1875in",
+	},
+	{
+		Name:        "avery-l7160",
+		DisplayName: "Avery L7160 (A4, 21 per sheet)",
+		PageSize:    "A4",
+		Columns:     3,
+		Rows:        7,
+		LabelWidth:  "63.5mm",
+		LabelHeight: "38.1mm",
+		MarginTop:   "15.15mm",
+		MarginLeft:  "7.25mm",
+		ColumnGap:   "2.5mm",
+	},
+	{
+		Name:        "avery-l7163",
+		DisplayName: "Avery L7163 (A4, 14 per sheet)",
+		PageSize:    "A4",
+		Columns:     2,
+		Rows:        7,
+		LabelWidth:  "99.1mm",
+		LabelHeight: "38.1mm",
+		MarginTop:   "15.15mm",
+		MarginLeft:  "4.65mm",
+		ColumnGap:   "2.5mm",
+	},
+}
+
+func findLabelLayout(name string) (LabelLayout, bool) {
+	for _, layout := range labelLayouts {
+		if layout.Name == name {
+			return layout, true
+		}
+	}
+
+	return LabelLayout{}, false
+}
+
+// Label is a single printed label.
+type Label struct {
+	Name string
+
+	// QRCode is an SVG image of a QR code linking to what the label is stuck on.
+	QRCode template.HTML
+}
+
+// LabelSheet is a set of labels split into the pages of a layout.
+type LabelSheet struct {
+	Layout LabelLayout
+	Pages  [][]Label
+}
+
+func newLabelSheet(layout LabelLayout, labels []Label) LabelSheet {
+	perPage := layout.Columns * layout.Rows
+
+	sheet := LabelSheet{Layout: layout}
+	for start := 0; start < len(labels); start += perPage {
+		sheet.Pages = append(sheet.Pages, labels[start:min(start+perPage, len(labels))])
+	}
+
+	return sheet
+}
+
+// locationLabel creates the label for a location. Its QR code encodes a short link so the code
+// stays small enough to scan when printed on small labels.
+func (a *Application) locationLabel(id uuid.UUID, name string) (Label, error) {
+	link := a.BaseURL.JoinPath("l", encodeShortLinkCode(id)).String()
+
+	code, err := qr.Encode(link)
+	if err != nil {
+		return Label{}, fmt.Errorf("encoding label link %q: %v", link, err)
+	}
+
+	// The SVG is generated by the QR package and contains no user input.
+	return Label{Name: name, QRCode: template.HTML(code.SVG())}, nil
+}
+
+// encodeShortLinkCode encodes an ID as the 22 character code used in short links.
+func encodeShortLinkCode(id uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString(id[:])
+}
+
+// decodeShortLinkCode parses the code from a short link.
+func decodeShortLinkCode(code string) (uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(code)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return uuid.FromBytes(raw)
+}
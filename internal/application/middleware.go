@@ -1,10 +1,15 @@
 package application
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/cdriehuys/stuff2/internal/i18n"
+	"github.com/cdriehuys/stuff2/internal/models"
+	"github.com/google/uuid"
 	"github.com/justinas/nosurf"
 )
 
@@ -38,8 +43,6 @@ func (a *Application) preventCSRF(next http.Handler) http.Handler {
 	return csrfHandler
 }
 
-var translatorContextKey = struct{ name string }{name: "translator"}
-
 func (a *Application) translatorMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t := i18n.NewRequestTranslator(a.Logger, a.Translator, r)
@@ -53,6 +56,12 @@ func (a *Application) translatorMiddleware(next http.Handler) http.Handler {
 func (a *Application) RequireAuthenticated(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if !a.isAuthenticated(r) {
+			// Send the user back to the page they were trying to view once they log in, such as
+			// when scanning a label. Other methods can't be replayed with a redirect.
+			if r.Method == http.MethodGet {
+				a.Session.Put(r.Context(), sessionKeyRedirectAfterLogin, r.URL.RequestURI())
+			}
+
 			http.Redirect(w, r, "/login", http.StatusSeeOther)
 			return
 		}
@@ -60,3 +69,91 @@ func (a *Application) RequireAuthenticated(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+var householdsContextKey = struct{ name string }{name: "households"}
+
+type requestHouseholds struct {
+	all    []models.Household
+	active models.Household
+}
+
+// LoadHouseholds loads the authenticated user's households and determines which one is active.
+// The active household is the one stored in the session, falling back to the user's first
+// household if the session doesn't reference one they still belong to.
+func (a *Application) LoadHouseholds(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		households, err := a.Households.ListForUser(r.Context(), a.getAuthenticatedUserID(r))
+		if err != nil {
+			a.serverError(w, r, "Failed to list households.", err)
+			return
+		}
+
+		loaded := requestHouseholds{all: households}
+
+		activeID, _ := a.Session.Get(r.Context(), sessionKeyHouseholdID).(string)
+		for _, household := range households {
+			if household.ID.String() == activeID {
+				loaded.active = household
+				break
+			}
+		}
+
+		if loaded.active.ID == uuid.Nil && len(households) > 0 {
+			loaded.active = households[0]
+		}
+
+		r = r.WithContext(context.WithValue(r.Context(), householdsContextKey, loaded))
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+var apiTokenContextKey = struct{ name string }{name: "apiToken"}
+
+// AuthenticateAPIToken requires requests to include a valid API token as a bearer token in the
+// `Authorization` header. It replaces session authentication and CSRF protection for API routes.
+func (a *Application) AuthenticateAPIToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			a.apiUnauthorized(w, r)
+			return
+		}
+
+		apiToken, err := a.APITokens.Authenticate(r.Context(), token)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidAPIToken) {
+				a.apiUnauthorized(w, r)
+				return
+			}
+
+			a.apiServerError(w, r, "Failed to authenticate API token.", err)
+			return
+		}
+
+		r = r.WithContext(context.WithValue(r.Context(), apiTokenContextKey, apiToken))
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireScope creates middleware that only allows requests authenticated with an API token that
+// was granted the given scope.
+func (a *Application) RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := a.apiToken(r)
+			if !ok {
+				a.apiUnauthorized(w, r)
+				return
+			}
+
+			if !token.HasScope(scope) {
+				a.apiForbidden(w, r, scope)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -2,11 +2,15 @@ package application_test
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/cdriehuys/stuff2/internal/application/testutils"
+	"github.com/cdriehuys/stuff2/internal/i18n_test"
+	"github.com/cdriehuys/stuff2/internal/models"
+	"github.com/cdriehuys/stuff2/internal/models/mocks"
 	"github.com/google/uuid"
 )
 
@@ -32,10 +36,20 @@ type mockSessionManager struct {
 	data map[string]any
 }
 
+func (m *mockSessionManager) Destroy(context.Context) error {
+	clear(m.data)
+
+	return nil
+}
+
 func (m *mockSessionManager) Get(_ context.Context, key string) any {
 	return m.data[key]
 }
 
+func (m *mockSessionManager) Iterate(ctx context.Context, fn func(context.Context) error) error {
+	return fn(ctx)
+}
+
 func (m *mockSessionManager) LoadAndSave(next http.Handler) http.Handler {
 	if m.data == nil {
 		m.data = make(map[string]any)
@@ -46,6 +60,13 @@ func (m *mockSessionManager) LoadAndSave(next http.Handler) http.Handler {
 	})
 }
 
+func (m *mockSessionManager) PopString(_ context.Context, key string) string {
+	value, _ := m.data[key].(string)
+	delete(m.data, key)
+
+	return value
+}
+
 func (m *mockSessionManager) Put(_ context.Context, key string, value any) {
 	m.data[key] = value
 }
@@ -54,13 +75,23 @@ func TestApplication_RequireAuthenticated(t *testing.T) {
 	userID := uuid.New()
 
 	testCases := []struct {
-		name           string
-		sessionManager mockSessionManager
-		wantStatus     int
-		wantLocation   string
+		name                   string
+		sessionManager         mockSessionManager
+		method                 string
+		wantStatus             int
+		wantLocation           string
+		wantRedirectAfterLogin string
 	}{
 		{
-			name:         "not authenticated",
+			name:                   "not authenticated",
+			method:                 http.MethodGet,
+			wantStatus:             http.StatusSeeOther,
+			wantLocation:           "/login",
+			wantRedirectAfterLogin: "/some/protected/route?page=2",
+		},
+		{
+			name:         "not authenticated post",
+			method:       http.MethodPost,
 			wantStatus:   http.StatusSeeOther,
 			wantLocation: "/login",
 		},
@@ -71,8 +102,10 @@ func TestApplication_RequireAuthenticated(t *testing.T) {
 					"user_id": "lizard",
 				},
 			},
-			wantStatus:   http.StatusSeeOther,
-			wantLocation: "/login",
+			method:                 http.MethodGet,
+			wantStatus:             http.StatusSeeOther,
+			wantLocation:           "/login",
+			wantRedirectAfterLogin: "/some/protected/route?page=2",
 		},
 		{
 			name: "authenticated",
@@ -81,6 +114,7 @@ func TestApplication_RequireAuthenticated(t *testing.T) {
 					"user_id": userID.String(),
 				},
 			},
+			method:     http.MethodGet,
 			wantStatus: http.StatusOK,
 		},
 	}
@@ -97,7 +131,7 @@ func TestApplication_RequireAuthenticated(t *testing.T) {
 			wrapped := tt.sessionManager.LoadAndSave(app.RequireAuthenticated(handler))
 
 			w := httptest.NewRecorder()
-			r := httptest.NewRequest(http.MethodGet, "/some/protected/route", nil)
+			r := httptest.NewRequest(tt.method, "/some/protected/route?page=2", nil)
 
 			wrapped.ServeHTTP(w, r)
 
@@ -109,6 +143,137 @@ func TestApplication_RequireAuthenticated(t *testing.T) {
 			if got := res.Header.Get("Location"); tt.wantLocation != got {
 				t.Errorf("Expected Location header %q, got %q", tt.wantLocation, got)
 			}
+
+			if got, _ := tt.sessionManager.data["redirect_after_login"].(string); got != tt.wantRedirectAfterLogin {
+				t.Errorf("Expected redirect after login %q, got %q", tt.wantRedirectAfterLogin, got)
+			}
+		})
+	}
+}
+
+func TestApplication_AuthenticateAPIToken(t *testing.T) {
+	testCases := []struct {
+		name              string
+		apiTokens         mocks.APITokenModel
+		authorization     string
+		wantAuthenticated string
+		wantStatus        int
+	}{
+		{
+			name:       "missing header",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:          "wrong scheme",
+			authorization: "Basic dXNlcjpwYXNz",
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:          "empty token",
+			authorization: "Bearer ",
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name: "invalid token",
+			apiTokens: mocks.APITokenModel{
+				AuthenticateError: models.ErrInvalidAPIToken,
+			},
+			authorization:     "Bearer stuff_invalid",
+			wantAuthenticated: "stuff_invalid",
+			wantStatus:        http.StatusUnauthorized,
+		},
+		{
+			name: "authentication error",
+			apiTokens: mocks.APITokenModel{
+				AuthenticateError: errors.New("everything broke"),
+			},
+			authorization:     "Bearer stuff_valid",
+			wantAuthenticated: "stuff_valid",
+			wantStatus:        http.StatusInternalServerError,
+		},
+		{
+			name:              "valid token",
+			authorization:     "bearer stuff_valid",
+			wantAuthenticated: "stuff_valid",
+			wantStatus:        http.StatusOK,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testutils.NewTestApplication(t)
+			app.APITokens = &tt.apiTokens
+
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/some/route", nil)
+			r = r.WithContext(i18n_test.WithMockTranslator(r.Context()))
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+
+			app.AuthenticateAPIToken(handler).ServeHTTP(w, r)
+
+			res := w.Result()
+			if res.StatusCode != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, res.StatusCode)
+			}
+
+			if got := tt.apiTokens.AuthenticatedToken; got != tt.wantAuthenticated {
+				t.Errorf("Expected authenticated token %q, got %q", tt.wantAuthenticated, got)
+			}
+
+			if tt.wantStatus == http.StatusUnauthorized && res.Header.Get("WWW-Authenticate") == "" {
+				t.Error("Expected a WWW-Authenticate header.")
+			}
+		})
+	}
+}
+
+func TestApplication_RequireScope(t *testing.T) {
+	testCases := []struct {
+		name       string
+		scopes     []string
+		wantStatus int
+	}{
+		{
+			name:       "missing scope",
+			scopes:     []string{models.ScopeItemsRead},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "has scope",
+			scopes:     []string{models.ScopeItemsRead, models.ScopeItemsWrite},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testutils.NewTestApplication(t)
+			app.APITokens = &mocks.APITokenModel{
+				AuthenticateReturn: models.APIToken{Scopes: tt.scopes},
+			}
+
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			wrapped := app.AuthenticateAPIToken(app.RequireScope(models.ScopeItemsWrite)(handler))
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/some/route", nil)
+			r = r.WithContext(i18n_test.WithMockTranslator(r.Context()))
+			r.Header.Set("Authorization", "Bearer stuff_valid")
+
+			wrapped.ServeHTTP(w, r)
+
+			if got := w.Result().StatusCode; got != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, got)
+			}
 		})
 	}
 }
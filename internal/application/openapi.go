@@ -0,0 +1,140 @@
+package application
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/cdriehuys/stuff2/internal/openapi"
+)
+
+// apiRoute defines an API endpoint. Routes are registered and documented from the same definition
+// so the OpenAPI document can't drift from the real API.
+type apiRoute struct {
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+
+	// Public routes don't require an API token.
+	Public bool
+	// Scope is the API token scope required to use the route, if any.
+	Scope string
+
+	// Request and Response are example values used to describe the request and response bodies.
+	// A nil request means the route doesn't accept a body.
+	Request  any
+	Response any
+
+	Handler http.HandlerFunc
+}
+
+func (r apiRoute) pattern() string {
+	return r.Method + " " + r.Path
+}
+
+func (a *Application) apiRoutes() []apiRoute {
+	return []apiRoute{
+		{
+			Method:      http.MethodGet,
+			Path:        "/api/v1/openapi.json",
+			OperationID: "getOpenAPIDocument",
+			Summary:     "Describe the API using OpenAPI 3.1.",
+			Public:      true,
+			Response:    map[string]any{},
+			Handler:     a.apiOpenAPIGet,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/api/v1/token",
+			OperationID: "getToken",
+			Summary:     "Describe the API token used to make the request.",
+			Response:    apiTokenResponse{},
+			Handler:     a.apiTokenGet,
+		},
+	}
+}
+
+const apiSecurityScheme = "bearerAuth"
+
+var pathParameterPattern = regexp.MustCompile(`\{([^}.]+)(\.\.\.)?\}`)
+
+func (a *Application) openAPIDocument() openapi.Document {
+	doc := openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:   "Stuff API",
+			Version: "1",
+		},
+		Paths: make(map[string]openapi.PathItem),
+		Components: openapi.Components{
+			SecuritySchemes: map[string]openapi.SecurityScheme{
+				apiSecurityScheme: {
+					Type:        "http",
+					Scheme:      "bearer",
+					Description: "A personal API token created from the account page.",
+				},
+			},
+		},
+	}
+
+	errorContent := openapi.JSONContent(openapi.SchemaFor(apiErrorResponse{}))
+
+	for _, route := range a.apiRoutes() {
+		operation := openapi.Operation{
+			OperationID: route.OperationID,
+			Summary:     route.Summary,
+			Responses: map[string]openapi.Response{
+				"200": {
+					Description: "Successful response.",
+					Content:     openapi.JSONContent(openapi.SchemaFor(route.Response)),
+				},
+				"500": {Description: "Unexpected server error.", Content: errorContent},
+			},
+		}
+
+		// Go's `{name...}` wildcards are written as plain `{name}` parameters in OpenAPI.
+		path := pathParameterPattern.ReplaceAllString(route.Path, "{$1}")
+		for _, match := range pathParameterPattern.FindAllStringSubmatch(route.Path, -1) {
+			operation.Parameters = append(operation.Parameters, openapi.Parameter{
+				Name:     match[1],
+				In:       "path",
+				Required: true,
+				Schema:   &openapi.Schema{Type: "string"},
+			})
+		}
+
+		if route.Request != nil {
+			operation.RequestBody = &openapi.RequestBody{
+				Required: true,
+				Content:  openapi.JSONContent(openapi.SchemaFor(route.Request)),
+			}
+			operation.Responses["400"] = openapi.Response{Description: "Invalid request body.", Content: errorContent}
+		}
+
+		if !route.Public {
+			var scopes []string
+			if route.Scope != "" {
+				scopes = []string{route.Scope}
+				operation.Responses["403"] = openapi.Response{Description: "The API token is missing the required scope.", Content: errorContent}
+			}
+
+			operation.Security = []openapi.SecurityRequirement{{apiSecurityScheme: scopes}}
+			operation.Responses["401"] = openapi.Response{Description: "Missing or invalid API token.", Content: errorContent}
+		}
+
+		item, exists := doc.Paths[path]
+		if !exists {
+			item = make(openapi.PathItem)
+			doc.Paths[path] = item
+		}
+
+		item[strings.ToLower(route.Method)] = operation
+	}
+
+	return doc
+}
+
+func (a *Application) apiOpenAPIGet(w http.ResponseWriter, r *http.Request) {
+	a.writeJSON(w, r, http.StatusOK, a.openAPIDocument())
+}
@@ -0,0 +1,84 @@
+package application_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cdriehuys/stuff2/internal/application/testutils"
+	"github.com/cdriehuys/stuff2/internal/openapi"
+)
+
+func fetchOpenAPIDocument(t *testing.T) openapi.Document {
+	app := testutils.NewTestApplication(t)
+	ts := testutils.NewTestServer(t, app.Routes())
+	defer ts.Close()
+
+	res := ts.Get(t, "/api/v1/openapi.json")
+
+	if res.Status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, res.Status)
+	}
+
+	if got := res.Headers.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Expected JSON content type, got %q", got)
+	}
+
+	var doc openapi.Document
+	if err := json.Unmarshal([]byte(res.Body), &doc); err != nil {
+		t.Fatalf("Failed to decode OpenAPI document: %v", err)
+	}
+
+	return doc
+}
+
+func TestApplication_apiOpenAPIGet(t *testing.T) {
+	doc := fetchOpenAPIDocument(t)
+
+	if doc.OpenAPI != openapi.Version {
+		t.Errorf("Expected OpenAPI version %q, got %q", openapi.Version, doc.OpenAPI)
+	}
+
+	operation, exists := doc.Paths["/api/v1/token"]["get"]
+	if !exists {
+		t.Fatal("Expected token route to be documented.")
+	}
+
+	if len(operation.Security) == 0 {
+		t.Error("Expected token route to require authentication.")
+	}
+
+	if _, exists := operation.Responses["401"]; !exists {
+		t.Error("Expected token route to document unauthorized responses.")
+	}
+}
+
+// Every API route registered by `Routes()` must appear in the OpenAPI document.
+func TestApplication_openAPIDocumentsAllRoutes(t *testing.T) {
+	doc := fetchOpenAPIDocument(t)
+	app := testutils.NewTestApplication(t)
+
+	for _, pattern := range app.RegisteredPatterns() {
+		method, path, found := strings.Cut(pattern, " ")
+		if !found || !strings.HasPrefix(path, "/api/") {
+			continue
+		}
+
+		path = strings.ReplaceAll(path, "...}", "}")
+
+		operation, exists := doc.Paths[path][strings.ToLower(method)]
+		if !exists {
+			t.Errorf("API route %q is not documented.", pattern)
+			continue
+		}
+
+		if operation.OperationID == "" || operation.Summary == "" {
+			t.Errorf("API route %q is missing an operation ID or summary.", pattern)
+		}
+
+		if _, exists := operation.Responses["200"]; !exists {
+			t.Errorf("API route %q does not document a successful response.", pattern)
+		}
+	}
+}
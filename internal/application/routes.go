@@ -6,28 +6,80 @@ import (
 	"github.com/justinas/alice"
 )
 
+// routeRegistrar is the part of [http.ServeMux] used to register routes.
+type routeRegistrar interface {
+	Handle(pattern string, handler http.Handler)
+}
+
 func (a *Application) Routes() http.Handler {
 	mux := http.NewServeMux()
+	a.registerRoutes(mux)
 
+	// Middleware applied to all requests.
+	standard := alice.New(a.RecoverPanic, a.translatorMiddleware)
+
+	return standard.Then(mux)
+}
+
+func (a *Application) registerRoutes(mux routeRegistrar) {
 	// Middleware applied to dynamic requests, ie requests that depend on the user who sent them.
 	dynamic := alice.New(a.Session.LoadAndSave, a.preventCSRF)
 
 	mux.Handle("GET /{$}", dynamic.ThenFunc(a.homeGet))
 	mux.Handle("GET /login", dynamic.ThenFunc(a.loginGet))
 	mux.Handle("POST /login", dynamic.ThenFunc(a.loginPost))
+	mux.Handle("POST /login/link", dynamic.ThenFunc(a.loginLinkPost))
+	mux.Handle("GET /login/link-sent", dynamic.ThenFunc(a.loginLinkSent))
+	mux.Handle("GET /login/link/{token}", dynamic.ThenFunc(a.loginLinkGet))
+	mux.Handle("POST /login/link/{token}", dynamic.ThenFunc(a.loginLinkConfirmPost))
+	mux.Handle("GET /login/oidc/{provider}", dynamic.ThenFunc(a.oidcLoginGet))
+	mux.Handle("GET /login/oidc/{provider}/callback", dynamic.ThenFunc(a.oidcCallbackGet))
 	mux.Handle("GET /register", dynamic.ThenFunc(a.registerGet))
 	mux.Handle("POST /register", dynamic.ThenFunc(a.registerPost))
 	mux.Handle("GET /register/success", dynamic.ThenFunc(a.registerSuccess))
 	mux.Handle("GET /verify-email/{token}", dynamic.ThenFunc(a.verifyEmailGet))
 	mux.Handle("POST /verify-email/{token}", dynamic.ThenFunc(a.verifyEmailPost))
 	mux.Handle("GET /verify-email-success", dynamic.ThenFunc(a.verifyEmailSuccess))
+	mux.Handle("GET /account/deleted", dynamic.ThenFunc(a.accountDeleted))
 
-	protected := dynamic.Append(a.RequireAuthenticated)
+	protected := dynamic.Append(a.RequireAuthenticated, a.LoadHouseholds)
 
 	mux.Handle("GET /app", protected.ThenFunc(a.authTestRoute))
+	mux.Handle("GET /account", protected.ThenFunc(a.accountGet))
+	mux.Handle("POST /account/delete", protected.ThenFunc(a.accountDeletePost))
+	mux.Handle("POST /account/export", protected.ThenFunc(a.accountExportPost))
+	mux.Handle("GET /account/export-requested", protected.ThenFunc(a.accountExportRequested))
+	mux.Handle("GET /account/export/{token}", protected.ThenFunc(a.accountExportGet))
+	mux.Handle("POST /account/tokens", protected.ThenFunc(a.accountTokensPost))
+	mux.Handle("POST /account/tokens/{id}/revoke", protected.ThenFunc(a.accountTokenRevokePost))
+	mux.Handle("GET /households", protected.ThenFunc(a.householdsGet))
+	mux.Handle("POST /households", protected.ThenFunc(a.householdsPost))
+	mux.Handle("POST /households/switch", protected.ThenFunc(a.householdSwitchPost))
+	mux.Handle("GET /households/{id}", protected.ThenFunc(a.householdGet))
+	mux.Handle("POST /households/{id}/invitations", protected.ThenFunc(a.householdInvitationsPost))
+	mux.Handle("GET /locations", protected.ThenFunc(a.locationsGet))
+	mux.Handle("POST /locations", protected.ThenFunc(a.locationsPost))
+	mux.Handle("GET /locations/labels", protected.ThenFunc(a.locationLabelsGet))
+	mux.Handle("GET /locations/{id}", protected.ThenFunc(a.locationGet))
+	mux.Handle("POST /locations/{id}/move", protected.ThenFunc(a.locationMovePost))
+	mux.Handle("GET /l/{code}", protected.ThenFunc(a.locationShortLinkGet))
+	mux.Handle("GET /invitations/{token}", protected.ThenFunc(a.householdInvitationGet))
+	mux.Handle("POST /invitations/{token}", protected.ThenFunc(a.householdInvitationPost))
 
-	// Middleware applied to all requests.
-	standard := alice.New(a.RecoverPanic, a.translatorMiddleware)
+	// Middleware applied to API requests. These authenticate with an API token instead of a
+	// session, so they don't need CSRF protection.
+	api := alice.New(a.AuthenticateAPIToken)
 
-	return standard.Then(mux)
+	for _, route := range a.apiRoutes() {
+		chain := api
+		if route.Public {
+			chain = alice.New()
+		}
+
+		if route.Scope != "" {
+			chain = chain.Append(a.RequireScope(route.Scope))
+		}
+
+		mux.Handle(route.pattern(), chain.ThenFunc(route.Handler))
+	}
 }
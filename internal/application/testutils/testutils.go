@@ -15,6 +15,7 @@ import (
 	"github.com/alexedwards/scs/v2"
 	"github.com/cdriehuys/stuff2/internal/application"
 	"github.com/cdriehuys/stuff2/internal/i18n"
+	"github.com/cdriehuys/stuff2/internal/models/mocks"
 	"github.com/cdriehuys/stuff2/internal/templating"
 	"github.com/cdriehuys/stuff2/translations"
 	"github.com/cdriehuys/stuff2/ui"
@@ -45,9 +46,15 @@ func NewTestApplication(t *testing.T) *application.Application {
 
 	return &application.Application{
 		Logger:     discardLogger,
+		BaseURL:    &url.URL{Scheme: "https", Host: "stuff.example.com"},
 		Session:    sessionManager,
 		Templates:  templates,
 		Translator: ut,
+
+		// Every authenticated page loads the user's households, so provide a model with no
+		// households by default. Pages that show history get no events by default.
+		Audit:      &mocks.AuditModel{},
+		Households: &mocks.HouseholdModel{},
 	}
 }
 
@@ -102,6 +109,15 @@ func (ts *TestServer) Get(t *testing.T, path string) TestResponse {
 	return ts.doRequest(t, req)
 }
 
+// GetWithToken sends a GET request authenticated with the given API token.
+func (ts *TestServer) GetWithToken(t *testing.T, path string, token string) TestResponse {
+	req := ts.makeRequest(t, http.MethodGet, path, nil)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return ts.doRequest(t, req)
+}
+
 func (ts *TestServer) PostForm(t *testing.T, path string, form url.Values) TestResponse {
 	req := ts.makeRequest(t, http.MethodPost, path, strings.NewReader(form.Encode()))
 
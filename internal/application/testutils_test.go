@@ -1,11 +1,15 @@
 package application_test
 
 import (
+	"net/http"
 	"net/url"
 	"testing"
 
 	"github.com/cdriehuys/stuff2/internal/application"
 	"github.com/cdriehuys/stuff2/internal/application/testutils"
+	"github.com/cdriehuys/stuff2/internal/models"
+	"github.com/cdriehuys/stuff2/internal/models/mocks"
+	"github.com/google/uuid"
 )
 
 func csrfFormValues(t *testing.T, app *application.Application, ts *testutils.TestServer, formURL string) url.Values {
@@ -25,3 +29,26 @@ func csrfFormValues(t *testing.T, app *application.Application, ts *testutils.Te
 
 	return form
 }
+
+// logIn authenticates the test server's client as the given user. The application's user model must
+// be a mock.
+func logIn(t *testing.T, app *application.Application, ts *testutils.TestServer, userID uuid.UUID) {
+	users, ok := app.Users.(*mocks.UserModel)
+	if !ok {
+		t.Fatalf("logging in requires a mock user model, got %T", app.Users)
+	}
+
+	authenticateUser, authenticateError := users.AuthenticateUser, users.AuthenticateError
+	defer func() {
+		users.AuthenticateUser, users.AuthenticateError = authenticateUser, authenticateError
+	}()
+
+	users.AuthenticateUser = models.User{ID: userID}
+	users.AuthenticateError = nil
+
+	form := csrfFormValues(t, app, ts, "/login")
+	res := ts.PostForm(t, "/login", form)
+	if res.Status != http.StatusSeeOther {
+		t.Fatalf("failed to log in: got status %d", res.Status)
+	}
+}
@@ -13,3 +13,10 @@ func AddToContext(ctx context.Context, t Translator) context.Context {
 func FromContext(ctx context.Context) Translator {
 	return ctx.Value(contextKeyTranslator).(Translator)
 }
+
+// Lookup returns the translator added to the context, if there is one.
+func Lookup(ctx context.Context) (Translator, bool) {
+	t, ok := ctx.Value(contextKeyTranslator).(Translator)
+
+	return t, ok
+}
@@ -0,0 +1,293 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cdriehuys/stuff2/internal/i18n"
+	"github.com/cdriehuys/stuff2/internal/models/queries"
+	"github.com/cdriehuys/stuff2/internal/validation"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	ScopeItemsRead  = "items:read"
+	ScopeItemsWrite = "items:write"
+)
+
+// APITokenScopes are the scopes that may be granted to an API token.
+var APITokenScopes = []string{ScopeItemsRead, ScopeItemsWrite}
+
+// APITokenLifetimes are the number of days an API token may be valid for.
+var APITokenLifetimes = []int{7, 30, 90, 365}
+
+// apiTokenPrefix makes tokens easy to identify, for example by secret scanners.
+const apiTokenPrefix = "stuff_"
+
+const apiTokenNameMaxLength = 100
+
+type NewAPIToken struct {
+	Name     string
+	Scopes   []string
+	Lifetime time.Duration
+}
+
+type NewAPITokenErrors struct {
+	Name     []validation.Error
+	Scopes   []validation.Error
+	Lifetime []validation.Error
+}
+
+func (e NewAPITokenErrors) Error() string {
+	return fmt.Sprintf("%#v", e)
+}
+
+func MakeNewAPIToken(ctx context.Context, name string, scopes []string, lifetimeDays string) (NewAPIToken, error) {
+	t := i18n.FromContext(ctx)
+
+	validationErrors := NewAPITokenErrors{}
+
+	trimmedName := strings.TrimSpace(name)
+	if len(trimmedName) == 0 {
+		validationErrors.Name = append(validationErrors.Name, validation.MakeError("required", t.T("api.token.name.required")))
+	} else if len(trimmedName) > apiTokenNameMaxLength {
+		validationErrors.Name = append(validationErrors.Name, validation.MakeError("max", t.C("api.token.name.length.max", apiTokenNameMaxLength, 0, t.FmtNumber(apiTokenNameMaxLength, 0))))
+	}
+
+	if len(scopes) == 0 {
+		validationErrors.Scopes = append(validationErrors.Scopes, validation.MakeError("required", t.T("api.token.scopes.required")))
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(APITokenScopes, scope) {
+			validationErrors.Scopes = append(validationErrors.Scopes, validation.MakeError("invalid", t.T("api.token.scopes.invalid", scope)))
+		}
+	}
+
+	days, err := strconv.Atoi(lifetimeDays)
+	if err != nil || !slices.Contains(APITokenLifetimes, days) {
+		validationErrors.Lifetime = append(validationErrors.Lifetime, validation.MakeError("invalid", t.T("api.token.lifetime.invalid")))
+	}
+
+	if len(validationErrors.Name) > 0 || len(validationErrors.Scopes) > 0 || len(validationErrors.Lifetime) > 0 {
+		return NewAPIToken{}, validationErrors
+	}
+
+	// Remove duplicate scopes so they're only stored once.
+	uniqueScopes := slices.Clone(scopes)
+	slices.Sort(uniqueScopes)
+	uniqueScopes = slices.Compact(uniqueScopes)
+
+	return NewAPIToken{
+		Name:     trimmedName,
+		Scopes:   uniqueScopes,
+		Lifetime: time.Duration(days) * 24 * time.Hour,
+	}, nil
+}
+
+type APIToken struct {
+	ID         int32
+	UserID     uuid.UUID
+	Name       string
+	Scopes     []string
+	ExpiresAt  time.Time
+	LastUsedAt time.Time
+	CreatedAt  time.Time
+}
+
+func makeAPIToken(token queries.APIToken) APIToken {
+	return APIToken{
+		ID:         token.ID,
+		UserID:     token.UserID,
+		Name:       token.Name,
+		Scopes:     token.Scopes,
+		ExpiresAt:  token.ExpiresAt.Time,
+		LastUsedAt: token.LastUsedAt.Time,
+		CreatedAt:  token.CreatedAt.Time,
+	}
+}
+
+func (t APIToken) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, scope)
+}
+
+type TokenHasher interface {
+	Hash(token string) string
+}
+
+type APITokenQueries interface {
+	WithTx(tx queries.DBTX) APITokenQueries
+
+	DeleteAPITokenForUser(context.Context, queries.DeleteAPITokenForUserParams) (string, error)
+	GetAPITokenByHash(ctx context.Context, tokenHash string) (queries.APIToken, error)
+	InsertAPIToken(context.Context, queries.InsertAPITokenParams) error
+	InsertAuditEvent(context.Context, queries.InsertAuditEventParams) error
+	ListAPITokensForUser(ctx context.Context, userID uuid.UUID) ([]queries.APIToken, error)
+	UpdateAPITokenLastUsed(ctx context.Context, id int32) error
+}
+
+type APITokenQueriesWrapper struct {
+	*queries.Queries
+}
+
+func (w APITokenQueriesWrapper) WithTx(tx queries.DBTX) APITokenQueries {
+	return APITokenQueriesWrapper{w.Queries.WithTx(tx.(pgx.Tx))}
+}
+
+type APITokenModel struct {
+	logger         *slog.Logger
+	hasher         TokenHasher
+	tokenGenerator TokenGenerator
+
+	db DB
+	q  APITokenQueries
+}
+
+func NewAPITokenModel(logger *slog.Logger, hasher TokenHasher, tokenGenerator TokenGenerator, db DB, queries APITokenQueries) *APITokenModel {
+	return &APITokenModel{
+		logger:         logger,
+		hasher:         hasher,
+		tokenGenerator: tokenGenerator,
+		db:             db,
+		q:              queries,
+	}
+}
+
+var ErrInvalidAPIToken = errors.New("invalid API token")
+
+// Authenticate finds the unexpired token matching the provided plaintext token and records that it
+// was used.
+func (m *APITokenModel) Authenticate(ctx context.Context, token string) (APIToken, error) {
+	storedToken, err := m.q.GetAPITokenByHash(ctx, m.hasher.Hash(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			m.logger.DebugContext(ctx, "API token does not exist.")
+
+			return APIToken{}, ErrInvalidAPIToken
+		}
+
+		return APIToken{}, fmt.Errorf("retrieving API token: %v", err)
+	}
+
+	if storedToken.ExpiresAt.Time.Before(time.Now()) {
+		m.logger.DebugContext(ctx, "API token is expired.", "tokenID", storedToken.ID)
+
+		return APIToken{}, ErrInvalidAPIToken
+	}
+
+	if err := m.q.UpdateAPITokenLastUsed(ctx, storedToken.ID); err != nil {
+		return APIToken{}, fmt.Errorf("recording API token use: %v", err)
+	}
+
+	return makeAPIToken(storedToken), nil
+}
+
+// Create persists a new token for the user and returns the plaintext token. The plaintext token is
+// not stored, so this is the only time it is available.
+func (m *APITokenModel) Create(ctx context.Context, userID uuid.UUID, token NewAPIToken) (_ string, retErr error) {
+	plaintext := apiTokenPrefix + m.tokenGenerator.Generate()
+
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("starting transaction: %v", err)
+	}
+
+	defer func() {
+		if txErr := tx.Rollback(ctx); txErr != nil && !errors.Is(txErr, pgx.ErrTxClosed) {
+			retErr = errors.Join(retErr, txErr)
+		}
+	}()
+
+	txQueries := m.q.WithTx(tx)
+
+	params := queries.InsertAPITokenParams{
+		UserID:    userID,
+		Name:      token.Name,
+		TokenHash: m.hasher.Hash(plaintext),
+		Scopes:    token.Scopes,
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(token.Lifetime), Valid: true},
+	}
+	if err := txQueries.InsertAPIToken(ctx, params); err != nil {
+		return "", fmt.Errorf("inserting API token: %v", err)
+	}
+
+	entry := securityEntry(userID, AuditActionAPITokenCreated)
+	entry.changes = map[string]FieldChange{
+		"name":   {New: token.Name},
+		"scopes": {New: strings.Join(token.Scopes, ", ")},
+	}
+	if err := recordAuditEvent(ctx, txQueries, entry); err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("committing transaction: %v", err)
+	}
+
+	m.logger.InfoContext(ctx, "Created API token.", "userID", userID)
+
+	return plaintext, nil
+}
+
+func (m *APITokenModel) ListForUser(ctx context.Context, userID uuid.UUID) ([]APIToken, error) {
+	storedTokens, err := m.q.ListAPITokensForUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("listing API tokens for user %s: %v", userID.String(), err)
+	}
+
+	tokens := make([]APIToken, 0, len(storedTokens))
+	for _, token := range storedTokens {
+		tokens = append(tokens, makeAPIToken(token))
+	}
+
+	return tokens, nil
+}
+
+// Revoke deletes one of the user's tokens. Revoking a token that doesn't exist or that belongs to a
+// different user does nothing.
+func (m *APITokenModel) Revoke(ctx context.Context, userID uuid.UUID, id int32) (retErr error) {
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("starting transaction: %v", err)
+	}
+
+	defer func() {
+		if txErr := tx.Rollback(ctx); txErr != nil && !errors.Is(txErr, pgx.ErrTxClosed) {
+			retErr = errors.Join(retErr, txErr)
+		}
+	}()
+
+	txQueries := m.q.WithTx(tx)
+
+	params := queries.DeleteAPITokenForUserParams{ID: id, UserID: userID}
+	name, err := txQueries.DeleteAPITokenForUser(ctx, params)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+
+		return fmt.Errorf("deleting API token %d: %v", id, err)
+	}
+
+	entry := securityEntry(userID, AuditActionAPITokenRevoked)
+	entry.changes = map[string]FieldChange{"name": {Old: name}}
+	if err := recordAuditEvent(ctx, txQueries, entry); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("committing transaction: %v", err)
+	}
+
+	m.logger.InfoContext(ctx, "Revoked API token.", "userID", userID, "tokenID", id)
+
+	return nil
+}
@@ -0,0 +1,597 @@
+package models_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cdriehuys/stuff2/internal/i18n_test"
+	"github.com/cdriehuys/stuff2/internal/models"
+	"github.com/cdriehuys/stuff2/internal/models/queries"
+	"github.com/cdriehuys/stuff2/internal/validation"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestMakeNewAPIToken(t *testing.T) {
+	type wantCodes struct {
+		name     []string
+		scopes   []string
+		lifetime []string
+	}
+
+	testCases := []struct {
+		name        string
+		tokenName   string
+		scopes      []string
+		lifetime    string
+		wantSuccess bool
+		wantToken   models.NewAPIToken
+		wantCodes   wantCodes
+	}{
+		{
+			name: "empty",
+			wantCodes: wantCodes{
+				name:     []string{"required"},
+				scopes:   []string{"required"},
+				lifetime: []string{"invalid"},
+			},
+		},
+		{
+			name:      "name too long",
+			tokenName: strings.Repeat("a", 101),
+			scopes:    []string{models.ScopeItemsRead},
+			lifetime:  "30",
+			wantCodes: wantCodes{
+				name: []string{"max"},
+			},
+		},
+		{
+			name:      "unknown scope",
+			tokenName: "Reports",
+			scopes:    []string{models.ScopeItemsRead, "admin"},
+			lifetime:  "30",
+			wantCodes: wantCodes{
+				scopes: []string{"invalid"},
+			},
+		},
+		{
+			name:      "unsupported lifetime",
+			tokenName: "Reports",
+			scopes:    []string{models.ScopeItemsRead},
+			lifetime:  "31",
+			wantCodes: wantCodes{
+				lifetime: []string{"invalid"},
+			},
+		},
+		{
+			name:      "non-numeric lifetime",
+			tokenName: "Reports",
+			scopes:    []string{models.ScopeItemsRead},
+			lifetime:  "forever",
+			wantCodes: wantCodes{
+				lifetime: []string{"invalid"},
+			},
+		},
+		{
+			name:        "valid data",
+			tokenName:   " Reports ",
+			scopes:      []string{models.ScopeItemsWrite, models.ScopeItemsRead, models.ScopeItemsWrite},
+			lifetime:    "7",
+			wantSuccess: true,
+			wantToken: models.NewAPIToken{
+				Name:     "Reports",
+				Scopes:   []string{models.ScopeItemsRead, models.ScopeItemsWrite},
+				Lifetime: 7 * 24 * time.Hour,
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := i18n_test.WithMockTranslator(t.Context())
+
+			token, err := models.MakeNewAPIToken(ctx, tt.tokenName, tt.scopes, tt.lifetime)
+
+			if tt.wantSuccess {
+				if err != nil {
+					t.Fatalf("Expected success, got error %v", err)
+				}
+
+				if token.Name != tt.wantToken.Name {
+					t.Errorf("Expected name %q, got %q", tt.wantToken.Name, token.Name)
+				}
+
+				if !slices.Equal(token.Scopes, tt.wantToken.Scopes) {
+					t.Errorf("Expected scopes %v, got %v", tt.wantToken.Scopes, token.Scopes)
+				}
+
+				if token.Lifetime != tt.wantToken.Lifetime {
+					t.Errorf("Expected lifetime %v, got %v", tt.wantToken.Lifetime, token.Lifetime)
+				}
+
+				return
+			}
+
+			tokenErrs := models.NewAPITokenErrors{}
+			if !errors.As(err, &tokenErrs) {
+				t.Fatalf("Expected `NewAPITokenErrors{}`, got %#v", err)
+			}
+
+			assertErrorCodes(t, "name", tt.wantCodes.name, tokenErrs.Name)
+			assertErrorCodes(t, "scopes", tt.wantCodes.scopes, tokenErrs.Scopes)
+			assertErrorCodes(t, "lifetime", tt.wantCodes.lifetime, tokenErrs.Lifetime)
+		})
+	}
+}
+
+type MockTokenHasher struct{}
+
+func (MockTokenHasher) Hash(token string) string {
+	return "hashed:" + token
+}
+
+type MockAPITokenQueries struct {
+	MockAuditEvents
+
+	deleteParams queries.DeleteAPITokenForUserParams
+	deleteReturn string
+	deleteError  error
+
+	getByHashHash   string
+	getByHashReturn queries.APIToken
+	getByHashError  error
+
+	insertParams queries.InsertAPITokenParams
+	insertError  error
+
+	listUserID uuid.UUID
+	listReturn []queries.APIToken
+	listError  error
+
+	updateLastUsedID    int32
+	updateLastUsedError error
+}
+
+func (q *MockAPITokenQueries) WithTx(queries.DBTX) models.APITokenQueries {
+	return q
+}
+
+func (q *MockAPITokenQueries) DeleteAPITokenForUser(ctx context.Context, params queries.DeleteAPITokenForUserParams) (string, error) {
+	q.deleteParams = params
+
+	return q.deleteReturn, q.deleteError
+}
+
+func (q *MockAPITokenQueries) GetAPITokenByHash(ctx context.Context, tokenHash string) (queries.APIToken, error) {
+	q.getByHashHash = tokenHash
+
+	return q.getByHashReturn, q.getByHashError
+}
+
+func (q *MockAPITokenQueries) InsertAPIToken(ctx context.Context, params queries.InsertAPITokenParams) error {
+	q.insertParams = params
+
+	return q.insertError
+}
+
+func (q *MockAPITokenQueries) ListAPITokensForUser(ctx context.Context, userID uuid.UUID) ([]queries.APIToken, error) {
+	q.listUserID = userID
+
+	return q.listReturn, q.listError
+}
+
+func (q *MockAPITokenQueries) UpdateAPITokenLastUsed(ctx context.Context, id int32) error {
+	q.updateLastUsedID = id
+
+	return q.updateLastUsedError
+}
+
+func TestAPITokenModel_Authenticate(t *testing.T) {
+	genericDBError := errors.New("generic DB error")
+	defaultUserID := uuid.New()
+
+	testCases := []struct {
+		name             string
+		queries          MockAPITokenQueries
+		token            string
+		wantLastUsedID   int32
+		wantToken        models.APIToken
+		wantErr          bool
+		wantInvalidToken bool
+	}{
+		{
+			name: "missing token",
+			queries: MockAPITokenQueries{
+				getByHashError: pgx.ErrNoRows,
+			},
+			token:            "missing",
+			wantErr:          true,
+			wantInvalidToken: true,
+		},
+		{
+			name: "error retrieving token",
+			queries: MockAPITokenQueries{
+				getByHashError: genericDBError,
+			},
+			token:   "causes-error",
+			wantErr: true,
+		},
+		{
+			name: "expired token",
+			queries: MockAPITokenQueries{
+				getByHashReturn: queries.APIToken{
+					ID:        1,
+					ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true},
+				},
+			},
+			token:            "expired",
+			wantErr:          true,
+			wantInvalidToken: true,
+		},
+		{
+			name: "error recording use",
+			queries: MockAPITokenQueries{
+				getByHashReturn: queries.APIToken{
+					ID:        2,
+					ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+				},
+				updateLastUsedError: genericDBError,
+			},
+			token:          "valid",
+			wantLastUsedID: 2,
+			wantErr:        true,
+		},
+		{
+			name: "valid token",
+			queries: MockAPITokenQueries{
+				getByHashReturn: queries.APIToken{
+					ID:        3,
+					UserID:    defaultUserID,
+					Name:      "Reports",
+					Scopes:    []string{models.ScopeItemsRead},
+					ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+				},
+			},
+			token:          "valid",
+			wantLastUsedID: 3,
+			wantToken: models.APIToken{
+				ID:     3,
+				UserID: defaultUserID,
+				Name:   "Reports",
+				Scopes: []string{models.ScopeItemsRead},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := models.NewAPITokenModel(slog.New(slog.DiscardHandler), MockTokenHasher{}, &ConstantTokenGenerator{}, &MockDB{}, &tt.queries)
+
+			token, err := tokens.Authenticate(t.Context(), tt.token)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error presence %v, got error %#v", tt.wantErr, err)
+			}
+
+			if tt.wantInvalidToken != errors.Is(err, models.ErrInvalidAPIToken) {
+				t.Errorf("Expected invalid token %v, got error %#v", tt.wantInvalidToken, err)
+			}
+
+			if want := (MockTokenHasher{}).Hash(tt.token); tt.queries.getByHashHash != want {
+				t.Errorf("Expected lookup by hash %q, got %q", want, tt.queries.getByHashHash)
+			}
+
+			if got := tt.queries.updateLastUsedID; got != tt.wantLastUsedID {
+				t.Errorf("Expected last use recorded for token %d, got %d", tt.wantLastUsedID, got)
+			}
+
+			if token.ID != tt.wantToken.ID || token.UserID != tt.wantToken.UserID || token.Name != tt.wantToken.Name {
+				t.Errorf("Expected token %#v, got %#v", tt.wantToken, token)
+			}
+
+			if !slices.Equal(token.Scopes, tt.wantToken.Scopes) {
+				t.Errorf("Expected scopes %v, got %v", tt.wantToken.Scopes, token.Scopes)
+			}
+		})
+	}
+}
+
+func TestAPITokenModel_Create(t *testing.T) {
+	userID := uuid.New()
+	newToken := models.NewAPIToken{
+		Name:     "Reports",
+		Scopes:   []string{models.ScopeItemsRead},
+		Lifetime: time.Hour,
+	}
+
+	genericDBError := errors.New("generic DB error")
+
+	testCases := []struct {
+		name         string
+		db           MockDB
+		tx           MockTX
+		queries      MockAPITokenQueries
+		wantAudit    bool
+		wantTxCommit bool
+		wantErr      bool
+	}{
+		{
+			name:    "error starting transaction",
+			db:      MockDB{beginError: genericDBError},
+			wantErr: true,
+		},
+		{
+			name: "insert error",
+			queries: MockAPITokenQueries{
+				insertError: errors.New("insert failed"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "error recording audit event",
+			queries: MockAPITokenQueries{
+				MockAuditEvents: MockAuditEvents{insertAuditError: genericDBError},
+			},
+			wantAudit: true,
+			wantErr:   true,
+		},
+		{
+			name:      "error committing",
+			tx:        MockTX{commitError: genericDBError},
+			wantAudit: true,
+			wantErr:   true,
+		},
+		{
+			name:         "success",
+			wantAudit:    true,
+			wantTxCommit: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.db.txFactory == nil {
+				tt.db.txFactory = func() models.Transaction { return &tt.tx }
+			}
+
+			tokens := models.NewAPITokenModel(slog.New(slog.DiscardHandler), MockTokenHasher{}, &ConstantTokenGenerator{token: mockToken}, &tt.db, &tt.queries)
+
+			before := time.Now()
+			plaintext, err := tokens.Create(t.Context(), userID, newToken)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error presence %v, got error %#v", tt.wantErr, err)
+			}
+
+			wantPlaintext := "stuff_" + mockToken
+			if !tt.wantErr && plaintext != wantPlaintext {
+				t.Errorf("Expected plaintext token %q, got %q", wantPlaintext, plaintext)
+			}
+
+			if tt.tx.committed != tt.wantTxCommit {
+				t.Errorf("Expected transaction commit %v, got %v", tt.wantTxCommit, tt.tx.committed)
+			}
+
+			var wantEvents []wantAuditEvent
+			if tt.wantAudit {
+				wantEvents = append(wantEvents, wantAuditEvent{
+					actorID:    userID,
+					userID:     userID,
+					entityType: models.AuditEntityUser,
+					entityID:   userID,
+					action:     models.AuditActionAPITokenCreated,
+					changes: map[string]models.FieldChange{
+						"name":   {New: "Reports"},
+						"scopes": {New: models.ScopeItemsRead},
+					},
+				})
+			}
+
+			assertAuditEvents(t, wantEvents, tt.queries.insertedAuditEvents)
+
+			if db := tt.db; db.beginError != nil {
+				return
+			}
+
+			params := tt.queries.insertParams
+			if params.UserID != userID {
+				t.Errorf("Expected token for user %v, got %v", userID, params.UserID)
+			}
+
+			if want := (MockTokenHasher{}).Hash(wantPlaintext); params.TokenHash != want {
+				t.Errorf("Expected stored hash %q, got %q", want, params.TokenHash)
+			}
+
+			if params.Name != newToken.Name {
+				t.Errorf("Expected stored name %q, got %q", newToken.Name, params.Name)
+			}
+
+			if !slices.Equal(params.Scopes, newToken.Scopes) {
+				t.Errorf("Expected stored scopes %v, got %v", newToken.Scopes, params.Scopes)
+			}
+
+			if wantExpiry := before.Add(newToken.Lifetime); params.ExpiresAt.Time.Before(wantExpiry) {
+				t.Errorf("Expected expiry after %v, got %v", wantExpiry, params.ExpiresAt.Time)
+			}
+		})
+	}
+}
+
+func TestAPITokenModel_ListForUser(t *testing.T) {
+	userID := uuid.New()
+
+	testCases := []struct {
+		name      string
+		queries   MockAPITokenQueries
+		wantNames []string
+		wantErr   bool
+	}{
+		{
+			name: "query error",
+			queries: MockAPITokenQueries{
+				listError: errors.New("query failed"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "success",
+			queries: MockAPITokenQueries{
+				listReturn: []queries.APIToken{
+					{ID: 1, Name: "Reports"},
+					{ID: 2, Name: "Imports", LastUsedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true}},
+				},
+			},
+			wantNames: []string{"Reports", "Imports"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := models.NewAPITokenModel(slog.New(slog.DiscardHandler), MockTokenHasher{}, &ConstantTokenGenerator{}, &MockDB{}, &tt.queries)
+
+			list, err := tokens.ListForUser(t.Context(), userID)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error presence %v, got error %#v", tt.wantErr, err)
+			}
+
+			if got := tt.queries.listUserID; got != userID {
+				t.Errorf("Expected tokens listed for user %v, got %v", userID, got)
+			}
+
+			var gotNames []string
+			for _, token := range list {
+				gotNames = append(gotNames, token.Name)
+			}
+
+			if !slices.Equal(gotNames, tt.wantNames) {
+				t.Errorf("Expected token names %v, got %v", tt.wantNames, gotNames)
+			}
+		})
+	}
+}
+
+func TestAPITokenModel_Revoke(t *testing.T) {
+	userID := uuid.New()
+	genericDBError := errors.New("generic DB error")
+
+	testCases := []struct {
+		name         string
+		db           MockDB
+		tx           MockTX
+		queries      MockAPITokenQueries
+		wantDeleted  bool
+		wantAudit    bool
+		wantTxCommit bool
+		wantErr      bool
+	}{
+		{
+			name:    "error starting transaction",
+			db:      MockDB{beginError: genericDBError},
+			wantErr: true,
+		},
+		{
+			name: "delete error",
+			queries: MockAPITokenQueries{
+				deleteError: errors.New("delete failed"),
+			},
+			wantDeleted: true,
+			wantErr:     true,
+		},
+		{
+			name: "token not found",
+			queries: MockAPITokenQueries{
+				deleteError: pgx.ErrNoRows,
+			},
+			wantDeleted: true,
+		},
+		{
+			name: "error recording audit event",
+			queries: MockAPITokenQueries{
+				MockAuditEvents: MockAuditEvents{insertAuditError: genericDBError},
+				deleteReturn:    "Reports",
+			},
+			wantDeleted: true,
+			wantAudit:   true,
+			wantErr:     true,
+		},
+		{
+			name:        "error committing",
+			tx:          MockTX{commitError: genericDBError},
+			queries:     MockAPITokenQueries{deleteReturn: "Reports"},
+			wantDeleted: true,
+			wantAudit:   true,
+			wantErr:     true,
+		},
+		{
+			name:         "success",
+			queries:      MockAPITokenQueries{deleteReturn: "Reports"},
+			wantDeleted:  true,
+			wantAudit:    true,
+			wantTxCommit: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.db.txFactory == nil {
+				tt.db.txFactory = func() models.Transaction { return &tt.tx }
+			}
+
+			tokens := models.NewAPITokenModel(slog.New(slog.DiscardHandler), MockTokenHasher{}, &ConstantTokenGenerator{}, &tt.db, &tt.queries)
+
+			err := tokens.Revoke(t.Context(), userID, 42)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error presence %v, got error %#v", tt.wantErr, err)
+			}
+
+			var want queries.DeleteAPITokenForUserParams
+			if tt.wantDeleted {
+				want = queries.DeleteAPITokenForUserParams{ID: 42, UserID: userID}
+			}
+
+			if got := tt.queries.deleteParams; got != want {
+				t.Errorf("Expected deletion %#v, got %#v", want, got)
+			}
+
+			if tt.tx.committed != tt.wantTxCommit {
+				t.Errorf("Expected transaction commit %v, got %v", tt.wantTxCommit, tt.tx.committed)
+			}
+
+			var wantEvents []wantAuditEvent
+			if tt.wantAudit {
+				wantEvents = append(wantEvents, wantAuditEvent{
+					actorID:    userID,
+					userID:     userID,
+					entityType: models.AuditEntityUser,
+					entityID:   userID,
+					action:     models.AuditActionAPITokenRevoked,
+					changes:    map[string]models.FieldChange{"name": {Old: "Reports"}},
+				})
+			}
+
+			assertAuditEvents(t, wantEvents, tt.queries.insertedAuditEvents)
+		})
+	}
+}
+
+func assertErrorCodes(t *testing.T, field string, wantCodes []string, errs []validation.Error) {
+	t.Helper()
+
+	if len(wantCodes) != len(errs) {
+		t.Errorf("Expected %s error codes %v, got %v", field, wantCodes, errs)
+	}
+
+	for _, wantCode := range wantCodes {
+		if !slices.ContainsFunc(errs, func(e validation.Error) bool { return e.Code() == wantCode }) {
+			t.Errorf("Expected %s error code %q in %v", field, wantCode, errs)
+		}
+	}
+}
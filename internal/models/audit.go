@@ -0,0 +1,222 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cdriehuys/stuff2/internal/models/queries"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type AuditAction string
+
+const (
+	AuditActionAPITokenCreated        AuditAction = "api_token_created"
+	AuditActionAPITokenRevoked        AuditAction = "api_token_revoked"
+	AuditActionCreated                AuditAction = "created"
+	AuditActionDataExportRequested    AuditAction = "data_export_requested"
+	AuditActionEmailVerified          AuditAction = "email_verified"
+	AuditActionExternalIdentityLinked AuditAction = "external_identity_linked"
+	AuditActionLoggedInWithLink       AuditAction = "logged_in_with_link"
+	AuditActionLoggedInWithOIDC       AuditAction = "logged_in_with_oidc"
+	AuditActionLoggedInWithPassword   AuditAction = "logged_in_with_password"
+	AuditActionMemberInvited          AuditAction = "member_invited"
+	AuditActionMemberJoined           AuditAction = "member_joined"
+	AuditActionMemberPromoted         AuditAction = "member_promoted"
+	AuditActionMoved                  AuditAction = "moved"
+)
+
+// Types of entities that audit events are recorded for.
+const (
+	AuditEntityHousehold = "household"
+	AuditEntityLocation  = "location"
+	AuditEntityUser      = "user"
+)
+
+// FieldChange is the value of a field before and after a change. Empty values mean the field had
+// no value.
+type FieldChange struct {
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+// AuditEvent records a change made to an entity.
+type AuditEvent struct {
+	ID int64
+
+	// ActorID is the user who made the change, or [uuid.Nil] if they have since deleted their
+	// account.
+	ActorID    uuid.UUID
+	ActorEmail string
+
+	EntityType string
+	EntityID   uuid.UUID
+	Action     AuditAction
+
+	// Changes are keyed by the name of the changed field.
+	Changes map[string]FieldChange
+
+	CreatedAt time.Time
+}
+
+// auditEntry is an audit event that hasn't been recorded yet. Events belong to either a household
+// or a user.
+type auditEntry struct {
+	actorID     uuid.UUID
+	householdID uuid.UUID
+	userID      uuid.UUID
+
+	entityType string
+	entityID   uuid.UUID
+	action     AuditAction
+	changes    map[string]FieldChange
+}
+
+type auditEventInserter interface {
+	InsertAuditEvent(context.Context, queries.InsertAuditEventParams) error
+}
+
+// recordAuditEvent persists an audit event. Models record events with the same queries, and
+// transaction, as the change being recorded.
+func recordAuditEvent(ctx context.Context, q auditEventInserter, entry auditEntry) error {
+	changes := entry.changes
+	if changes == nil {
+		changes = map[string]FieldChange{}
+	}
+
+	rawChanges, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("encoding audit event changes: %v", err)
+	}
+
+	params := queries.InsertAuditEventParams{
+		ActorID:     uuid.NullUUID{UUID: entry.actorID, Valid: entry.actorID != uuid.Nil},
+		HouseholdID: uuid.NullUUID{UUID: entry.householdID, Valid: entry.householdID != uuid.Nil},
+		UserID:      uuid.NullUUID{UUID: entry.userID, Valid: entry.userID != uuid.Nil},
+		EntityType:  entry.entityType,
+		EntityID:    entry.entityID,
+		Action:      string(entry.action),
+		Changes:     rawChanges,
+	}
+	if err := q.InsertAuditEvent(ctx, params); err != nil {
+		return fmt.Errorf("inserting audit event: %v", err)
+	}
+
+	return nil
+}
+
+// securityEntry creates an entry for the security log of the given user.
+func securityEntry(userID uuid.UUID, action AuditAction) auditEntry {
+	return auditEntry{
+		actorID:    userID,
+		userID:     userID,
+		entityType: AuditEntityUser,
+		entityID:   userID,
+		action:     action,
+	}
+}
+
+// auditEventListLimit is the number of most recent events returned when listing events.
+const auditEventListLimit = 50
+
+type AuditQueries interface {
+	ListAuditEventsForEntity(context.Context, queries.ListAuditEventsForEntityParams) ([]queries.ListAuditEventsForEntityRow, error)
+	ListAuditEventsForUser(context.Context, queries.ListAuditEventsForUserParams) ([]queries.ListAuditEventsForUserRow, error)
+}
+
+// AuditModel reads the audit events recorded by the other models.
+type AuditModel struct {
+	logger *slog.Logger
+
+	q AuditQueries
+}
+
+func NewAuditModel(logger *slog.Logger, queries AuditQueries) *AuditModel {
+	return &AuditModel{
+		logger: logger,
+		q:      queries,
+	}
+}
+
+// ListForEntity returns the most recent changes to an entity in the household, newest first.
+func (m *AuditModel) ListForEntity(ctx context.Context, householdID uuid.UUID, entityType string, entityID uuid.UUID) ([]AuditEvent, error) {
+	params := queries.ListAuditEventsForEntityParams{
+		HouseholdID: householdID,
+		EntityType:  entityType,
+		EntityID:    entityID,
+		MaxEvents:   auditEventListLimit,
+	}
+
+	rows, err := m.q.ListAuditEventsForEntity(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("listing audit events: %v", err)
+	}
+
+	events := make([]AuditEvent, 0, len(rows))
+	for _, row := range rows {
+		event, err := makeAuditEvent(row.ID, row.ActorID, row.ActorEmail, row.EntityType, row.EntityID, row.Action, row.Changes, row.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// ListSecurityLog returns the most recent security events for the user's account, newest first.
+func (m *AuditModel) ListSecurityLog(ctx context.Context, userID uuid.UUID) ([]AuditEvent, error) {
+	params := queries.ListAuditEventsForUserParams{
+		UserID:    userID,
+		MaxEvents: auditEventListLimit,
+	}
+
+	rows, err := m.q.ListAuditEventsForUser(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("listing security events for user %s: %v", userID.String(), err)
+	}
+
+	events := make([]AuditEvent, 0, len(rows))
+	for _, row := range rows {
+		event, err := makeAuditEvent(row.ID, row.ActorID, row.ActorEmail, row.EntityType, row.EntityID, row.Action, row.Changes, row.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+func makeAuditEvent(
+	id int64,
+	actorID uuid.NullUUID,
+	actorEmail pgtype.Text,
+	entityType string,
+	entityID uuid.UUID,
+	action string,
+	changes []byte,
+	createdAt pgtype.Timestamptz,
+) (AuditEvent, error) {
+	event := AuditEvent{
+		ID:         id,
+		ActorID:    actorID.UUID,
+		ActorEmail: actorEmail.String,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     AuditAction(action),
+		CreatedAt:  createdAt.Time,
+	}
+
+	if err := json.Unmarshal(changes, &event.Changes); err != nil {
+		return AuditEvent{}, fmt.Errorf("decoding changes for audit event %d: %v", id, err)
+	}
+
+	return event, nil
+}
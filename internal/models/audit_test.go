@@ -0,0 +1,399 @@
+package models_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cdriehuys/stuff2/internal/models"
+	"github.com/cdriehuys/stuff2/internal/models/queries"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// MockAuditEvents records the audit events inserted through a set of mock queries. It is embedded
+// in the mock queries of each model that records events.
+type MockAuditEvents struct {
+	insertedAuditEvents []queries.InsertAuditEventParams
+	insertAuditError    error
+}
+
+func (q *MockAuditEvents) InsertAuditEvent(ctx context.Context, params queries.InsertAuditEventParams) error {
+	q.insertedAuditEvents = append(q.insertedAuditEvents, params)
+
+	return q.insertAuditError
+}
+
+// wantAuditEvent describes an audit event expected to be recorded.
+type wantAuditEvent struct {
+	actorID     uuid.UUID
+	householdID uuid.UUID
+	userID      uuid.UUID
+	entityType  string
+	entityID    uuid.UUID
+	action      models.AuditAction
+	changes     map[string]models.FieldChange
+}
+
+func assertAuditEvents(t *testing.T, want []wantAuditEvent, got []queries.InsertAuditEventParams) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d audit events, got %d: %v", len(want), len(got), got)
+	}
+
+	for i, event := range got {
+		w := want[i]
+
+		if event.ActorID.UUID != w.actorID || event.ActorID.Valid != (w.actorID != uuid.Nil) {
+			t.Errorf("Expected event %d actor %v, got %v", i, w.actorID, event.ActorID)
+		}
+
+		if event.HouseholdID.UUID != w.householdID || event.HouseholdID.Valid != (w.householdID != uuid.Nil) {
+			t.Errorf("Expected event %d household %v, got %v", i, w.householdID, event.HouseholdID)
+		}
+
+		if event.UserID.UUID != w.userID || event.UserID.Valid != (w.userID != uuid.Nil) {
+			t.Errorf("Expected event %d user %v, got %v", i, w.userID, event.UserID)
+		}
+
+		if event.EntityType != w.entityType || event.EntityID != w.entityID {
+			t.Errorf("Expected event %d entity %s %v, got %s %v", i, w.entityType, w.entityID, event.EntityType, event.EntityID)
+		}
+
+		if event.Action != string(w.action) {
+			t.Errorf("Expected event %d action %q, got %q", i, w.action, event.Action)
+		}
+
+		var changes map[string]models.FieldChange
+		if err := json.Unmarshal(event.Changes, &changes); err != nil {
+			t.Fatalf("Failed to decode event %d changes: %v", i, err)
+		}
+
+		wantChanges := w.changes
+		if wantChanges == nil {
+			wantChanges = map[string]models.FieldChange{}
+		}
+
+		if !reflect.DeepEqual(changes, wantChanges) {
+			t.Errorf("Expected event %d changes %v, got %v", i, wantChanges, changes)
+		}
+	}
+}
+
+type MockAuditQueries struct {
+	entityParams queries.ListAuditEventsForEntityParams
+	entityReturn []queries.ListAuditEventsForEntityRow
+	entityError  error
+
+	userParams queries.ListAuditEventsForUserParams
+	userReturn []queries.ListAuditEventsForUserRow
+	userError  error
+}
+
+func (q *MockAuditQueries) ListAuditEventsForEntity(ctx context.Context, params queries.ListAuditEventsForEntityParams) ([]queries.ListAuditEventsForEntityRow, error) {
+	q.entityParams = params
+
+	return q.entityReturn, q.entityError
+}
+
+func (q *MockAuditQueries) ListAuditEventsForUser(ctx context.Context, params queries.ListAuditEventsForUserParams) ([]queries.ListAuditEventsForUserRow, error) {
+	q.userParams = params
+
+	return q.userReturn, q.userError
+}
+
+func TestAuditModel_ListForEntity(t *testing.T) {
+	householdID := uuid.New()
+	locationID := uuid.New()
+	actorID := uuid.New()
+	createdAt := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name    string
+		queries MockAuditQueries
+		want    []models.AuditEvent
+		wantErr bool
+	}{
+		{
+			name:    "query error",
+			queries: MockAuditQueries{entityError: errors.New("query failed")},
+			wantErr: true,
+		},
+		{
+			name: "malformed changes",
+			queries: MockAuditQueries{
+				entityReturn: []queries.ListAuditEventsForEntityRow{{ID: 1, Changes: []byte("nope")}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "events",
+			queries: MockAuditQueries{
+				entityReturn: []queries.ListAuditEventsForEntityRow{
+					{
+						ID:         2,
+						ActorID:    uuid.NullUUID{UUID: actorID, Valid: true},
+						ActorEmail: pgtype.Text{String: "test@example.com", Valid: true},
+						EntityType: models.AuditEntityLocation,
+						EntityID:   locationID,
+						Action:     string(models.AuditActionMoved),
+						Changes:    []byte(`{"parent_id":{"new":"abc"}}`),
+						CreatedAt:  pgtype.Timestamptz{Time: createdAt, Valid: true},
+					},
+					{
+						ID:         1,
+						EntityType: models.AuditEntityLocation,
+						EntityID:   locationID,
+						Action:     string(models.AuditActionCreated),
+						Changes:    []byte(`{}`),
+						CreatedAt:  pgtype.Timestamptz{Time: createdAt, Valid: true},
+					},
+				},
+			},
+			want: []models.AuditEvent{
+				{
+					ID:         2,
+					ActorID:    actorID,
+					ActorEmail: "test@example.com",
+					EntityType: models.AuditEntityLocation,
+					EntityID:   locationID,
+					Action:     models.AuditActionMoved,
+					Changes:    map[string]models.FieldChange{"parent_id": {New: "abc"}},
+					CreatedAt:  createdAt,
+				},
+				{
+					ID:         1,
+					EntityType: models.AuditEntityLocation,
+					EntityID:   locationID,
+					Action:     models.AuditActionCreated,
+					Changes:    map[string]models.FieldChange{},
+					CreatedAt:  createdAt,
+				},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			audit := models.NewAuditModel(slog.New(slog.DiscardHandler), &tt.queries)
+
+			events, err := audit.ListForEntity(t.Context(), householdID, models.AuditEntityLocation, locationID)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error presence %v, got error %#v", tt.wantErr, err)
+			}
+
+			params := tt.queries.entityParams
+			if params.HouseholdID != householdID || params.EntityType != models.AuditEntityLocation || params.EntityID != locationID {
+				t.Errorf("Expected events for location %v in household %v, got %v", locationID, householdID, params)
+			}
+
+			if params.MaxEvents <= 0 {
+				t.Errorf("Expected a positive event limit, got %d", params.MaxEvents)
+			}
+
+			if !reflect.DeepEqual(events, tt.want) {
+				t.Errorf("Expected events %v, got %v", tt.want, events)
+			}
+		})
+	}
+}
+
+func TestAuditModel_ListSecurityLog(t *testing.T) {
+	userID := uuid.New()
+
+	testCases := []struct {
+		name    string
+		queries MockAuditQueries
+		want    []models.AuditEvent
+		wantErr bool
+	}{
+		{
+			name:    "query error",
+			queries: MockAuditQueries{userError: errors.New("query failed")},
+			wantErr: true,
+		},
+		{
+			name: "events",
+			queries: MockAuditQueries{
+				userReturn: []queries.ListAuditEventsForUserRow{
+					{
+						ID:         1,
+						ActorID:    uuid.NullUUID{UUID: userID, Valid: true},
+						ActorEmail: pgtype.Text{String: "test@example.com", Valid: true},
+						EntityType: models.AuditEntityUser,
+						EntityID:   userID,
+						Action:     string(models.AuditActionLoggedInWithPassword),
+						Changes:    []byte(`{}`),
+					},
+				},
+			},
+			want: []models.AuditEvent{
+				{
+					ID:         1,
+					ActorID:    userID,
+					ActorEmail: "test@example.com",
+					EntityType: models.AuditEntityUser,
+					EntityID:   userID,
+					Action:     models.AuditActionLoggedInWithPassword,
+					Changes:    map[string]models.FieldChange{},
+				},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			audit := models.NewAuditModel(slog.New(slog.DiscardHandler), &tt.queries)
+
+			events, err := audit.ListSecurityLog(t.Context(), userID)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error presence %v, got error %#v", tt.wantErr, err)
+			}
+
+			if got := tt.queries.userParams.UserID; got != userID {
+				t.Errorf("Expected events for user %v, got %v", userID, got)
+			}
+
+			if !reflect.DeepEqual(events, tt.want) {
+				t.Errorf("Expected events %v, got %v", tt.want, events)
+			}
+		})
+	}
+}
+
+// TestAuditEvents_AppendOnly checks the triggers that keep the audit log append-only. It needs a
+// PostgreSQL database given by TEST_DB_CONN. The migrations are applied to a schema that only
+// exists inside a transaction, so nothing is left behind.
+func TestAuditEvents_AppendOnly(t *testing.T) {
+	connString := os.Getenv("TEST_DB_CONN")
+	if connString == "" {
+		t.Skip("TEST_DB_CONN is not set.")
+	}
+
+	conn, err := pgx.Connect(t.Context(), connString)
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	defer conn.Close(context.Background())
+
+	tx, err := conn.Begin(t.Context())
+	if err != nil {
+		t.Fatalf("Failed to start transaction: %v", err)
+	}
+
+	defer tx.Rollback(context.Background())
+
+	mustExec := func(sql string, args ...any) {
+		t.Helper()
+
+		if _, err := tx.Exec(t.Context(), sql, args...); err != nil {
+			t.Fatalf("Failed to execute %q: %v", sql, err)
+		}
+	}
+
+	countEvents := func(where string, args ...any) int {
+		t.Helper()
+
+		var count int
+		if err := tx.QueryRow(t.Context(), "SELECT count(*) FROM audit_events WHERE "+where, args...).Scan(&count); err != nil {
+			t.Fatalf("Failed to count audit events: %v", err)
+		}
+
+		return count
+	}
+
+	mustExec("CREATE SCHEMA audit_test")
+	mustExec("SET LOCAL search_path TO audit_test")
+
+	migrations, err := filepath.Glob("../../migrations/*.sql")
+	if err != nil || len(migrations) == 0 {
+		t.Fatalf("Failed to find migrations: %v", err)
+	}
+
+	slices.Sort(migrations)
+	for _, migration := range migrations {
+		contents, err := os.ReadFile(migration)
+		if err != nil {
+			t.Fatalf("Failed to read migration: %v", err)
+		}
+
+		up, _, _ := strings.Cut(string(contents), "---- create above / drop below ----")
+		mustExec(up)
+	}
+
+	userID, householdID := uuid.New(), uuid.New()
+
+	mustExec("INSERT INTO users(id, email, password_hash) VALUES ($1, 'test@example.com', 'hash')", userID)
+	mustExec("INSERT INTO households(id, name) VALUES ($1, 'Home')", householdID)
+	mustExec(
+		`INSERT INTO audit_events(actor_id, household_id, entity_type, entity_id, action)
+		VALUES ($1, $2, 'household', $2, 'created')`,
+		userID,
+		householdID,
+	)
+	mustExec(
+		`INSERT INTO audit_events(actor_id, user_id, entity_type, entity_id, action, changes)
+		VALUES ($1, $1, 'user', $1, 'created', '{"email": {"new": "test@example.com"}}')`,
+		userID,
+	)
+
+	rejected := []string{
+		"UPDATE audit_events SET action = 'forged'",
+		"UPDATE audit_events SET actor_id = NULL, action = 'forged'",
+		"DELETE FROM audit_events",
+		"DELETE FROM audit_events WHERE user_id IS NOT NULL",
+	}
+
+	for _, sql := range rejected {
+		savepoint, err := tx.Begin(t.Context())
+		if err != nil {
+			t.Fatalf("Failed to create savepoint: %v", err)
+		}
+
+		if _, err := savepoint.Exec(t.Context(), sql); err == nil {
+			t.Errorf("Expected %q to be rejected.", sql)
+		}
+
+		if err := savepoint.Rollback(t.Context()); err != nil {
+			t.Fatalf("Failed to roll back savepoint: %v", err)
+		}
+	}
+
+	if got := countEvents("true"); got != 2 {
+		t.Fatalf("Expected both events to remain, got %d", got)
+	}
+
+	// Deleting the user forgets them as the actor of household events, and deletes their own.
+	mustExec("DELETE FROM users WHERE id = $1", userID)
+
+	if got := countEvents("household_id = $1 AND actor_id IS NULL", householdID); got != 1 {
+		t.Errorf("Expected household event to remain without an actor, got %d", got)
+	}
+
+	if got := countEvents("user_id = $1", userID); got != 0 {
+		t.Errorf("Expected user events to be deleted with the user, got %d", got)
+	}
+
+	if got := countEvents("changes::text LIKE '%test@example.com%'"); got != 0 {
+		t.Errorf("Expected no events to mention the deleted user's email, got %d", got)
+	}
+
+	mustExec("DELETE FROM households WHERE id = $1", householdID)
+
+	if got := countEvents("true"); got != 0 {
+		t.Errorf("Expected household events to be deleted with the household, got %d", got)
+	}
+}
@@ -0,0 +1,449 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cdriehuys/stuff2/internal/i18n"
+	"github.com/cdriehuys/stuff2/internal/models/queries"
+	"github.com/cdriehuys/stuff2/internal/validation"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// HouseholdRole determines what a member may do in a household.
+type HouseholdRole string
+
+const (
+	HouseholdRoleOwner  HouseholdRole = "owner"
+	HouseholdRoleEditor HouseholdRole = "editor"
+	HouseholdRoleViewer HouseholdRole = "viewer"
+)
+
+// HouseholdRoles are the roles a household member may have, from most to least privileged.
+var HouseholdRoles = []HouseholdRole{HouseholdRoleOwner, HouseholdRoleEditor, HouseholdRoleViewer}
+
+// CanEdit reports whether the role allows changing the household's inventory.
+func (r HouseholdRole) CanEdit() bool {
+	return r == HouseholdRoleOwner || r == HouseholdRoleEditor
+}
+
+// CanManage reports whether the role allows managing the household's members.
+func (r HouseholdRole) CanManage() bool {
+	return r == HouseholdRoleOwner
+}
+
+const householdNameMaxLength = 100
+
+type NewHousehold struct {
+	Name string
+}
+
+type NewHouseholdErrors struct {
+	Name []validation.Error
+}
+
+func (e NewHouseholdErrors) Error() string {
+	return fmt.Sprintf("%#v", e)
+}
+
+func MakeNewHousehold(ctx context.Context, name string) (NewHousehold, error) {
+	t := i18n.FromContext(ctx)
+
+	validationErrors := NewHouseholdErrors{}
+
+	trimmedName := strings.TrimSpace(name)
+	if len(trimmedName) == 0 {
+		validationErrors.Name = append(validationErrors.Name, validation.MakeError("required", t.T("household.name.required")))
+	} else if len(trimmedName) > householdNameMaxLength {
+		validationErrors.Name = append(validationErrors.Name, validation.MakeError("max", t.C("household.name.length.max", householdNameMaxLength, 0, t.FmtNumber(householdNameMaxLength, 0))))
+	}
+
+	if len(validationErrors.Name) > 0 {
+		return NewHousehold{}, validationErrors
+	}
+
+	return NewHousehold{Name: trimmedName}, nil
+}
+
+type NewHouseholdInvitation struct {
+	Email string
+	Role  HouseholdRole
+}
+
+type NewHouseholdInvitationErrors struct {
+	Email []validation.Error
+	Role  []validation.Error
+}
+
+func (e NewHouseholdInvitationErrors) Error() string {
+	return fmt.Sprintf("%#v", e)
+}
+
+func MakeNewHouseholdInvitation(ctx context.Context, email string, role string) (NewHouseholdInvitation, error) {
+	t := i18n.FromContext(ctx)
+
+	validationErrors := NewHouseholdInvitationErrors{}
+
+	trimmedEmail := strings.TrimSpace(email)
+	if len(trimmedEmail) == 0 {
+		validationErrors.Email = append(validationErrors.Email, validation.MakeError("required", t.T("user.email.required")))
+	} else if len(trimmedEmail) < 3 || len(trimmedEmail) > 254 || !strings.Contains(trimmedEmail, "@") {
+		validationErrors.Email = append(validationErrors.Email, validation.MakeError("email", t.T("user.email.invalid")))
+	}
+
+	if !slices.Contains(HouseholdRoles, HouseholdRole(role)) {
+		validationErrors.Role = append(validationErrors.Role, validation.MakeError("invalid", t.T("household.role.invalid")))
+	}
+
+	if len(validationErrors.Email) > 0 || len(validationErrors.Role) > 0 {
+		return NewHouseholdInvitation{}, validationErrors
+	}
+
+	return NewHouseholdInvitation{Email: trimmedEmail, Role: HouseholdRole(role)}, nil
+}
+
+// Household is a household as seen by one of its members.
+type Household struct {
+	ID   uuid.UUID
+	Name string
+
+	// Role is the member's role in the household.
+	Role HouseholdRole
+}
+
+type HouseholdMember struct {
+	UserID uuid.UUID
+	Email  string
+	Role   HouseholdRole
+}
+
+type HouseholdInviter interface {
+	HouseholdInvitation(ctx context.Context, email string, householdName string, token string) error
+}
+
+type HouseholdQueries interface {
+	WithTx(tx queries.DBTX) HouseholdQueries
+
+	DeleteHouseholdInvitationByID(ctx context.Context, id int32) error
+	GetHouseholdForMember(context.Context, queries.GetHouseholdForMemberParams) (queries.GetHouseholdForMemberRow, error)
+	GetHouseholdInvitationByToken(ctx context.Context, token string) (queries.HouseholdInvitation, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (queries.User, error)
+	InsertAuditEvent(context.Context, queries.InsertAuditEventParams) error
+	InsertHousehold(context.Context, queries.InsertHouseholdParams) error
+	InsertHouseholdInvitation(context.Context, queries.InsertHouseholdInvitationParams) error
+	InsertHouseholdMember(context.Context, queries.InsertHouseholdMemberParams) (int64, error)
+	ListHouseholdMembers(ctx context.Context, householdID uuid.UUID) ([]queries.ListHouseholdMembersRow, error)
+	ListHouseholdsForUser(ctx context.Context, userID uuid.UUID) ([]queries.ListHouseholdsForUserRow, error)
+}
+
+type HouseholdQueriesWrapper struct {
+	*queries.Queries
+}
+
+func (w HouseholdQueriesWrapper) WithTx(tx queries.DBTX) HouseholdQueries {
+	return HouseholdQueriesWrapper{w.Queries.WithTx(tx.(pgx.Tx))}
+}
+
+type HouseholdModel struct {
+	logger             *slog.Logger
+	inviter            HouseholdInviter
+	tokenGenerator     TokenGenerator
+	invitationLifetime time.Duration
+
+	db DB
+	q  HouseholdQueries
+}
+
+func NewHouseholdModel(
+	logger *slog.Logger,
+	inviter HouseholdInviter,
+	tokenGenerator TokenGenerator,
+	invitationLifetime time.Duration,
+	db DB,
+	queries HouseholdQueries,
+) *HouseholdModel {
+	return &HouseholdModel{
+		logger:             logger,
+		inviter:            inviter,
+		tokenGenerator:     tokenGenerator,
+		invitationLifetime: invitationLifetime,
+		db:                 db,
+		q:                  queries,
+	}
+}
+
+var (
+	// ErrHouseholdNotFound is returned for households that don't exist and households the user
+	// isn't a member of, so that the two can't be told apart.
+	ErrHouseholdNotFound = errors.New("household not found")
+
+	ErrHouseholdForbidden         = errors.New("household role does not allow this action")
+	ErrInvalidHouseholdInvitation = errors.New("invalid household invitation")
+
+	// ErrAlreadyHouseholdMember is returned when accepting an invitation to a household the user
+	// already belongs to. Their existing role is left as it is.
+	ErrAlreadyHouseholdMember = errors.New("already a household member")
+)
+
+// Create persists a new household with the user as its owner.
+func (m *HouseholdModel) Create(ctx context.Context, userID uuid.UUID, household NewHousehold) (_ Household, retErr error) {
+	householdID := uuid.New()
+
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return Household{}, fmt.Errorf("starting transaction: %v", err)
+	}
+
+	defer func() {
+		if txErr := tx.Rollback(ctx); txErr != nil && !errors.Is(txErr, pgx.ErrTxClosed) {
+			retErr = errors.Join(retErr, txErr)
+		}
+	}()
+
+	txQueries := m.q.WithTx(tx)
+
+	householdParams := queries.InsertHouseholdParams{
+		ID:   householdID,
+		Name: household.Name,
+	}
+	if err := txQueries.InsertHousehold(ctx, householdParams); err != nil {
+		return Household{}, fmt.Errorf("inserting household: %v", err)
+	}
+
+	memberParams := queries.InsertHouseholdMemberParams{
+		HouseholdID: householdID,
+		UserID:      userID,
+		Role:        string(HouseholdRoleOwner),
+	}
+	if _, err := txQueries.InsertHouseholdMember(ctx, memberParams); err != nil {
+		return Household{}, fmt.Errorf("inserting household owner: %v", err)
+	}
+
+	entry := auditEntry{
+		actorID:     userID,
+		householdID: householdID,
+		entityType:  AuditEntityHousehold,
+		entityID:    householdID,
+		action:      AuditActionCreated,
+		changes:     map[string]FieldChange{"name": {New: household.Name}},
+	}
+	if err := recordAuditEvent(ctx, txQueries, entry); err != nil {
+		return Household{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Household{}, fmt.Errorf("committing transaction: %v", err)
+	}
+
+	m.logger.InfoContext(ctx, "Created household.", "householdID", householdID, "userID", userID)
+
+	return Household{ID: householdID, Name: household.Name, Role: HouseholdRoleOwner}, nil
+}
+
+// GetForUser returns a household that the user is a member of.
+func (m *HouseholdModel) GetForUser(ctx context.Context, userID uuid.UUID, householdID uuid.UUID) (Household, error) {
+	params := queries.GetHouseholdForMemberParams{
+		HouseholdID: householdID,
+		UserID:      userID,
+	}
+
+	household, err := m.q.GetHouseholdForMember(ctx, params)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Household{}, ErrHouseholdNotFound
+		}
+
+		return Household{}, fmt.Errorf("retrieving household: %v", err)
+	}
+
+	return Household{ID: household.ID, Name: household.Name, Role: HouseholdRole(household.Role)}, nil
+}
+
+// ListForUser returns the households the user is a member of, ordered by name.
+func (m *HouseholdModel) ListForUser(ctx context.Context, userID uuid.UUID) ([]Household, error) {
+	rows, err := m.q.ListHouseholdsForUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("listing households: %v", err)
+	}
+
+	households := make([]Household, len(rows))
+	for i, row := range rows {
+		households[i] = Household{ID: row.ID, Name: row.Name, Role: HouseholdRole(row.Role)}
+	}
+
+	return households, nil
+}
+
+// ListMembers returns the members of a household that the user is also a member of.
+func (m *HouseholdModel) ListMembers(ctx context.Context, userID uuid.UUID, householdID uuid.UUID) ([]HouseholdMember, error) {
+	if _, err := m.GetForUser(ctx, userID, householdID); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.q.ListHouseholdMembers(ctx, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("listing household members: %v", err)
+	}
+
+	members := make([]HouseholdMember, len(rows))
+	for i, row := range rows {
+		members[i] = HouseholdMember{UserID: row.UserID, Email: row.Email, Role: HouseholdRole(row.Role)}
+	}
+
+	return members, nil
+}
+
+// Invite emails an invitation to join the household. Only owners may invite new members.
+func (m *HouseholdModel) Invite(ctx context.Context, userID uuid.UUID, householdID uuid.UUID, invitation NewHouseholdInvitation) (retErr error) {
+	household, err := m.GetForUser(ctx, userID, householdID)
+	if err != nil {
+		return err
+	}
+
+	if !household.Role.CanManage() {
+		return ErrHouseholdForbidden
+	}
+
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("starting transaction: %v", err)
+	}
+
+	defer func() {
+		if txErr := tx.Rollback(ctx); txErr != nil && !errors.Is(txErr, pgx.ErrTxClosed) {
+			retErr = errors.Join(retErr, txErr)
+		}
+	}()
+
+	txQueries := m.q.WithTx(tx)
+
+	token := m.tokenGenerator.Generate()
+
+	params := queries.InsertHouseholdInvitationParams{
+		HouseholdID: householdID,
+		Email:       invitation.Email,
+		Role:        string(invitation.Role),
+		Token:       token,
+		InvitedBy:   userID,
+	}
+	if err := txQueries.InsertHouseholdInvitation(ctx, params); err != nil {
+		return fmt.Errorf("inserting household invitation: %v", err)
+	}
+
+	entry := auditEntry{
+		actorID:     userID,
+		householdID: householdID,
+		entityType:  AuditEntityHousehold,
+		entityID:    householdID,
+		action:      AuditActionMemberInvited,
+		// The invitee's email isn't recorded because the event outlives the invitation and any
+		// account the invitee creates. Their email is shown once they join.
+		changes: map[string]FieldChange{"role": {New: string(invitation.Role)}},
+	}
+	if err := recordAuditEvent(ctx, txQueries, entry); err != nil {
+		return err
+	}
+
+	if err := m.inviter.HouseholdInvitation(ctx, invitation.Email, household.Name, token); err != nil {
+		return fmt.Errorf("sending household invitation: %v", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("committing transaction: %v", err)
+	}
+
+	m.logger.InfoContext(ctx, "Created household invitation.", "householdID", householdID, "userID", userID)
+
+	return nil
+}
+
+// AcceptInvitation adds the user to the household they were invited to. The invitation must have
+// been sent to the user's email address.
+func (m *HouseholdModel) AcceptInvitation(ctx context.Context, userID uuid.UUID, token string) (_ Household, retErr error) {
+	invitation, err := m.q.GetHouseholdInvitationByToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			m.logger.DebugContext(ctx, "Household invitation does not exist.")
+
+			return Household{}, ErrInvalidHouseholdInvitation
+		}
+
+		return Household{}, fmt.Errorf("retrieving household invitation: %v", err)
+	}
+
+	if invitation.CreatedAt.Time.Add(m.invitationLifetime).Before(time.Now()) {
+		m.logger.DebugContext(ctx, "Household invitation is expired.", "invitationID", invitation.ID)
+
+		return Household{}, ErrInvalidHouseholdInvitation
+	}
+
+	user, err := m.q.GetUserByID(ctx, userID)
+	if err != nil {
+		return Household{}, fmt.Errorf("retrieving user: %v", err)
+	}
+
+	if !strings.EqualFold(user.Email, invitation.Email) {
+		m.logger.InfoContext(ctx, "Household invitation was sent to a different email.", "invitationID", invitation.ID, "userID", userID)
+
+		return Household{}, ErrInvalidHouseholdInvitation
+	}
+
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return Household{}, fmt.Errorf("starting transaction: %v", err)
+	}
+
+	defer func() {
+		if txErr := tx.Rollback(ctx); txErr != nil && !errors.Is(txErr, pgx.ErrTxClosed) {
+			retErr = errors.Join(retErr, txErr)
+		}
+	}()
+
+	txQueries := m.q.WithTx(tx)
+
+	memberParams := queries.InsertHouseholdMemberParams{
+		HouseholdID: invitation.HouseholdID,
+		UserID:      userID,
+		Role:        invitation.Role,
+	}
+	inserted, err := txQueries.InsertHouseholdMember(ctx, memberParams)
+	if err != nil {
+		return Household{}, fmt.Errorf("inserting household member: %v", err)
+	}
+
+	if inserted == 0 {
+		m.logger.InfoContext(ctx, "User is already a member of the invited household.", "invitationID", invitation.ID, "userID", userID)
+
+		return Household{}, ErrAlreadyHouseholdMember
+	}
+
+	if err := txQueries.DeleteHouseholdInvitationByID(ctx, invitation.ID); err != nil {
+		return Household{}, fmt.Errorf("deleting used household invitation: %v", err)
+	}
+
+	entry := auditEntry{
+		actorID:     userID,
+		householdID: invitation.HouseholdID,
+		entityType:  AuditEntityHousehold,
+		entityID:    invitation.HouseholdID,
+		action:      AuditActionMemberJoined,
+		changes:     map[string]FieldChange{"role": {New: invitation.Role}},
+	}
+	if err := recordAuditEvent(ctx, txQueries, entry); err != nil {
+		return Household{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Household{}, fmt.Errorf("committing transaction: %v", err)
+	}
+
+	m.logger.InfoContext(ctx, "User joined household.", "householdID", invitation.HouseholdID, "userID", userID)
+
+	return m.GetForUser(ctx, userID, invitation.HouseholdID)
+}
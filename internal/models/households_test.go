@@ -0,0 +1,839 @@
+package models_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cdriehuys/stuff2/internal/i18n_test"
+	"github.com/cdriehuys/stuff2/internal/models"
+	"github.com/cdriehuys/stuff2/internal/models/queries"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestMakeNewHousehold(t *testing.T) {
+	testCases := []struct {
+		name          string
+		householdName string
+		wantSuccess   bool
+		wantHousehold models.NewHousehold
+		wantCodes     []string
+	}{
+		{
+			name:      "empty",
+			wantCodes: []string{"required"},
+		},
+		{
+			name:          "whitespace",
+			householdName: "   ",
+			wantCodes:     []string{"required"},
+		},
+		{
+			name:          "name too long",
+			householdName: strings.Repeat("a", 101),
+			wantCodes:     []string{"max"},
+		},
+		{
+			name:          "valid data",
+			householdName: " The Cabin ",
+			wantSuccess:   true,
+			wantHousehold: models.NewHousehold{Name: "The Cabin"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := i18n_test.WithMockTranslator(t.Context())
+
+			household, err := models.MakeNewHousehold(ctx, tt.householdName)
+
+			if tt.wantSuccess {
+				if err != nil {
+					t.Fatalf("Expected success, got error %v", err)
+				}
+
+				if household != tt.wantHousehold {
+					t.Errorf("Expected household %v, got %v", tt.wantHousehold, household)
+				}
+
+				return
+			}
+
+			householdErrs := models.NewHouseholdErrors{}
+			if !errors.As(err, &householdErrs) {
+				t.Fatalf("Expected `NewHouseholdErrors{}`, got %#v", err)
+			}
+
+			assertErrorCodes(t, "name", tt.wantCodes, householdErrs.Name)
+		})
+	}
+}
+
+func TestMakeNewHouseholdInvitation(t *testing.T) {
+	type wantCodes struct {
+		email []string
+		role  []string
+	}
+
+	testCases := []struct {
+		name           string
+		email          string
+		role           string
+		wantSuccess    bool
+		wantInvitation models.NewHouseholdInvitation
+		wantCodes      wantCodes
+	}{
+		{
+			name: "empty",
+			wantCodes: wantCodes{
+				email: []string{"required"},
+				role:  []string{"invalid"},
+			},
+		},
+		{
+			name:  "invalid email",
+			email: "not-an-email",
+			role:  "viewer",
+			wantCodes: wantCodes{
+				email: []string{"email"},
+			},
+		},
+		{
+			name:  "unknown role",
+			email: "test@example.com",
+			role:  "admin",
+			wantCodes: wantCodes{
+				role: []string{"invalid"},
+			},
+		},
+		{
+			name:        "valid data",
+			email:       " test@example.com ",
+			role:        "editor",
+			wantSuccess: true,
+			wantInvitation: models.NewHouseholdInvitation{
+				Email: "test@example.com",
+				Role:  models.HouseholdRoleEditor,
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := i18n_test.WithMockTranslator(t.Context())
+
+			invitation, err := models.MakeNewHouseholdInvitation(ctx, tt.email, tt.role)
+
+			if tt.wantSuccess {
+				if err != nil {
+					t.Fatalf("Expected success, got error %v", err)
+				}
+
+				if invitation != tt.wantInvitation {
+					t.Errorf("Expected invitation %v, got %v", tt.wantInvitation, invitation)
+				}
+
+				return
+			}
+
+			invitationErrs := models.NewHouseholdInvitationErrors{}
+			if !errors.As(err, &invitationErrs) {
+				t.Fatalf("Expected `NewHouseholdInvitationErrors{}`, got %#v", err)
+			}
+
+			assertErrorCodes(t, "email", tt.wantCodes.email, invitationErrs.Email)
+			assertErrorCodes(t, "role", tt.wantCodes.role, invitationErrs.Role)
+		})
+	}
+}
+
+func TestHouseholdRole(t *testing.T) {
+	testCases := []struct {
+		role       models.HouseholdRole
+		wantEdit   bool
+		wantManage bool
+	}{
+		{role: models.HouseholdRoleOwner, wantEdit: true, wantManage: true},
+		{role: models.HouseholdRoleEditor, wantEdit: true},
+		{role: models.HouseholdRoleViewer},
+		{role: "unknown"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(string(tt.role), func(t *testing.T) {
+			if got := tt.role.CanEdit(); got != tt.wantEdit {
+				t.Errorf("Expected CanEdit() to be %v, got %v", tt.wantEdit, got)
+			}
+
+			if got := tt.role.CanManage(); got != tt.wantManage {
+				t.Errorf("Expected CanManage() to be %v, got %v", tt.wantManage, got)
+			}
+		})
+	}
+}
+
+type MockHouseholdInviter struct {
+	email         string
+	householdName string
+	token         string
+	err           error
+}
+
+func (i *MockHouseholdInviter) HouseholdInvitation(ctx context.Context, email string, householdName string, token string) error {
+	i.email = email
+	i.householdName = householdName
+	i.token = token
+
+	return i.err
+}
+
+type MockHouseholdQueries struct {
+	MockAuditEvents
+
+	deletedInvitationID   int32
+	deleteInvitationError error
+
+	gotHouseholdForMember       queries.GetHouseholdForMemberParams
+	getHouseholdForMemberReturn queries.GetHouseholdForMemberRow
+	getHouseholdForMemberError  error
+
+	gotInvitationToken    string
+	getInvitationReturn   queries.HouseholdInvitation
+	getInvitationError    error
+	gotUserByID           uuid.UUID
+	getUserByIDReturn     queries.User
+	getUserByIDError      error
+	insertHouseholdParams queries.InsertHouseholdParams
+	insertHouseholdError  error
+
+	insertInvitationParams queries.InsertHouseholdInvitationParams
+	insertInvitationError  error
+
+	insertMemberParams   queries.InsertHouseholdMemberParams
+	insertMemberConflict bool
+	insertMemberError    error
+
+	listMembersHouseholdID uuid.UUID
+	listMembersReturn      []queries.ListHouseholdMembersRow
+	listMembersError       error
+
+	listForUserID     uuid.UUID
+	listForUserReturn []queries.ListHouseholdsForUserRow
+	listForUserError  error
+}
+
+func (q *MockHouseholdQueries) WithTx(queries.DBTX) models.HouseholdQueries {
+	return q
+}
+
+func (q *MockHouseholdQueries) DeleteHouseholdInvitationByID(ctx context.Context, id int32) error {
+	q.deletedInvitationID = id
+
+	return q.deleteInvitationError
+}
+
+func (q *MockHouseholdQueries) GetHouseholdForMember(ctx context.Context, params queries.GetHouseholdForMemberParams) (queries.GetHouseholdForMemberRow, error) {
+	q.gotHouseholdForMember = params
+
+	return q.getHouseholdForMemberReturn, q.getHouseholdForMemberError
+}
+
+func (q *MockHouseholdQueries) GetHouseholdInvitationByToken(ctx context.Context, token string) (queries.HouseholdInvitation, error) {
+	q.gotInvitationToken = token
+
+	return q.getInvitationReturn, q.getInvitationError
+}
+
+func (q *MockHouseholdQueries) GetUserByID(ctx context.Context, id uuid.UUID) (queries.User, error) {
+	q.gotUserByID = id
+
+	return q.getUserByIDReturn, q.getUserByIDError
+}
+
+func (q *MockHouseholdQueries) InsertHousehold(ctx context.Context, params queries.InsertHouseholdParams) error {
+	q.insertHouseholdParams = params
+
+	return q.insertHouseholdError
+}
+
+func (q *MockHouseholdQueries) InsertHouseholdInvitation(ctx context.Context, params queries.InsertHouseholdInvitationParams) error {
+	q.insertInvitationParams = params
+
+	return q.insertInvitationError
+}
+
+func (q *MockHouseholdQueries) InsertHouseholdMember(ctx context.Context, params queries.InsertHouseholdMemberParams) (int64, error) {
+	q.insertMemberParams = params
+
+	if q.insertMemberConflict {
+		return 0, q.insertMemberError
+	}
+
+	return 1, q.insertMemberError
+}
+
+func (q *MockHouseholdQueries) ListHouseholdMembers(ctx context.Context, householdID uuid.UUID) ([]queries.ListHouseholdMembersRow, error) {
+	q.listMembersHouseholdID = householdID
+
+	return q.listMembersReturn, q.listMembersError
+}
+
+func (q *MockHouseholdQueries) ListHouseholdsForUser(ctx context.Context, userID uuid.UUID) ([]queries.ListHouseholdsForUserRow, error) {
+	q.listForUserID = userID
+
+	return q.listForUserReturn, q.listForUserError
+}
+
+func newTestHouseholdModel(inviter *MockHouseholdInviter, db *MockDB, q *MockHouseholdQueries) *models.HouseholdModel {
+	return models.NewHouseholdModel(
+		slog.New(slog.DiscardHandler),
+		inviter,
+		&ConstantTokenGenerator{token: mockToken},
+		time.Minute,
+		db,
+		q,
+	)
+}
+
+func TestHouseholdModel_Create(t *testing.T) {
+	genericDBError := errors.New("generic DB error")
+	userID := uuid.New()
+
+	testCases := []struct {
+		name         string
+		db           MockDB
+		tx           MockTX
+		queries      MockHouseholdQueries
+		wantTxCommit bool
+		wantErr      bool
+	}{
+		{
+			name:    "error starting transaction",
+			db:      MockDB{beginError: genericDBError},
+			wantErr: true,
+		},
+		{
+			name:    "error inserting household",
+			queries: MockHouseholdQueries{insertHouseholdError: genericDBError},
+			wantErr: true,
+		},
+		{
+			name:    "error inserting owner",
+			queries: MockHouseholdQueries{insertMemberError: genericDBError},
+			wantErr: true,
+		},
+		{
+			name: "error recording audit event",
+			queries: MockHouseholdQueries{
+				MockAuditEvents: MockAuditEvents{insertAuditError: genericDBError},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "error committing",
+			tx:      MockTX{commitError: genericDBError},
+			wantErr: true,
+		},
+		{
+			name:         "success",
+			wantTxCommit: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.db.txFactory == nil {
+				tt.db.txFactory = func() models.Transaction { return &tt.tx }
+			}
+
+			households := newTestHouseholdModel(&MockHouseholdInviter{}, &tt.db, &tt.queries)
+
+			household, err := households.Create(t.Context(), userID, models.NewHousehold{Name: "Home"})
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error presence %v, got error %#v", tt.wantErr, err)
+			}
+
+			if tt.tx.committed != tt.wantTxCommit {
+				t.Errorf("Expected transaction commit %v, got %v", tt.wantTxCommit, tt.tx.committed)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			wantHousehold := models.Household{ID: tt.queries.insertHouseholdParams.ID, Name: "Home", Role: models.HouseholdRoleOwner}
+			if household != wantHousehold {
+				t.Errorf("Expected household %v, got %v", wantHousehold, household)
+			}
+
+			wantMember := queries.InsertHouseholdMemberParams{HouseholdID: household.ID, UserID: userID, Role: "owner"}
+			if got := tt.queries.insertMemberParams; got != wantMember {
+				t.Errorf("Expected owner %v, got %v", wantMember, got)
+			}
+
+			wantEvents := []wantAuditEvent{
+				{
+					actorID:     userID,
+					householdID: household.ID,
+					entityType:  models.AuditEntityHousehold,
+					entityID:    household.ID,
+					action:      models.AuditActionCreated,
+					changes:     map[string]models.FieldChange{"name": {New: "Home"}},
+				},
+			}
+			assertAuditEvents(t, wantEvents, tt.queries.insertedAuditEvents)
+		})
+	}
+}
+
+func TestHouseholdModel_GetForUser(t *testing.T) {
+	userID := uuid.New()
+	householdID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		queries       MockHouseholdQueries
+		wantHousehold models.Household
+		wantErr       error
+	}{
+		{
+			name:    "not a member",
+			queries: MockHouseholdQueries{getHouseholdForMemberError: pgx.ErrNoRows},
+			wantErr: models.ErrHouseholdNotFound,
+		},
+		{
+			name: "member",
+			queries: MockHouseholdQueries{
+				getHouseholdForMemberReturn: queries.GetHouseholdForMemberRow{ID: householdID, Name: "Home", Role: "viewer"},
+			},
+			wantHousehold: models.Household{ID: householdID, Name: "Home", Role: models.HouseholdRoleViewer},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			households := newTestHouseholdModel(&MockHouseholdInviter{}, &MockDB{}, &tt.queries)
+
+			household, err := households.GetForUser(t.Context(), userID, householdID)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+
+			wantParams := queries.GetHouseholdForMemberParams{HouseholdID: householdID, UserID: userID}
+			if got := tt.queries.gotHouseholdForMember; got != wantParams {
+				t.Errorf("Expected membership lookup %v, got %v", wantParams, got)
+			}
+
+			if household != tt.wantHousehold {
+				t.Errorf("Expected household %v, got %v", tt.wantHousehold, household)
+			}
+		})
+	}
+}
+
+func TestHouseholdModel_ListForUser(t *testing.T) {
+	userID := uuid.New()
+	householdID := uuid.New()
+
+	q := MockHouseholdQueries{
+		listForUserReturn: []queries.ListHouseholdsForUserRow{
+			{ID: householdID, Name: "Home", Role: "editor"},
+		},
+	}
+
+	households := newTestHouseholdModel(&MockHouseholdInviter{}, &MockDB{}, &q)
+
+	got, err := households.ListForUser(t.Context(), userID)
+	if err != nil {
+		t.Fatalf("ListForUser returned an error: %v", err)
+	}
+
+	want := []models.Household{{ID: householdID, Name: "Home", Role: models.HouseholdRoleEditor}}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected households %v, got %v", want, got)
+	}
+
+	if q.listForUserID != userID {
+		t.Errorf("Expected households listed for %v, got %v", userID, q.listForUserID)
+	}
+}
+
+func TestHouseholdModel_ListMembers(t *testing.T) {
+	userID := uuid.New()
+	householdID := uuid.New()
+
+	testCases := []struct {
+		name        string
+		queries     MockHouseholdQueries
+		wantMembers []models.HouseholdMember
+		wantErr     error
+	}{
+		{
+			name:    "not a member",
+			queries: MockHouseholdQueries{getHouseholdForMemberError: pgx.ErrNoRows},
+			wantErr: models.ErrHouseholdNotFound,
+		},
+		{
+			name: "member",
+			queries: MockHouseholdQueries{
+				getHouseholdForMemberReturn: queries.GetHouseholdForMemberRow{ID: householdID, Role: "viewer"},
+				listMembersReturn: []queries.ListHouseholdMembersRow{
+					{UserID: userID, Email: "test@example.com", Role: "viewer"},
+				},
+			},
+			wantMembers: []models.HouseholdMember{{UserID: userID, Email: "test@example.com", Role: models.HouseholdRoleViewer}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			households := newTestHouseholdModel(&MockHouseholdInviter{}, &MockDB{}, &tt.queries)
+
+			members, err := households.ListMembers(t.Context(), userID, householdID)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+
+			if !slices.Equal(members, tt.wantMembers) {
+				t.Errorf("Expected members %v, got %v", tt.wantMembers, members)
+			}
+		})
+	}
+}
+
+func TestHouseholdModel_Invite(t *testing.T) {
+	genericDBError := errors.New("generic DB error")
+	userID := uuid.New()
+	householdID := uuid.New()
+	invitation := models.NewHouseholdInvitation{Email: "friend@example.com", Role: models.HouseholdRoleEditor}
+
+	testCases := []struct {
+		name           string
+		db             MockDB
+		tx             MockTX
+		inviter        MockHouseholdInviter
+		queries        MockHouseholdQueries
+		wantInserted   bool
+		wantAudit      bool
+		wantEmailTo    string
+		wantTxCommit   bool
+		wantErr        bool
+		wantErrorMatch error
+	}{
+		{
+			name:           "not a member",
+			queries:        MockHouseholdQueries{getHouseholdForMemberError: pgx.ErrNoRows},
+			wantErr:        true,
+			wantErrorMatch: models.ErrHouseholdNotFound,
+		},
+		{
+			name: "not an owner",
+			queries: MockHouseholdQueries{
+				getHouseholdForMemberReturn: queries.GetHouseholdForMemberRow{ID: householdID, Name: "Home", Role: "editor"},
+			},
+			wantErr:        true,
+			wantErrorMatch: models.ErrHouseholdForbidden,
+		},
+		{
+			name: "error starting transaction",
+			db:   MockDB{beginError: genericDBError},
+			queries: MockHouseholdQueries{
+				getHouseholdForMemberReturn: queries.GetHouseholdForMemberRow{ID: householdID, Name: "Home", Role: "owner"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error inserting invitation",
+			queries: MockHouseholdQueries{
+				getHouseholdForMemberReturn: queries.GetHouseholdForMemberRow{ID: householdID, Name: "Home", Role: "owner"},
+				insertInvitationError:       genericDBError,
+			},
+			wantInserted: true,
+			wantErr:      true,
+		},
+		{
+			name: "error recording audit event",
+			queries: MockHouseholdQueries{
+				MockAuditEvents:             MockAuditEvents{insertAuditError: genericDBError},
+				getHouseholdForMemberReturn: queries.GetHouseholdForMemberRow{ID: householdID, Name: "Home", Role: "owner"},
+			},
+			wantInserted: true,
+			wantAudit:    true,
+			wantErr:      true,
+		},
+		{
+			name:    "error sending invitation",
+			inviter: MockHouseholdInviter{err: errors.New("send failed")},
+			queries: MockHouseholdQueries{
+				getHouseholdForMemberReturn: queries.GetHouseholdForMemberRow{ID: householdID, Name: "Home", Role: "owner"},
+			},
+			wantInserted: true,
+			wantAudit:    true,
+			wantEmailTo:  "friend@example.com",
+			wantErr:      true,
+		},
+		{
+			name: "error committing",
+			tx:   MockTX{commitError: genericDBError},
+			queries: MockHouseholdQueries{
+				getHouseholdForMemberReturn: queries.GetHouseholdForMemberRow{ID: householdID, Name: "Home", Role: "owner"},
+			},
+			wantInserted: true,
+			wantAudit:    true,
+			wantEmailTo:  "friend@example.com",
+			wantErr:      true,
+		},
+		{
+			name: "success",
+			queries: MockHouseholdQueries{
+				getHouseholdForMemberReturn: queries.GetHouseholdForMemberRow{ID: householdID, Name: "Home", Role: "owner"},
+			},
+			wantInserted: true,
+			wantAudit:    true,
+			wantEmailTo:  "friend@example.com",
+			wantTxCommit: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.db.txFactory == nil {
+				tt.db.txFactory = func() models.Transaction { return &tt.tx }
+			}
+
+			households := newTestHouseholdModel(&tt.inviter, &tt.db, &tt.queries)
+
+			err := households.Invite(t.Context(), userID, householdID, invitation)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error presence %v, got error %#v", tt.wantErr, err)
+			}
+
+			if tt.wantErrorMatch != nil && !errors.Is(err, tt.wantErrorMatch) {
+				t.Errorf("Expected error %v, got %v", tt.wantErrorMatch, err)
+			}
+
+			wantParams := queries.InsertHouseholdInvitationParams{}
+			if tt.wantInserted {
+				wantParams = queries.InsertHouseholdInvitationParams{
+					HouseholdID: householdID,
+					Email:       "friend@example.com",
+					Role:        "editor",
+					Token:       mockToken,
+					InvitedBy:   userID,
+				}
+			}
+
+			if got := tt.queries.insertInvitationParams; got != wantParams {
+				t.Errorf("Expected inserted invitation %v, got %v", wantParams, got)
+			}
+
+			if got := tt.inviter.email; got != tt.wantEmailTo {
+				t.Errorf("Expected invitation sent to %q, got %q", tt.wantEmailTo, got)
+			}
+
+			if tt.wantEmailTo != "" && (tt.inviter.token != mockToken || tt.inviter.householdName != "Home") {
+				t.Errorf("Expected invitation to Home with token %q, got %q with token %q", mockToken, tt.inviter.householdName, tt.inviter.token)
+			}
+
+			if tt.tx.committed != tt.wantTxCommit {
+				t.Errorf("Expected transaction commit %v, got %v", tt.wantTxCommit, tt.tx.committed)
+			}
+
+			var wantEvents []wantAuditEvent
+			if tt.wantAudit {
+				wantEvents = append(wantEvents, wantAuditEvent{
+					actorID:     userID,
+					householdID: householdID,
+					entityType:  models.AuditEntityHousehold,
+					entityID:    householdID,
+					action:      models.AuditActionMemberInvited,
+					changes:     map[string]models.FieldChange{"role": {New: "editor"}},
+				})
+			}
+
+			assertAuditEvents(t, wantEvents, tt.queries.insertedAuditEvents)
+		})
+	}
+}
+
+func TestHouseholdModel_AcceptInvitation(t *testing.T) {
+	genericDBError := errors.New("generic DB error")
+	userID := uuid.New()
+	householdID := uuid.New()
+
+	validInvitation := queries.HouseholdInvitation{
+		ID:          3,
+		HouseholdID: householdID,
+		Email:       "Friend@example.com",
+		Role:        "viewer",
+		CreatedAt:   pgtype.Timestamptz{Time: time.Now()},
+	}
+
+	testCases := []struct {
+		name              string
+		tx                MockTX
+		queries           MockHouseholdQueries
+		wantMember        bool
+		wantDeletedID     int32
+		wantAudit         bool
+		wantTxCommit      bool
+		wantHousehold     models.Household
+		wantErr           bool
+		wantErrorMatch    error
+		wantUserRetrieved bool
+	}{
+		{
+			name:           "missing invitation",
+			queries:        MockHouseholdQueries{getInvitationError: pgx.ErrNoRows},
+			wantErr:        true,
+			wantErrorMatch: models.ErrInvalidHouseholdInvitation,
+		},
+		{
+			name:    "error retrieving invitation",
+			queries: MockHouseholdQueries{getInvitationError: genericDBError},
+			wantErr: true,
+		},
+		{
+			name: "expired invitation",
+			queries: MockHouseholdQueries{
+				getInvitationReturn: queries.HouseholdInvitation{
+					HouseholdID: householdID,
+					Email:       "friend@example.com",
+					CreatedAt:   pgtype.Timestamptz{Time: time.Now().Add(-2 * time.Minute)},
+				},
+			},
+			wantErr:        true,
+			wantErrorMatch: models.ErrInvalidHouseholdInvitation,
+		},
+		{
+			name: "different email",
+			queries: MockHouseholdQueries{
+				getInvitationReturn: validInvitation,
+				getUserByIDReturn:   queries.User{ID: userID, Email: "someone-else@example.com"},
+			},
+			wantUserRetrieved: true,
+			wantErr:           true,
+			wantErrorMatch:    models.ErrInvalidHouseholdInvitation,
+		},
+		{
+			name: "error adding member",
+			queries: MockHouseholdQueries{
+				getInvitationReturn: validInvitation,
+				getUserByIDReturn:   queries.User{ID: userID, Email: "friend@example.com"},
+				insertMemberError:   genericDBError,
+			},
+			wantUserRetrieved: true,
+			wantMember:        true,
+			wantErr:           true,
+		},
+		{
+			name: "already a member",
+			queries: MockHouseholdQueries{
+				getInvitationReturn:  validInvitation,
+				getUserByIDReturn:    queries.User{ID: userID, Email: "friend@example.com"},
+				insertMemberConflict: true,
+			},
+			wantUserRetrieved: true,
+			wantMember:        true,
+			wantErr:           true,
+			wantErrorMatch:    models.ErrAlreadyHouseholdMember,
+		},
+		{
+			name: "error recording audit event",
+			queries: MockHouseholdQueries{
+				MockAuditEvents:     MockAuditEvents{insertAuditError: genericDBError},
+				getInvitationReturn: validInvitation,
+				getUserByIDReturn:   queries.User{ID: userID, Email: "friend@example.com"},
+			},
+			wantUserRetrieved: true,
+			wantMember:        true,
+			wantDeletedID:     3,
+			wantAudit:         true,
+			wantErr:           true,
+		},
+		{
+			name: "success",
+			queries: MockHouseholdQueries{
+				getInvitationReturn:         validInvitation,
+				getUserByIDReturn:           queries.User{ID: userID, Email: "friend@example.com"},
+				getHouseholdForMemberReturn: queries.GetHouseholdForMemberRow{ID: householdID, Name: "Home", Role: "viewer"},
+			},
+			wantUserRetrieved: true,
+			wantMember:        true,
+			wantDeletedID:     3,
+			wantAudit:         true,
+			wantTxCommit:      true,
+			wantHousehold:     models.Household{ID: householdID, Name: "Home", Role: models.HouseholdRoleViewer},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			db := MockDB{txFactory: func() models.Transaction { return &tt.tx }}
+			households := newTestHouseholdModel(&MockHouseholdInviter{}, &db, &tt.queries)
+
+			household, err := households.AcceptInvitation(t.Context(), userID, "the-token")
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error presence %v, got error %#v", tt.wantErr, err)
+			}
+
+			if tt.wantErrorMatch != nil && !errors.Is(err, tt.wantErrorMatch) {
+				t.Errorf("Expected error %v, got %v", tt.wantErrorMatch, err)
+			}
+
+			if got := tt.queries.gotInvitationToken; got != "the-token" {
+				t.Errorf("Expected invitation lookup for %q, got %q", "the-token", got)
+			}
+
+			if got := tt.queries.gotUserByID; tt.wantUserRetrieved && got != userID {
+				t.Errorf("Expected user %v to be retrieved, got %v", userID, got)
+			}
+
+			wantMember := queries.InsertHouseholdMemberParams{}
+			if tt.wantMember {
+				wantMember = queries.InsertHouseholdMemberParams{HouseholdID: householdID, UserID: userID, Role: "viewer"}
+			}
+
+			if got := tt.queries.insertMemberParams; got != wantMember {
+				t.Errorf("Expected inserted member %v, got %v", wantMember, got)
+			}
+
+			if got := tt.queries.deletedInvitationID; got != tt.wantDeletedID {
+				t.Errorf("Expected invitation %d to be deleted, got %d", tt.wantDeletedID, got)
+			}
+
+			if tt.tx.committed != tt.wantTxCommit {
+				t.Errorf("Expected transaction commit %v, got %v", tt.wantTxCommit, tt.tx.committed)
+			}
+
+			if household != tt.wantHousehold {
+				t.Errorf("Expected household %v, got %v", tt.wantHousehold, household)
+			}
+
+			var wantEvents []wantAuditEvent
+			if tt.wantAudit {
+				wantEvents = append(wantEvents, wantAuditEvent{
+					actorID:     userID,
+					householdID: householdID,
+					entityType:  models.AuditEntityHousehold,
+					entityID:    householdID,
+					action:      models.AuditActionMemberJoined,
+					changes:     map[string]models.FieldChange{"role": {New: "viewer"}},
+				})
+			}
+
+			assertAuditEvents(t, wantEvents, tt.queries.insertedAuditEvents)
+		})
+	}
+}
@@ -0,0 +1,423 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/cdriehuys/stuff2/internal/i18n"
+	"github.com/cdriehuys/stuff2/internal/models/queries"
+	"github.com/cdriehuys/stuff2/internal/validation"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const locationNameMaxLength = 100
+
+type NewLocation struct {
+	Name string
+
+	// ParentID is the location to create the new location in. New locations with no parent are at
+	// the top level of the household.
+	ParentID uuid.UUID
+}
+
+type NewLocationErrors struct {
+	Name   []validation.Error
+	Parent []validation.Error
+}
+
+func (e NewLocationErrors) Error() string {
+	return fmt.Sprintf("%#v", e)
+}
+
+func MakeNewLocation(ctx context.Context, name string, parentID string) (NewLocation, error) {
+	t := i18n.FromContext(ctx)
+
+	validationErrors := NewLocationErrors{}
+
+	trimmedName := strings.TrimSpace(name)
+	if len(trimmedName) == 0 {
+		validationErrors.Name = append(validationErrors.Name, validation.MakeError("required", t.T("location.name.required")))
+	} else if len(trimmedName) > locationNameMaxLength {
+		validationErrors.Name = append(validationErrors.Name, validation.MakeError("max", t.C("location.name.length.max", locationNameMaxLength, 0, t.FmtNumber(locationNameMaxLength, 0))))
+	}
+
+	parent, err := parseLocationParent(parentID)
+	if err != nil {
+		validationErrors.Parent = append(validationErrors.Parent, validation.MakeError("invalid", t.T("location.parent.invalid")))
+	}
+
+	if len(validationErrors.Name) > 0 || len(validationErrors.Parent) > 0 {
+		return NewLocation{}, validationErrors
+	}
+
+	return NewLocation{Name: trimmedName, ParentID: parent}, nil
+}
+
+type LocationMove struct {
+	// ParentID is the location to move into. Moving to no parent moves the location to the top
+	// level of the household.
+	ParentID uuid.UUID
+}
+
+type LocationMoveErrors struct {
+	Parent []validation.Error
+}
+
+func (e LocationMoveErrors) Error() string {
+	return fmt.Sprintf("%#v", e)
+}
+
+func MakeLocationMove(ctx context.Context, parentID string) (LocationMove, error) {
+	parent, err := parseLocationParent(parentID)
+	if err != nil {
+		t := i18n.FromContext(ctx)
+
+		return LocationMove{}, LocationMoveErrors{
+			Parent: []validation.Error{validation.MakeError("invalid", t.T("location.parent.invalid"))},
+		}
+	}
+
+	return LocationMove{ParentID: parent}, nil
+}
+
+// parseLocationParent parses a parent location ID, where an empty ID means the top level.
+func parseLocationParent(rawID string) (uuid.UUID, error) {
+	if rawID == "" {
+		return uuid.Nil, nil
+	}
+
+	return uuid.Parse(rawID)
+}
+
+type Location struct {
+	ID uuid.UUID
+
+	// ParentID is the location containing this one, or [uuid.Nil] for top level locations.
+	ParentID uuid.UUID
+
+	Name string
+}
+
+// LocationNode is a location within a listing of a location tree.
+type LocationNode struct {
+	Location
+
+	// Depth is how deeply the location is nested within the listing, starting from 1.
+	Depth int
+
+	// DescendantCount is how many locations are nested under this one at any depth.
+	DescendantCount int
+}
+
+// LocationDetail is a location along with where it is and what it contains.
+type LocationDetail struct {
+	Location
+
+	// Breadcrumbs are the locations containing this one, starting from the top level and ending
+	// with the location itself.
+	Breadcrumbs []Location
+
+	// Descendants are the locations nested under this one, listed depth first.
+	Descendants []LocationNode
+}
+
+type LocationQueries interface {
+	WithTx(tx queries.DBTX) LocationQueries
+
+	GetLocation(context.Context, queries.GetLocationParams) (queries.Location, error)
+	GetLocationHouseholdForMember(context.Context, queries.GetLocationHouseholdForMemberParams) (uuid.UUID, error)
+	InsertAuditEvent(context.Context, queries.InsertAuditEventParams) error
+	InsertLocation(context.Context, queries.InsertLocationParams) error
+	ListLocationAncestors(context.Context, queries.ListLocationAncestorsParams) ([]queries.ListLocationAncestorsRow, error)
+	ListLocationSubtree(context.Context, queries.ListLocationSubtreeParams) ([]queries.ListLocationSubtreeRow, error)
+	LockHouseholdLocations(ctx context.Context, householdID uuid.UUID) error
+	MoveLocation(context.Context, queries.MoveLocationParams) (int64, error)
+}
+
+type LocationQueriesWrapper struct {
+	*queries.Queries
+}
+
+func (w LocationQueriesWrapper) WithTx(tx queries.DBTX) LocationQueries {
+	return LocationQueriesWrapper{w.Queries.WithTx(tx.(pgx.Tx))}
+}
+
+type LocationModel struct {
+	logger *slog.Logger
+
+	db DB
+	q  LocationQueries
+}
+
+func NewLocationModel(logger *slog.Logger, db DB, queries LocationQueries) *LocationModel {
+	return &LocationModel{
+		logger: logger,
+		db:     db,
+		q:      queries,
+	}
+}
+
+var (
+	ErrLocationNotFound       = errors.New("location not found")
+	ErrLocationParentNotFound = errors.New("parent location not found")
+
+	// ErrLocationCycle is returned when moving a location would place it inside itself.
+	ErrLocationCycle = errors.New("location cannot be moved inside itself")
+)
+
+// Create persists a new location in the household on behalf of the user.
+func (m *LocationModel) Create(ctx context.Context, userID uuid.UUID, householdID uuid.UUID, location NewLocation) (_ Location, retErr error) {
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return Location{}, fmt.Errorf("starting transaction: %v", err)
+	}
+
+	defer func() {
+		if txErr := tx.Rollback(ctx); txErr != nil && !errors.Is(txErr, pgx.ErrTxClosed) {
+			retErr = errors.Join(retErr, txErr)
+		}
+	}()
+
+	txQueries := m.q.WithTx(tx)
+
+	if location.ParentID != uuid.Nil {
+		if _, err := m.get(ctx, txQueries, householdID, location.ParentID); err != nil {
+			if errors.Is(err, ErrLocationNotFound) {
+				return Location{}, ErrLocationParentNotFound
+			}
+
+			return Location{}, err
+		}
+	}
+
+	params := queries.InsertLocationParams{
+		ID:          uuid.New(),
+		HouseholdID: householdID,
+		ParentID:    uuid.NullUUID{UUID: location.ParentID, Valid: location.ParentID != uuid.Nil},
+		Name:        location.Name,
+	}
+	if err := txQueries.InsertLocation(ctx, params); err != nil {
+		return Location{}, fmt.Errorf("inserting location: %v", err)
+	}
+
+	entry := auditEntry{
+		actorID:     userID,
+		householdID: householdID,
+		entityType:  AuditEntityLocation,
+		entityID:    params.ID,
+		action:      AuditActionCreated,
+		changes: map[string]FieldChange{
+			"name":      {New: location.Name},
+			"parent_id": {New: auditLocationID(location.ParentID)},
+		},
+	}
+	if err := recordAuditEvent(ctx, txQueries, entry); err != nil {
+		return Location{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Location{}, fmt.Errorf("committing transaction: %v", err)
+	}
+
+	m.logger.InfoContext(ctx, "Created location.", "householdID", householdID, "locationID", params.ID)
+
+	return Location{ID: params.ID, ParentID: location.ParentID, Name: location.Name}, nil
+}
+
+// Get returns a location in the household along with its breadcrumbs and everything under it.
+func (m *LocationModel) Get(ctx context.Context, householdID uuid.UUID, id uuid.UUID) (LocationDetail, error) {
+	location, err := m.get(ctx, m.q, householdID, id)
+	if err != nil {
+		return LocationDetail{}, err
+	}
+
+	ancestorParams := queries.ListLocationAncestorsParams{HouseholdID: householdID, ID: id}
+	ancestors, err := m.q.ListLocationAncestors(ctx, ancestorParams)
+	if err != nil {
+		return LocationDetail{}, fmt.Errorf("listing location ancestors: %v", err)
+	}
+
+	breadcrumbs := make([]Location, 0, len(ancestors))
+	for _, ancestor := range ancestors {
+		breadcrumbs = append(breadcrumbs, Location{ID: ancestor.ID, ParentID: ancestor.ParentID.UUID, Name: ancestor.Name})
+	}
+
+	descendants, err := m.listSubtree(ctx, householdID, id)
+	if err != nil {
+		return LocationDetail{}, err
+	}
+
+	detail := LocationDetail{
+		Location:    location,
+		Breadcrumbs: breadcrumbs,
+		Descendants: descendants,
+	}
+
+	return detail, nil
+}
+
+// GetHouseholdID returns the ID of the household a location belongs to, as long as the user is a
+// member of that household.
+func (m *LocationModel) GetHouseholdID(ctx context.Context, userID uuid.UUID, id uuid.UUID) (uuid.UUID, error) {
+	params := queries.GetLocationHouseholdForMemberParams{ID: id, UserID: userID}
+
+	householdID, err := m.q.GetLocationHouseholdForMember(ctx, params)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, ErrLocationNotFound
+		}
+
+		return uuid.Nil, fmt.Errorf("retrieving household of location %s: %v", id.String(), err)
+	}
+
+	return householdID, nil
+}
+
+// ListTree lists every location in the household, depth first.
+func (m *LocationModel) ListTree(ctx context.Context, householdID uuid.UUID) ([]LocationNode, error) {
+	return m.listSubtree(ctx, householdID, uuid.Nil)
+}
+
+// Move places a location inside a new parent on behalf of the user. Everything nested under the
+// location moves with it.
+func (m *LocationModel) Move(ctx context.Context, userID uuid.UUID, householdID uuid.UUID, id uuid.UUID, move LocationMove) (retErr error) {
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("starting transaction: %v", err)
+	}
+
+	defer func() {
+		if txErr := tx.Rollback(ctx); txErr != nil && !errors.Is(txErr, pgx.ErrTxClosed) {
+			retErr = errors.Join(retErr, txErr)
+		}
+	}()
+
+	txQueries := m.q.WithTx(tx)
+
+	if err := txQueries.LockHouseholdLocations(ctx, householdID); err != nil {
+		return fmt.Errorf("locking household locations: %v", err)
+	}
+
+	location, err := m.get(ctx, txQueries, householdID, id)
+	if err != nil {
+		return err
+	}
+
+	// Nothing changes, so there's nothing to record either.
+	if location.ParentID == move.ParentID {
+		m.logger.DebugContext(ctx, "Location is already in the requested parent.", "locationID", id, "parentID", move.ParentID)
+
+		return nil
+	}
+
+	if move.ParentID != uuid.Nil {
+		if _, err := m.get(ctx, txQueries, householdID, move.ParentID); err != nil {
+			if errors.Is(err, ErrLocationNotFound) {
+				return ErrLocationParentNotFound
+			}
+
+			return err
+		}
+	}
+
+	params := queries.MoveLocationParams{
+		HouseholdID: householdID,
+		ID:          id,
+		ParentID:    uuid.NullUUID{UUID: move.ParentID, Valid: move.ParentID != uuid.Nil},
+	}
+	moved, err := txQueries.MoveLocation(ctx, params)
+	if err != nil {
+		return fmt.Errorf("moving location: %v", err)
+	}
+
+	// The location exists, so the only reason for it to not move is that the new parent is inside
+	// it.
+	if moved == 0 {
+		return ErrLocationCycle
+	}
+
+	entry := auditEntry{
+		actorID:     userID,
+		householdID: householdID,
+		entityType:  AuditEntityLocation,
+		entityID:    id,
+		action:      AuditActionMoved,
+		changes: map[string]FieldChange{
+			"parent_id": {Old: auditLocationID(location.ParentID), New: auditLocationID(move.ParentID)},
+		},
+	}
+	if err := recordAuditEvent(ctx, txQueries, entry); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("committing transaction: %v", err)
+	}
+
+	m.logger.InfoContext(ctx, "Moved location.", "householdID", householdID, "locationID", id, "parentID", move.ParentID)
+
+	return nil
+}
+
+// auditLocationID formats a location ID for an audit event, where top level locations have no
+// parent.
+func auditLocationID(id uuid.UUID) string {
+	if id == uuid.Nil {
+		return ""
+	}
+
+	return id.String()
+}
+
+func (m *LocationModel) get(ctx context.Context, q LocationQueries, householdID uuid.UUID, id uuid.UUID) (Location, error) {
+	location, err := q.GetLocation(ctx, queries.GetLocationParams{HouseholdID: householdID, ID: id})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Location{}, ErrLocationNotFound
+		}
+
+		return Location{}, fmt.Errorf("retrieving location: %v", err)
+	}
+
+	return Location{ID: location.ID, ParentID: location.ParentID.UUID, Name: location.Name}, nil
+}
+
+func (m *LocationModel) listSubtree(ctx context.Context, householdID uuid.UUID, parentID uuid.UUID) ([]LocationNode, error) {
+	params := queries.ListLocationSubtreeParams{
+		HouseholdID: householdID,
+		ParentID:    uuid.NullUUID{UUID: parentID, Valid: parentID != uuid.Nil},
+	}
+
+	rows, err := m.q.ListLocationSubtree(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("listing locations: %v", err)
+	}
+
+	nodes := make([]LocationNode, 0, len(rows))
+	for _, row := range rows {
+		node := LocationNode{
+			Location: Location{ID: row.ID, ParentID: row.ParentID.UUID, Name: row.Name},
+			Depth:    int(row.Depth),
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	// Since the nodes are listed depth first, a node's descendants are the nodes immediately after
+	// it that are nested more deeply.
+	for i := range nodes {
+		for _, next := range nodes[i+1:] {
+			if next.Depth <= nodes[i].Depth {
+				break
+			}
+
+			nodes[i].DescendantCount++
+		}
+	}
+
+	return nodes, nil
+}
@@ -0,0 +1,664 @@
+package models_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cdriehuys/stuff2/internal/i18n_test"
+	"github.com/cdriehuys/stuff2/internal/models"
+	"github.com/cdriehuys/stuff2/internal/models/queries"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestMakeNewLocation(t *testing.T) {
+	parentID := uuid.New()
+
+	type wantCodes struct {
+		name   []string
+		parent []string
+	}
+
+	testCases := []struct {
+		name         string
+		locationName string
+		parentID     string
+		wantSuccess  bool
+		wantLocation models.NewLocation
+		wantCodes    wantCodes
+	}{
+		{
+			name: "empty",
+			wantCodes: wantCodes{
+				name: []string{"required"},
+			},
+		},
+		{
+			name:         "name too long",
+			locationName: strings.Repeat("a", 101),
+			wantCodes: wantCodes{
+				name: []string{"max"},
+			},
+		},
+		{
+			name:         "invalid parent",
+			locationName: "Garage",
+			parentID:     "not-a-uuid",
+			wantCodes: wantCodes{
+				parent: []string{"invalid"},
+			},
+		},
+		{
+			name:         "top level",
+			locationName: " Garage ",
+			wantSuccess:  true,
+			wantLocation: models.NewLocation{Name: "Garage"},
+		},
+		{
+			name:         "nested",
+			locationName: "Shelf 3",
+			parentID:     parentID.String(),
+			wantSuccess:  true,
+			wantLocation: models.NewLocation{Name: "Shelf 3", ParentID: parentID},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := i18n_test.WithMockTranslator(t.Context())
+
+			location, err := models.MakeNewLocation(ctx, tt.locationName, tt.parentID)
+
+			if tt.wantSuccess {
+				if err != nil {
+					t.Fatalf("Expected success, got error %v", err)
+				}
+
+				if location != tt.wantLocation {
+					t.Errorf("Expected location %v, got %v", tt.wantLocation, location)
+				}
+
+				return
+			}
+
+			locationErrs := models.NewLocationErrors{}
+			if !errors.As(err, &locationErrs) {
+				t.Fatalf("Expected `NewLocationErrors{}`, got %#v", err)
+			}
+
+			assertErrorCodes(t, "name", tt.wantCodes.name, locationErrs.Name)
+			assertErrorCodes(t, "parent", tt.wantCodes.parent, locationErrs.Parent)
+		})
+	}
+}
+
+func TestMakeLocationMove(t *testing.T) {
+	parentID := uuid.New()
+
+	testCases := []struct {
+		name        string
+		parentID    string
+		wantSuccess bool
+		wantMove    models.LocationMove
+	}{
+		{
+			name:     "invalid parent",
+			parentID: "not-a-uuid",
+		},
+		{
+			name:        "top level",
+			wantSuccess: true,
+		},
+		{
+			name:        "nested",
+			parentID:    parentID.String(),
+			wantSuccess: true,
+			wantMove:    models.LocationMove{ParentID: parentID},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := i18n_test.WithMockTranslator(t.Context())
+
+			move, err := models.MakeLocationMove(ctx, tt.parentID)
+
+			if tt.wantSuccess {
+				if err != nil {
+					t.Fatalf("Expected success, got error %v", err)
+				}
+
+				if move != tt.wantMove {
+					t.Errorf("Expected move %v, got %v", tt.wantMove, move)
+				}
+
+				return
+			}
+
+			moveErrs := models.LocationMoveErrors{}
+			if !errors.As(err, &moveErrs) {
+				t.Fatalf("Expected `LocationMoveErrors{}`, got %#v", err)
+			}
+
+			assertErrorCodes(t, "parent", []string{"invalid"}, moveErrs.Parent)
+		})
+	}
+}
+
+type MockLocationQueries struct {
+	MockAuditEvents
+
+	// locations are returned by GetLocation. Locations that aren't present are not found.
+	locations map[uuid.UUID]queries.Location
+	getError  error
+
+	householdForMemberParams queries.GetLocationHouseholdForMemberParams
+	householdForMemberReturn uuid.UUID
+	householdForMemberError  error
+
+	insertParams queries.InsertLocationParams
+	insertError  error
+
+	ancestorsParams queries.ListLocationAncestorsParams
+	ancestorsReturn []queries.ListLocationAncestorsRow
+	ancestorsError  error
+
+	subtreeParams queries.ListLocationSubtreeParams
+	subtreeReturn []queries.ListLocationSubtreeRow
+	subtreeError  error
+
+	lockedHouseholdID uuid.UUID
+	lockError         error
+
+	moveParams queries.MoveLocationParams
+	moveReturn int64
+	moveError  error
+}
+
+func (q *MockLocationQueries) WithTx(queries.DBTX) models.LocationQueries {
+	return q
+}
+
+func (q *MockLocationQueries) GetLocation(ctx context.Context, params queries.GetLocationParams) (queries.Location, error) {
+	if q.getError != nil {
+		return queries.Location{}, q.getError
+	}
+
+	location, ok := q.locations[params.ID]
+	if !ok || location.HouseholdID != params.HouseholdID {
+		return queries.Location{}, pgx.ErrNoRows
+	}
+
+	return location, nil
+}
+
+func (q *MockLocationQueries) GetLocationHouseholdForMember(ctx context.Context, params queries.GetLocationHouseholdForMemberParams) (uuid.UUID, error) {
+	q.householdForMemberParams = params
+
+	return q.householdForMemberReturn, q.householdForMemberError
+}
+
+func (q *MockLocationQueries) InsertLocation(ctx context.Context, params queries.InsertLocationParams) error {
+	q.insertParams = params
+
+	return q.insertError
+}
+
+func (q *MockLocationQueries) ListLocationAncestors(ctx context.Context, params queries.ListLocationAncestorsParams) ([]queries.ListLocationAncestorsRow, error) {
+	q.ancestorsParams = params
+
+	return q.ancestorsReturn, q.ancestorsError
+}
+
+func (q *MockLocationQueries) ListLocationSubtree(ctx context.Context, params queries.ListLocationSubtreeParams) ([]queries.ListLocationSubtreeRow, error) {
+	q.subtreeParams = params
+
+	return q.subtreeReturn, q.subtreeError
+}
+
+func (q *MockLocationQueries) LockHouseholdLocations(ctx context.Context, householdID uuid.UUID) error {
+	q.lockedHouseholdID = householdID
+
+	return q.lockError
+}
+
+func (q *MockLocationQueries) MoveLocation(ctx context.Context, params queries.MoveLocationParams) (int64, error) {
+	q.moveParams = params
+
+	return q.moveReturn, q.moveError
+}
+
+func TestLocationModel_Create(t *testing.T) {
+	userID := uuid.New()
+	householdID := uuid.New()
+	parentID := uuid.New()
+
+	testCases := []struct {
+		name         string
+		db           MockDB
+		tx           MockTX
+		queries      MockLocationQueries
+		location     models.NewLocation
+		wantInsert   bool
+		wantAudit    bool
+		wantTxCommit bool
+		wantErr      bool
+		wantErrorIs  error
+	}{
+		{
+			name:     "error starting transaction",
+			db:       MockDB{beginError: errors.New("begin failed")},
+			location: models.NewLocation{Name: "Garage"},
+			wantErr:  true,
+		},
+		{
+			name:         "top level",
+			location:     models.NewLocation{Name: "Garage"},
+			wantInsert:   true,
+			wantAudit:    true,
+			wantTxCommit: true,
+		},
+		{
+			name: "nested",
+			queries: MockLocationQueries{
+				locations: map[uuid.UUID]queries.Location{
+					parentID: {ID: parentID, HouseholdID: householdID},
+				},
+			},
+			location:     models.NewLocation{Name: "Shelf 3", ParentID: parentID},
+			wantInsert:   true,
+			wantAudit:    true,
+			wantTxCommit: true,
+		},
+		{
+			name:        "parent in another household",
+			queries:     MockLocationQueries{locations: map[uuid.UUID]queries.Location{parentID: {ID: parentID, HouseholdID: uuid.New()}}},
+			location:    models.NewLocation{Name: "Shelf 3", ParentID: parentID},
+			wantErr:     true,
+			wantErrorIs: models.ErrLocationParentNotFound,
+		},
+		{
+			name:       "insert error",
+			queries:    MockLocationQueries{insertError: errors.New("insert failed")},
+			location:   models.NewLocation{Name: "Garage"},
+			wantInsert: true,
+			wantErr:    true,
+		},
+		{
+			name: "audit error",
+			queries: MockLocationQueries{
+				MockAuditEvents: MockAuditEvents{insertAuditError: errors.New("insert failed")},
+			},
+			location:   models.NewLocation{Name: "Garage"},
+			wantInsert: true,
+			wantAudit:  true,
+			wantErr:    true,
+		},
+		{
+			name:       "commit error",
+			tx:         MockTX{commitError: errors.New("commit failed")},
+			location:   models.NewLocation{Name: "Garage"},
+			wantInsert: true,
+			wantAudit:  true,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.db.txFactory == nil {
+				tt.db.txFactory = func() models.Transaction { return &tt.tx }
+			}
+
+			locations := models.NewLocationModel(slog.New(slog.DiscardHandler), &tt.db, &tt.queries)
+
+			location, err := locations.Create(t.Context(), userID, householdID, tt.location)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error presence %v, got error %#v", tt.wantErr, err)
+			}
+
+			if tt.wantErrorIs != nil && !errors.Is(err, tt.wantErrorIs) {
+				t.Errorf("Expected error %v, got %v", tt.wantErrorIs, err)
+			}
+
+			if tt.tx.committed != tt.wantTxCommit {
+				t.Errorf("Expected transaction commit %v, got %v", tt.wantTxCommit, tt.tx.committed)
+			}
+
+			params := tt.queries.insertParams
+			if tt.wantInsert != (params.ID != uuid.Nil) {
+				t.Fatalf("Expected location insert %v, got %v", tt.wantInsert, params)
+			}
+
+			if !tt.wantInsert {
+				return
+			}
+
+			if params.HouseholdID != householdID || params.Name != tt.location.Name || params.ParentID.UUID != tt.location.ParentID {
+				t.Errorf("Expected location %v inserted in household %v, got %v", tt.location, householdID, params)
+			}
+
+			if wantValid := tt.location.ParentID != uuid.Nil; params.ParentID.Valid != wantValid {
+				t.Errorf("Expected parent presence %v, got %v", wantValid, params.ParentID)
+			}
+
+			if !tt.wantErr && location.ID != params.ID {
+				t.Errorf("Expected created location ID %v, got %v", params.ID, location.ID)
+			}
+
+			var wantEvents []wantAuditEvent
+			if tt.wantAudit {
+				wantParent := ""
+				if tt.location.ParentID != uuid.Nil {
+					wantParent = tt.location.ParentID.String()
+				}
+
+				wantEvents = append(wantEvents, wantAuditEvent{
+					actorID:     userID,
+					householdID: householdID,
+					entityType:  models.AuditEntityLocation,
+					entityID:    params.ID,
+					action:      models.AuditActionCreated,
+					changes: map[string]models.FieldChange{
+						"name":      {New: tt.location.Name},
+						"parent_id": {New: wantParent},
+					},
+				})
+			}
+
+			assertAuditEvents(t, wantEvents, tt.queries.insertedAuditEvents)
+		})
+	}
+}
+
+func TestLocationModel_Get(t *testing.T) {
+	householdID := uuid.New()
+	houseID, garageID, shelfID, binID, drawerID := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	q := MockLocationQueries{
+		locations: map[uuid.UUID]queries.Location{
+			garageID: {ID: garageID, HouseholdID: householdID, ParentID: uuid.NullUUID{UUID: houseID, Valid: true}, Name: "Garage"},
+		},
+		ancestorsReturn: []queries.ListLocationAncestorsRow{
+			{ID: houseID, Name: "House"},
+			{ID: garageID, ParentID: uuid.NullUUID{UUID: houseID, Valid: true}, Name: "Garage"},
+		},
+		subtreeReturn: []queries.ListLocationSubtreeRow{
+			{ID: shelfID, ParentID: uuid.NullUUID{UUID: garageID, Valid: true}, Name: "Shelf 3", Depth: 1},
+			{ID: binID, ParentID: uuid.NullUUID{UUID: shelfID, Valid: true}, Name: "Bin B", Depth: 2},
+			{ID: drawerID, ParentID: uuid.NullUUID{UUID: garageID, Valid: true}, Name: "Workbench", Depth: 1},
+		},
+	}
+
+	locations := models.NewLocationModel(slog.New(slog.DiscardHandler), &MockDB{}, &q)
+
+	detail, err := locations.Get(t.Context(), householdID, garageID)
+	if err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+
+	wantLocation := models.Location{ID: garageID, ParentID: houseID, Name: "Garage"}
+	if detail.Location != wantLocation {
+		t.Errorf("Expected location %v, got %v", wantLocation, detail.Location)
+	}
+
+	wantBreadcrumbs := []models.Location{
+		{ID: houseID, Name: "House"},
+		{ID: garageID, ParentID: houseID, Name: "Garage"},
+	}
+	if !slices.Equal(detail.Breadcrumbs, wantBreadcrumbs) {
+		t.Errorf("Expected breadcrumbs %v, got %v", wantBreadcrumbs, detail.Breadcrumbs)
+	}
+
+	wantDescendants := []models.LocationNode{
+		{Location: models.Location{ID: shelfID, ParentID: garageID, Name: "Shelf 3"}, Depth: 1, DescendantCount: 1},
+		{Location: models.Location{ID: binID, ParentID: shelfID, Name: "Bin B"}, Depth: 2},
+		{Location: models.Location{ID: drawerID, ParentID: garageID, Name: "Workbench"}, Depth: 1},
+	}
+	if !slices.Equal(detail.Descendants, wantDescendants) {
+		t.Errorf("Expected descendants %v, got %v", wantDescendants, detail.Descendants)
+	}
+
+	wantSubtree := queries.ListLocationSubtreeParams{HouseholdID: householdID, ParentID: uuid.NullUUID{UUID: garageID, Valid: true}}
+	if q.subtreeParams != wantSubtree {
+		t.Errorf("Expected subtree listed with %v, got %v", wantSubtree, q.subtreeParams)
+	}
+}
+
+func TestLocationModel_Get_NotFound(t *testing.T) {
+	locations := models.NewLocationModel(slog.New(slog.DiscardHandler), &MockDB{}, &MockLocationQueries{})
+
+	if _, err := locations.Get(t.Context(), uuid.New(), uuid.New()); !errors.Is(err, models.ErrLocationNotFound) {
+		t.Errorf("Expected error %v, got %v", models.ErrLocationNotFound, err)
+	}
+}
+
+func TestLocationModel_GetHouseholdID(t *testing.T) {
+	userID := uuid.New()
+	householdID := uuid.New()
+	locationID := uuid.New()
+	genericDBError := errors.New("generic DB error")
+
+	testCases := []struct {
+		name            string
+		queries         MockLocationQueries
+		wantHouseholdID uuid.UUID
+		wantErr         error
+	}{
+		{
+			name:    "not found",
+			queries: MockLocationQueries{householdForMemberError: pgx.ErrNoRows},
+			wantErr: models.ErrLocationNotFound,
+		},
+		{
+			name:    "query error",
+			queries: MockLocationQueries{householdForMemberError: genericDBError},
+			wantErr: genericDBError,
+		},
+		{
+			name:            "found",
+			queries:         MockLocationQueries{householdForMemberReturn: householdID},
+			wantHouseholdID: householdID,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			locations := models.NewLocationModel(slog.New(slog.DiscardHandler), &MockDB{}, &tt.queries)
+
+			got, err := locations.GetHouseholdID(t.Context(), userID, locationID)
+
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("GetHouseholdID returned an error: %v", err)
+			}
+
+			if tt.wantErr != nil && (err == nil || !strings.Contains(err.Error(), tt.wantErr.Error())) {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+
+			if got != tt.wantHouseholdID {
+				t.Errorf("Expected household %v, got %v", tt.wantHouseholdID, got)
+			}
+
+			wantParams := queries.GetLocationHouseholdForMemberParams{ID: locationID, UserID: userID}
+			if tt.queries.householdForMemberParams != wantParams {
+				t.Errorf("Expected lookup %v, got %v", wantParams, tt.queries.householdForMemberParams)
+			}
+		})
+	}
+}
+
+func TestLocationModel_ListTree(t *testing.T) {
+	householdID := uuid.New()
+
+	q := MockLocationQueries{}
+	locations := models.NewLocationModel(slog.New(slog.DiscardHandler), &MockDB{}, &q)
+
+	if _, err := locations.ListTree(t.Context(), householdID); err != nil {
+		t.Fatalf("ListTree returned an error: %v", err)
+	}
+
+	want := queries.ListLocationSubtreeParams{HouseholdID: householdID}
+	if q.subtreeParams != want {
+		t.Errorf("Expected whole tree listed with %v, got %v", want, q.subtreeParams)
+	}
+}
+
+func TestLocationModel_Move(t *testing.T) {
+	userID := uuid.New()
+	householdID := uuid.New()
+	locationID := uuid.New()
+	currentParentID := uuid.New()
+	parentID := uuid.New()
+
+	existing := map[uuid.UUID]queries.Location{
+		locationID: {
+			ID:          locationID,
+			HouseholdID: householdID,
+			ParentID:    uuid.NullUUID{UUID: currentParentID, Valid: true},
+		},
+		currentParentID: {ID: currentParentID, HouseholdID: householdID},
+		parentID:        {ID: parentID, HouseholdID: householdID},
+	}
+
+	testCases := []struct {
+		name         string
+		db           MockDB
+		tx           MockTX
+		queries      MockLocationQueries
+		move         models.LocationMove
+		wantMove     bool
+		wantAudit    bool
+		wantTxCommit bool
+		wantErr      bool
+		wantErrorIs  error
+	}{
+		{
+			name:    "error starting transaction",
+			db:      MockDB{beginError: errors.New("begin failed")},
+			move:    models.LocationMove{ParentID: parentID},
+			wantErr: true,
+		},
+		{
+			name:        "missing location",
+			queries:     MockLocationQueries{locations: map[uuid.UUID]queries.Location{parentID: existing[parentID]}},
+			move:        models.LocationMove{ParentID: parentID},
+			wantErr:     true,
+			wantErrorIs: models.ErrLocationNotFound,
+		},
+		{
+			name:        "missing parent",
+			queries:     MockLocationQueries{locations: map[uuid.UUID]queries.Location{locationID: existing[locationID]}},
+			move:        models.LocationMove{ParentID: parentID},
+			wantErr:     true,
+			wantErrorIs: models.ErrLocationParentNotFound,
+		},
+		{
+			name:        "cycle",
+			queries:     MockLocationQueries{locations: existing},
+			move:        models.LocationMove{ParentID: parentID},
+			wantMove:    true,
+			wantErr:     true,
+			wantErrorIs: models.ErrLocationCycle,
+		},
+		{
+			name: "audit error",
+			queries: MockLocationQueries{
+				MockAuditEvents: MockAuditEvents{insertAuditError: errors.New("insert failed")},
+				locations:       existing,
+				moveReturn:      1,
+			},
+			move:      models.LocationMove{ParentID: parentID},
+			wantMove:  true,
+			wantAudit: true,
+			wantErr:   true,
+		},
+		{
+			name:    "already in parent",
+			queries: MockLocationQueries{locations: existing, moveReturn: 1},
+			move:    models.LocationMove{ParentID: currentParentID},
+		},
+		{
+			name:         "move to top level",
+			queries:      MockLocationQueries{locations: existing, moveReturn: 1},
+			wantMove:     true,
+			wantAudit:    true,
+			wantTxCommit: true,
+		},
+		{
+			name:         "move into parent",
+			queries:      MockLocationQueries{locations: existing, moveReturn: 1},
+			move:         models.LocationMove{ParentID: parentID},
+			wantMove:     true,
+			wantAudit:    true,
+			wantTxCommit: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.db.txFactory == nil {
+				tt.db.txFactory = func() models.Transaction { return &tt.tx }
+			}
+
+			locations := models.NewLocationModel(slog.New(slog.DiscardHandler), &tt.db, &tt.queries)
+
+			err := locations.Move(t.Context(), userID, householdID, locationID, tt.move)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error presence %v, got error %#v", tt.wantErr, err)
+			}
+
+			if tt.wantErrorIs != nil && !errors.Is(err, tt.wantErrorIs) {
+				t.Errorf("Expected error %v, got %v", tt.wantErrorIs, err)
+			}
+
+			if tt.db.beginError == nil && tt.queries.lockedHouseholdID != householdID {
+				t.Errorf("Expected household %v locations to be locked, got %v", householdID, tt.queries.lockedHouseholdID)
+			}
+
+			wantParams := queries.MoveLocationParams{}
+			if tt.wantMove {
+				wantParams = queries.MoveLocationParams{
+					HouseholdID: householdID,
+					ID:          locationID,
+					ParentID:    uuid.NullUUID{UUID: tt.move.ParentID, Valid: tt.move.ParentID != uuid.Nil},
+				}
+			}
+
+			if tt.queries.moveParams != wantParams {
+				t.Errorf("Expected move %v, got %v", wantParams, tt.queries.moveParams)
+			}
+
+			if tt.tx.committed != tt.wantTxCommit {
+				t.Errorf("Expected transaction commit %v, got %v", tt.wantTxCommit, tt.tx.committed)
+			}
+
+			var wantEvents []wantAuditEvent
+			if tt.wantAudit {
+				wantParent := ""
+				if tt.move.ParentID != uuid.Nil {
+					wantParent = tt.move.ParentID.String()
+				}
+
+				wantEvents = append(wantEvents, wantAuditEvent{
+					actorID:     userID,
+					householdID: householdID,
+					entityType:  models.AuditEntityLocation,
+					entityID:    locationID,
+					action:      models.AuditActionMoved,
+					changes: map[string]models.FieldChange{
+						"parent_id": {Old: currentParentID.String(), New: wantParent},
+					},
+				})
+			}
+
+			assertAuditEvents(t, wantEvents, tt.queries.insertedAuditEvents)
+		})
+	}
+}
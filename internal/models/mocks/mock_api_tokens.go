@@ -0,0 +1,53 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/cdriehuys/stuff2/internal/models"
+	"github.com/google/uuid"
+)
+
+type APITokenModel struct {
+	AuthenticatedToken string
+	AuthenticateReturn models.APIToken
+	AuthenticateError  error
+
+	CreatedUserID uuid.UUID
+	CreatedToken  models.NewAPIToken
+	CreateReturn  string
+	CreateError   error
+
+	ListedUserID     uuid.UUID
+	ListForUserList  []models.APIToken
+	ListForUserError error
+
+	RevokedUserID uuid.UUID
+	RevokedID     int32
+	RevokeError   error
+}
+
+func (m *APITokenModel) Authenticate(_ context.Context, token string) (models.APIToken, error) {
+	m.AuthenticatedToken = token
+
+	return m.AuthenticateReturn, m.AuthenticateError
+}
+
+func (m *APITokenModel) Create(_ context.Context, userID uuid.UUID, token models.NewAPIToken) (string, error) {
+	m.CreatedUserID = userID
+	m.CreatedToken = token
+
+	return m.CreateReturn, m.CreateError
+}
+
+func (m *APITokenModel) ListForUser(_ context.Context, userID uuid.UUID) ([]models.APIToken, error) {
+	m.ListedUserID = userID
+
+	return m.ListForUserList, m.ListForUserError
+}
+
+func (m *APITokenModel) Revoke(_ context.Context, userID uuid.UUID, id int32) error {
+	m.RevokedUserID = userID
+	m.RevokedID = id
+
+	return m.RevokeError
+}
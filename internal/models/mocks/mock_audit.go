@@ -0,0 +1,34 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/cdriehuys/stuff2/internal/models"
+	"github.com/google/uuid"
+)
+
+type AuditModel struct {
+	ListedHouseholdID  uuid.UUID
+	ListedEntityType   string
+	ListedEntityID     uuid.UUID
+	ListForEntityList  []models.AuditEvent
+	ListForEntityError error
+
+	ListedSecurityUserID uuid.UUID
+	ListSecurityLogList  []models.AuditEvent
+	ListSecurityLogError error
+}
+
+func (m *AuditModel) ListForEntity(_ context.Context, householdID uuid.UUID, entityType string, entityID uuid.UUID) ([]models.AuditEvent, error) {
+	m.ListedHouseholdID = householdID
+	m.ListedEntityType = entityType
+	m.ListedEntityID = entityID
+
+	return m.ListForEntityList, m.ListForEntityError
+}
+
+func (m *AuditModel) ListSecurityLog(_ context.Context, userID uuid.UUID) ([]models.AuditEvent, error) {
+	m.ListedSecurityUserID = userID
+
+	return m.ListSecurityLogList, m.ListSecurityLogError
+}
@@ -0,0 +1,76 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/cdriehuys/stuff2/internal/models"
+	"github.com/google/uuid"
+)
+
+type HouseholdModel struct {
+	AcceptedUserID         uuid.UUID
+	AcceptedToken          string
+	AcceptInvitationReturn models.Household
+	AcceptInvitationError  error
+
+	CreatedUserID    uuid.UUID
+	CreatedHousehold models.NewHousehold
+	CreateReturn     models.Household
+	CreateError      error
+
+	GotUserID        uuid.UUID
+	GotHouseholdID   uuid.UUID
+	GetForUserReturn models.Household
+	GetForUserError  error
+
+	InvitedUserID      uuid.UUID
+	InvitedHouseholdID uuid.UUID
+	InvitedInvitation  models.NewHouseholdInvitation
+	InviteError        error
+
+	ListedUserID     uuid.UUID
+	ListForUserList  []models.Household
+	ListForUserError error
+
+	ListMembersList  []models.HouseholdMember
+	ListMembersError error
+}
+
+func (m *HouseholdModel) AcceptInvitation(_ context.Context, userID uuid.UUID, token string) (models.Household, error) {
+	m.AcceptedUserID = userID
+	m.AcceptedToken = token
+
+	return m.AcceptInvitationReturn, m.AcceptInvitationError
+}
+
+func (m *HouseholdModel) Create(_ context.Context, userID uuid.UUID, household models.NewHousehold) (models.Household, error) {
+	m.CreatedUserID = userID
+	m.CreatedHousehold = household
+
+	return m.CreateReturn, m.CreateError
+}
+
+func (m *HouseholdModel) GetForUser(_ context.Context, userID uuid.UUID, householdID uuid.UUID) (models.Household, error) {
+	m.GotUserID = userID
+	m.GotHouseholdID = householdID
+
+	return m.GetForUserReturn, m.GetForUserError
+}
+
+func (m *HouseholdModel) Invite(_ context.Context, userID uuid.UUID, householdID uuid.UUID, invitation models.NewHouseholdInvitation) error {
+	m.InvitedUserID = userID
+	m.InvitedHouseholdID = householdID
+	m.InvitedInvitation = invitation
+
+	return m.InviteError
+}
+
+func (m *HouseholdModel) ListForUser(_ context.Context, userID uuid.UUID) ([]models.Household, error) {
+	m.ListedUserID = userID
+
+	return m.ListForUserList, m.ListForUserError
+}
+
+func (m *HouseholdModel) ListMembers(_ context.Context, userID uuid.UUID, householdID uuid.UUID) ([]models.HouseholdMember, error) {
+	return m.ListMembersList, m.ListMembersError
+}
@@ -0,0 +1,73 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/cdriehuys/stuff2/internal/models"
+	"github.com/google/uuid"
+)
+
+type LocationModel struct {
+	CreatedByUserID    uuid.UUID
+	CreatedHouseholdID uuid.UUID
+	CreatedLocation    models.NewLocation
+	CreateReturn       models.Location
+	CreateError        error
+
+	GotHouseholdID uuid.UUID
+	GotID          uuid.UUID
+	GetReturn      models.LocationDetail
+	GetError       error
+
+	GotHouseholdIDForUserID uuid.UUID
+	GotHouseholdIDForID     uuid.UUID
+	GetHouseholdIDReturn    uuid.UUID
+	GetHouseholdIDError     error
+
+	ListedHouseholdID uuid.UUID
+	ListTreeList      []models.LocationNode
+	ListTreeError     error
+
+	MovedByUserID    uuid.UUID
+	MovedHouseholdID uuid.UUID
+	MovedID          uuid.UUID
+	MovedMove        models.LocationMove
+	MoveError        error
+}
+
+func (m *LocationModel) Create(_ context.Context, userID uuid.UUID, householdID uuid.UUID, location models.NewLocation) (models.Location, error) {
+	m.CreatedByUserID = userID
+	m.CreatedHouseholdID = householdID
+	m.CreatedLocation = location
+
+	return m.CreateReturn, m.CreateError
+}
+
+func (m *LocationModel) Get(_ context.Context, householdID uuid.UUID, id uuid.UUID) (models.LocationDetail, error) {
+	m.GotHouseholdID = householdID
+	m.GotID = id
+
+	return m.GetReturn, m.GetError
+}
+
+func (m *LocationModel) GetHouseholdID(_ context.Context, userID uuid.UUID, id uuid.UUID) (uuid.UUID, error) {
+	m.GotHouseholdIDForUserID = userID
+	m.GotHouseholdIDForID = id
+
+	return m.GetHouseholdIDReturn, m.GetHouseholdIDError
+}
+
+func (m *LocationModel) ListTree(_ context.Context, householdID uuid.UUID) ([]models.LocationNode, error) {
+	m.ListedHouseholdID = householdID
+
+	return m.ListTreeList, m.ListTreeError
+}
+
+func (m *LocationModel) Move(_ context.Context, userID uuid.UUID, householdID uuid.UUID, id uuid.UUID, move models.LocationMove) error {
+	m.MovedByUserID = userID
+	m.MovedHouseholdID = householdID
+	m.MovedID = id
+	m.MovedMove = move
+
+	return m.MoveError
+}
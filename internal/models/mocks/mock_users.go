@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/cdriehuys/stuff2/internal/models"
+	"github.com/google/uuid"
 )
 
 type UserModel struct {
@@ -12,9 +13,32 @@ type UserModel struct {
 	AuthenticateUser      models.User
 	AuthenticateError     error
 
+	AuthenticatedExternalIdentity     models.ExternalIdentity
+	AuthenticateExternalIdentityUser  models.User
+	AuthenticateExternalIdentityError error
+
+	AuthenticateLoginLinkToken string
+	AuthenticateLoginLinkUser  models.User
+	AuthenticateLoginLinkError error
+
+	DeletedUserID   uuid.UUID
+	DeletedPassword string
+	DeleteError     error
+
+	GetDataExportUserID uuid.UUID
+	GetDataExportToken  string
+	GetDataExportReturn models.DataExport
+	GetDataExportError  error
+
 	RegisterError  error
 	RegisteredUser models.NewUser
 
+	RequestDataExportUserID uuid.UUID
+	RequestDataExportError  error
+
+	RequestLoginLinkEmail string
+	RequestLoginLinkError error
+
 	VerifyEmailToken string
 	VerifyEmailError error
 }
@@ -26,12 +50,50 @@ func (m *UserModel) Authenticate(_ context.Context, email string, password strin
 	return m.AuthenticateUser, m.AuthenticateError
 }
 
+func (m *UserModel) AuthenticateExternalIdentity(_ context.Context, identity models.ExternalIdentity) (models.User, error) {
+	m.AuthenticatedExternalIdentity = identity
+
+	return m.AuthenticateExternalIdentityUser, m.AuthenticateExternalIdentityError
+}
+
+func (m *UserModel) AuthenticateLoginLink(_ context.Context, token string) (models.User, error) {
+	m.AuthenticateLoginLinkToken = token
+
+	return m.AuthenticateLoginLinkUser, m.AuthenticateLoginLinkError
+}
+
+func (m *UserModel) Delete(_ context.Context, userID uuid.UUID, password string) error {
+	m.DeletedUserID = userID
+	m.DeletedPassword = password
+
+	return m.DeleteError
+}
+
+func (m *UserModel) GetDataExport(_ context.Context, userID uuid.UUID, token string) (models.DataExport, error) {
+	m.GetDataExportUserID = userID
+	m.GetDataExportToken = token
+
+	return m.GetDataExportReturn, m.GetDataExportError
+}
+
 func (m *UserModel) Register(_ context.Context, user models.NewUser) error {
 	m.RegisteredUser = user
 
 	return m.RegisterError
 }
 
+func (m *UserModel) RequestDataExport(_ context.Context, userID uuid.UUID) error {
+	m.RequestDataExportUserID = userID
+
+	return m.RequestDataExportError
+}
+
+func (m *UserModel) RequestLoginLink(_ context.Context, email string) error {
+	m.RequestLoginLinkEmail = email
+
+	return m.RequestLoginLinkError
+}
+
 func (m *UserModel) VerifyEmail(_ context.Context, token string) error {
 	m.VerifyEmailToken = token
 
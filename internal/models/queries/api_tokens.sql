@@ -0,0 +1,22 @@
+-- name: DeleteAPITokenForUser :one
+DELETE FROM api_tokens
+WHERE id = @id AND user_id = @user_id
+RETURNING name;
+
+-- name: GetAPITokenByHash :one
+SELECT * FROM api_tokens
+WHERE token_hash = @token_hash;
+
+-- name: InsertAPIToken :exec
+INSERT INTO api_tokens(user_id, name, token_hash, scopes, expires_at)
+VALUES (@user_id, @name, @token_hash, @scopes, @expires_at);
+
+-- name: ListAPITokensForUser :many
+SELECT * FROM api_tokens
+WHERE user_id = @user_id
+ORDER BY created_at DESC;
+
+-- name: UpdateAPITokenLastUsed :exec
+UPDATE api_tokens
+SET last_used_at = now()
+WHERE id = @id;
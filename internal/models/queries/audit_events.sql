@@ -0,0 +1,36 @@
+-- name: InsertAuditEvent :exec
+INSERT INTO audit_events(actor_id, household_id, user_id, entity_type, entity_id, action, changes)
+VALUES (
+    sqlc.narg(actor_id),
+    sqlc.narg(household_id),
+    sqlc.narg(user_id),
+    @entity_type,
+    @entity_id,
+    @action,
+    @changes
+);
+
+-- name: ListAllAuditEventsForUser :many
+SELECT audit_events.*, users.email AS actor_email
+FROM audit_events
+LEFT JOIN users ON users.id = audit_events.actor_id
+WHERE audit_events.user_id = @user_id::uuid
+ORDER BY audit_events.created_at DESC, audit_events.id DESC;
+
+-- name: ListAuditEventsForEntity :many
+SELECT audit_events.*, users.email AS actor_email
+FROM audit_events
+LEFT JOIN users ON users.id = audit_events.actor_id
+WHERE audit_events.household_id = @household_id::uuid
+    AND audit_events.entity_type = @entity_type
+    AND audit_events.entity_id = @entity_id
+ORDER BY audit_events.created_at DESC, audit_events.id DESC
+LIMIT @max_events;
+
+-- name: ListAuditEventsForUser :many
+SELECT audit_events.*, users.email AS actor_email
+FROM audit_events
+LEFT JOIN users ON users.id = audit_events.actor_id
+WHERE audit_events.user_id = @user_id::uuid
+ORDER BY audit_events.created_at DESC, audit_events.id DESC
+LIMIT @max_events;
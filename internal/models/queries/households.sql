@@ -0,0 +1,107 @@
+-- name: DeleteHouseholdInvitationByID :exec
+DELETE FROM household_invitations
+WHERE id = @id;
+
+-- name: DeleteHouseholdInvitationsForEmail :exec
+DELETE FROM household_invitations
+WHERE lower(email) = lower(@email);
+
+-- name: DeleteHouseholdsWithOnlyMember :exec
+DELETE FROM households
+WHERE id IN (
+    SELECT members.household_id FROM household_members members
+    WHERE members.user_id = @user_id
+)
+AND NOT EXISTS (
+    SELECT 1 FROM household_members
+    WHERE household_members.household_id = households.id
+        AND household_members.user_id <> @user_id
+);
+
+-- name: GetHouseholdForMember :one
+SELECT households.id, households.name, household_members.role
+FROM households
+JOIN household_members ON household_members.household_id = households.id
+WHERE households.id = @household_id AND household_members.user_id = @user_id;
+
+-- name: GetHouseholdInvitationByToken :one
+SELECT * FROM household_invitations
+WHERE token = @token;
+
+-- name: InsertHousehold :exec
+INSERT INTO households(id, name)
+VALUES (@id, @name);
+
+-- name: InsertHouseholdInvitation :exec
+INSERT INTO household_invitations(household_id, email, role, token, invited_by)
+VALUES (@household_id, @email, @role, @token, @invited_by);
+
+-- name: InsertHouseholdMember :execrows
+INSERT INTO household_members(household_id, user_id, role)
+VALUES (@household_id, @user_id, @role)
+ON CONFLICT (household_id, user_id) DO NOTHING;
+
+-- name: ListHouseholdInvitationsForUser :many
+-- Lists the invitations sent by the user, or to their email address, leaving out the secret token.
+SELECT
+    household_invitations.id,
+    household_invitations.household_id,
+    households.name AS household_name,
+    household_invitations.email,
+    household_invitations.role,
+    household_invitations.invited_by,
+    household_invitations.created_at
+FROM household_invitations
+JOIN households ON households.id = household_invitations.household_id
+WHERE household_invitations.invited_by = @user_id
+    OR lower(household_invitations.email) = lower(@email)
+ORDER BY household_invitations.created_at, household_invitations.id;
+
+-- name: ListHouseholdMembers :many
+SELECT users.id AS user_id, users.email, household_members.role
+FROM household_members
+JOIN users ON users.id = household_members.user_id
+WHERE household_members.household_id = @household_id
+ORDER BY users.email;
+
+-- name: ListHouseholdsForUser :many
+SELECT households.id, households.name, household_members.role
+FROM households
+JOIN household_members ON household_members.household_id = households.id
+WHERE household_members.user_id = @user_id
+ORDER BY households.name, households.id;
+
+-- name: ListHouseholdMembershipsForUser :many
+SELECT households.id, households.name, household_members.role, household_members.created_at
+FROM households
+JOIN household_members ON household_members.household_id = households.id
+WHERE household_members.user_id = @user_id
+ORDER BY households.name, households.id;
+
+-- name: PromoteSuccessorOwners :many
+-- Promotes a member of each household where the given user is the only owner. Editors are
+-- preferred over viewers, then whoever joined first.
+UPDATE household_members
+SET role = 'owner'
+FROM (
+    SELECT DISTINCT ON (candidates.household_id)
+        candidates.household_id,
+        candidates.user_id,
+        candidates.role
+    FROM household_members candidates
+    JOIN household_members leaving
+        ON leaving.household_id = candidates.household_id
+        AND leaving.user_id = @user_id
+        AND leaving.role = 'owner'
+    WHERE candidates.user_id <> @user_id
+        AND NOT EXISTS (
+            SELECT 1 FROM household_members owners
+            WHERE owners.household_id = candidates.household_id
+                AND owners.role = 'owner'
+                AND owners.user_id <> @user_id
+        )
+    ORDER BY candidates.household_id, candidates.role = 'editor' DESC, candidates.created_at, candidates.user_id
+) successors
+WHERE household_members.household_id = successors.household_id
+    AND household_members.user_id = successors.user_id
+RETURNING household_members.household_id, household_members.user_id, successors.role AS previous_role;
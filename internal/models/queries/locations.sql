@@ -0,0 +1,89 @@
+-- name: GetLocation :one
+SELECT * FROM locations
+WHERE household_id = @household_id AND id = @id;
+
+-- name: GetLocationHouseholdForMember :one
+-- Finds which of the member's households a location belongs to.
+SELECT locations.household_id FROM locations
+JOIN household_members ON household_members.household_id = locations.household_id
+WHERE locations.id = @id AND household_members.user_id = @user_id;
+
+-- name: InsertLocation :exec
+INSERT INTO locations(id, household_id, parent_id, name)
+VALUES (@id, @household_id, sqlc.narg(parent_id), @name);
+
+-- name: ListLocationAncestors :many
+-- Lists the location and every location containing it, starting from the outermost location.
+WITH RECURSIVE ancestors AS (
+    SELECT locations.id, locations.parent_id, locations.name, 0 AS depth
+    FROM locations
+    WHERE locations.household_id = @household_id AND locations.id = @id
+
+    UNION ALL
+
+    SELECT locations.id, locations.parent_id, locations.name, ancestors.depth + 1
+    FROM locations
+    JOIN ancestors ON locations.id = ancestors.parent_id
+)
+SELECT ancestors.id, ancestors.parent_id, ancestors.name
+FROM ancestors
+ORDER BY ancestors.depth DESC;
+
+-- name: ListLocationSubtree :many
+-- Lists every location nested under the given parent, or the household's whole tree if there is
+-- no parent. Locations are ordered depth first, with siblings ordered by name.
+WITH RECURSIVE subtree AS (
+    SELECT
+        locations.id,
+        locations.parent_id,
+        locations.name,
+        1 AS depth,
+        ARRAY[locations.name, locations.id::text] AS path
+    FROM locations
+    WHERE locations.household_id = @household_id
+        AND locations.parent_id IS NOT DISTINCT FROM sqlc.narg(parent_id)
+
+    UNION ALL
+
+    SELECT
+        locations.id,
+        locations.parent_id,
+        locations.name,
+        subtree.depth + 1,
+        subtree.path || ARRAY[locations.name, locations.id::text]
+    FROM locations
+    JOIN subtree ON locations.parent_id = subtree.id
+)
+SELECT subtree.id, subtree.parent_id, subtree.name, subtree.depth::int AS depth
+FROM subtree
+ORDER BY subtree.path;
+
+-- name: LockHouseholdLocations :exec
+-- Serializes changes to the shape of a household's location tree so concurrent moves can't create
+-- a cycle.
+SELECT id FROM households
+WHERE id = @household_id
+FOR UPDATE;
+
+-- name: MoveLocation :execrows
+-- Moves the location under a new parent. Nothing is moved if the new parent is the location itself
+-- or one of its descendants.
+WITH RECURSIVE subtree AS (
+    SELECT locations.id
+    FROM locations
+    WHERE locations.household_id = @household_id AND locations.id = @id
+
+    UNION ALL
+
+    SELECT locations.id
+    FROM locations
+    JOIN subtree ON locations.parent_id = subtree.id
+)
+UPDATE locations
+SET parent_id = sqlc.narg(parent_id)
+WHERE locations.household_id = @household_id
+    AND locations.id = @id
+    AND (
+        sqlc.narg(parent_id)::uuid IS NULL
+        OR sqlc.narg(parent_id)::uuid NOT IN (SELECT subtree.id FROM subtree)
+    );
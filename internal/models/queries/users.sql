@@ -2,27 +2,67 @@
 DELETE FROM email_verification_keys
 WHERE id = @id;
 
+-- name: DeleteLoginLinkByToken :one
+DELETE FROM login_links
+WHERE token = @token
+RETURNING *;
+
 -- name: DeleteUnverifiedEmails :exec
 DELETE FROM users
 WHERE email = @email AND email_verified_at IS NULL;
 
+-- name: DeleteUserByID :exec
+DELETE FROM users
+WHERE id = @id;
+
+-- name: GetDataExportByToken :one
+SELECT * FROM data_exports
+WHERE token = @token;
+
 -- name: GetEmailVerificationKeyByToken :one
 SELECT * FROM email_verification_keys
 WHERE token = @token;
 
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = @id;
+
+-- name: GetUserByExternalIdentity :one
+SELECT users.* FROM users
+JOIN external_identities ON external_identities.user_id = users.id
+WHERE external_identities.issuer = @issuer AND external_identities.subject = @subject;
+
 -- name: GetUserByVerifiedEmail :one
 SELECT * FROM users
 WHERE email = @email AND email_verified_at IS NOT NULL;
 
+-- name: InsertDataExport :exec
+INSERT INTO data_exports(user_id, token)
+VALUES (@user_id, @token);
+
 -- name: InsertEmailVerificationKey :exec
 INSERT INTO email_verification_keys(user_id, email, token)
 VALUES (@user_id, @email, @token);
 
+-- name: InsertExternalIdentity :exec
+INSERT INTO external_identities(user_id, issuer, provider, subject)
+VALUES (@user_id, @issuer, @provider, @subject)
+ON CONFLICT (issuer, subject) DO NOTHING;
+
+-- name: InsertLoginLink :exec
+INSERT INTO login_links(user_id, token)
+VALUES (@user_id, @token);
+
 -- name: InsertNewUser :one
 INSERT INTO users (id, email, password_hash)
 VALUES (@id, @email, @password_hash)
 RETURNING *;
 
+-- name: ListExternalIdentitiesForUser :many
+SELECT * FROM external_identities
+WHERE user_id = @user_id
+ORDER BY created_at, id;
+
 -- name: VerifiedEmailExists :one
 SELECT EXISTS(
     SELECT 1 FROM users
@@ -58,6 +58,104 @@ type User struct {
 	ID uuid.UUID
 }
 
+// UserData is the account information stored for a user, suitable for handing back to them.
+type UserData struct {
+	ID              uuid.UUID  `json:"id"`
+	Email           string     `json:"email"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func makeUserData(user queries.User) UserData {
+	data := UserData{
+		ID:        user.ID,
+		Email:     user.Email,
+		CreatedAt: user.CreatedAt.Time,
+		UpdatedAt: user.UpdatedAt.Time,
+	}
+
+	if user.EmailVerifiedAt.Valid {
+		data.EmailVerifiedAt = &user.EmailVerifiedAt.Time
+	}
+
+	return data
+}
+
+// APITokenData is the metadata of an API token. Only a hash of the token itself is stored, so it
+// isn't included.
+type APITokenData struct {
+	ID         int32      `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func makeAPITokenData(token queries.APIToken) APITokenData {
+	data := APITokenData{
+		ID:        token.ID,
+		Name:      token.Name,
+		Scopes:    token.Scopes,
+		ExpiresAt: token.ExpiresAt.Time,
+		CreatedAt: token.CreatedAt.Time,
+	}
+
+	if token.LastUsedAt.Valid {
+		data.LastUsedAt = &token.LastUsedAt.Time
+	}
+
+	return data
+}
+
+// ExternalIdentityData is an identity from a single sign-on provider linked to the user.
+type ExternalIdentityData struct {
+	Issuer    string    `json:"issuer"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// HouseholdMembershipData is a household the user belongs to.
+type HouseholdMembershipData struct {
+	HouseholdID   uuid.UUID     `json:"household_id"`
+	HouseholdName string        `json:"household_name"`
+	Role          HouseholdRole `json:"role"`
+	JoinedAt      time.Time     `json:"joined_at"`
+}
+
+// HouseholdInvitationData is an invitation sent by the user or to their email address. The token
+// used to accept the invitation isn't included.
+type HouseholdInvitationData struct {
+	ID            int32         `json:"id"`
+	HouseholdID   uuid.UUID     `json:"household_id"`
+	HouseholdName string        `json:"household_name"`
+	Email         string        `json:"email"`
+	Role          HouseholdRole `json:"role"`
+	InvitedBy     uuid.UUID     `json:"invited_by"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+// SecurityEventData is an event from the user's security log.
+type SecurityEventData struct {
+	ID         int64                  `json:"id"`
+	ActorEmail string                 `json:"actor_email,omitempty"`
+	Action     AuditAction            `json:"action"`
+	Changes    map[string]FieldChange `json:"changes"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// DataExport contains everything we hold about a user.
+type DataExport struct {
+	User                 UserData
+	APITokens            []APITokenData
+	ExternalIdentities   []ExternalIdentityData
+	HouseholdMemberships []HouseholdMembershipData
+	HouseholdInvitations []HouseholdInvitationData
+	SecurityLog          []SecurityEventData
+}
+
 type PasswordHasher interface {
 	Hash(password string) (string, error)
 	ComparePasswordAndHash(password string, hash string) (bool, error)
@@ -68,7 +166,9 @@ type TokenGenerator interface {
 }
 
 type EmailVerifier interface {
+	DataExport(ctx context.Context, email string, token string) error
 	DuplicateRegistration(ctx context.Context, email string) error
+	LoginLink(ctx context.Context, email string, token string) error
 	NewEmail(ctx context.Context, email string, token string) error
 }
 
@@ -76,11 +176,28 @@ type UserQueries interface {
 	WithTx(tx queries.DBTX) UserQueries
 
 	DeleteEmailVerificationKeyByID(ctx context.Context, id int32) error
+	DeleteLoginLinkByToken(ctx context.Context, token string) (queries.LoginLink, error)
+	DeleteHouseholdInvitationsForEmail(ctx context.Context, email string) error
+	DeleteHouseholdsWithOnlyMember(ctx context.Context, userID uuid.UUID) error
 	DeleteUnverifiedEmails(ctx context.Context, email string) error
+	DeleteUserByID(ctx context.Context, id uuid.UUID) error
+	GetDataExportByToken(ctx context.Context, token string) (queries.DataExport, error)
 	GetEmailVerificationKeyByToken(context.Context, string) (queries.EmailVerificationKey, error)
+	GetUserByExternalIdentity(context.Context, queries.GetUserByExternalIdentityParams) (queries.User, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (queries.User, error)
 	GetUserByVerifiedEmail(ctx context.Context, email string) (queries.User, error)
+	InsertAuditEvent(context.Context, queries.InsertAuditEventParams) error
+	InsertDataExport(context.Context, queries.InsertDataExportParams) error
 	InsertEmailVerificationKey(context.Context, queries.InsertEmailVerificationKeyParams) error
+	InsertExternalIdentity(context.Context, queries.InsertExternalIdentityParams) error
+	InsertLoginLink(context.Context, queries.InsertLoginLinkParams) error
 	InsertNewUser(context.Context, queries.InsertNewUserParams) (queries.User, error)
+	ListAllAuditEventsForUser(ctx context.Context, userID uuid.UUID) ([]queries.ListAllAuditEventsForUserRow, error)
+	ListAPITokensForUser(ctx context.Context, userID uuid.UUID) ([]queries.APIToken, error)
+	ListExternalIdentitiesForUser(ctx context.Context, userID uuid.UUID) ([]queries.ExternalIdentity, error)
+	ListHouseholdInvitationsForUser(context.Context, queries.ListHouseholdInvitationsForUserParams) ([]queries.ListHouseholdInvitationsForUserRow, error)
+	ListHouseholdMembershipsForUser(ctx context.Context, userID uuid.UUID) ([]queries.ListHouseholdMembershipsForUserRow, error)
+	PromoteSuccessorOwners(ctx context.Context, userID uuid.UUID) ([]queries.PromoteSuccessorOwnersRow, error)
 	VerifiedEmailExists(context.Context, string) (bool, error)
 	VerifyEmailForUser(ctx context.Context, userID uuid.UUID) error
 }
@@ -99,6 +216,8 @@ type UserModel struct {
 	hasher         PasswordHasher
 	tokenGenerator TokenGenerator
 	tokenLifetime  time.Duration
+	exportLifetime time.Duration
+	loginLifetime  time.Duration
 
 	db DB
 	q  UserQueries
@@ -110,6 +229,8 @@ func NewUserModel(
 	hasher PasswordHasher,
 	tokenGenerator TokenGenerator,
 	tokenLifetime time.Duration,
+	exportLifetime time.Duration,
+	loginLifetime time.Duration,
 	db DB,
 	queries UserQueries,
 ) *UserModel {
@@ -119,6 +240,8 @@ func NewUserModel(
 		hasher:         hasher,
 		tokenGenerator: tokenGenerator,
 		tokenLifetime:  tokenLifetime,
+		exportLifetime: exportLifetime,
+		loginLifetime:  loginLifetime,
 		db:             db,
 		q:              queries,
 	}
@@ -156,6 +279,200 @@ func (m *UserModel) Authenticate(ctx context.Context, email string, password str
 		return User{}, ErrInvalidCredentials
 	}
 
+	if err := recordAuditEvent(ctx, m.q, securityEntry(user.ID, AuditActionLoggedInWithPassword)); err != nil {
+		return User{}, err
+	}
+
+	return User{ID: user.ID}, nil
+}
+
+// RequestLoginLink emails a single-use sign-in link to the user with the given verified email. To
+// avoid revealing which emails have accounts, no error is returned if there is no such user.
+func (m *UserModel) RequestLoginLink(ctx context.Context, email string) error {
+	trimmedEmail := strings.TrimSpace(email)
+
+	user, err := m.q.GetUserByVerifiedEmail(ctx, trimmedEmail)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			m.logger.DebugContext(ctx, "Login link requested for an email without a verified user.")
+
+			return nil
+		}
+
+		return fmt.Errorf("searching for user: %v", err)
+	}
+
+	token := m.tokenGenerator.Generate()
+
+	linkParams := queries.InsertLoginLinkParams{
+		UserID: user.ID,
+		Token:  token,
+	}
+	if err := m.q.InsertLoginLink(ctx, linkParams); err != nil {
+		return fmt.Errorf("inserting login link: %v", err)
+	}
+
+	m.logger.DebugContext(ctx, "Persisted login link.", "userID", user.ID)
+
+	if err := m.emailVerifier.LoginLink(ctx, trimmedEmail, token); err != nil {
+		return fmt.Errorf("sending login link: %v", err)
+	}
+
+	return nil
+}
+
+var ErrInvalidLoginLink = errors.New("invalid login link")
+
+// AuthenticateLoginLink consumes a login link and returns the user it belongs to. Links are
+// deleted as they are used, so each one can only be used once.
+func (m *UserModel) AuthenticateLoginLink(ctx context.Context, token string) (_ User, retErr error) {
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return User{}, fmt.Errorf("starting transaction: %v", err)
+	}
+
+	defer func() {
+		if txErr := tx.Rollback(ctx); txErr != nil && !errors.Is(txErr, pgx.ErrTxClosed) {
+			retErr = errors.Join(retErr, txErr)
+		}
+	}()
+
+	txQueries := m.q.WithTx(tx)
+
+	link, err := txQueries.DeleteLoginLinkByToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			m.logger.DebugContext(ctx, "Login link does not exist.")
+
+			return User{}, ErrInvalidLoginLink
+		}
+
+		return User{}, fmt.Errorf("consuming login link: %v", err)
+	}
+
+	if link.CreatedAt.Time.Add(m.loginLifetime).Before(time.Now()) {
+		m.logger.DebugContext(ctx, "Login link is expired.", "userID", link.UserID)
+
+		// Expired links are still consumed so they can't be tried again.
+		if err := tx.Commit(ctx); err != nil {
+			return User{}, fmt.Errorf("committing transaction: %v", err)
+		}
+
+		return User{}, ErrInvalidLoginLink
+	}
+
+	if err := recordAuditEvent(ctx, txQueries, securityEntry(link.UserID, AuditActionLoggedInWithLink)); err != nil {
+		return User{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return User{}, fmt.Errorf("committing transaction: %v", err)
+	}
+
+	m.logger.InfoContext(ctx, "Authenticated user with login link.", "userID", link.UserID)
+
+	return User{ID: link.UserID}, nil
+}
+
+// ExternalIdentity is a user's identity at an external identity provider.
+type ExternalIdentity struct {
+	// Issuer identifies the provider that issued the identity.
+	Issuer string
+
+	// Provider is the name of the configured provider.
+	Provider string
+
+	// Subject is the issuer's identifier for the user. Unlike the email, it never changes, but it
+	// is only unique for the issuer.
+	Subject string
+
+	Email         string
+	EmailVerified bool
+}
+
+var ErrExternalIdentityNotLinked = errors.New("external identity is not linked to a user")
+
+// AuthenticateExternalIdentity returns the user linked to an external identity. An identity that
+// hasn't been seen before is linked to the user with the same verified email, as long as the
+// provider has also verified that email.
+func (m *UserModel) AuthenticateExternalIdentity(ctx context.Context, identity ExternalIdentity) (_ User, retErr error) {
+	identityParams := queries.GetUserByExternalIdentityParams{
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+	}
+
+	user, err := m.q.GetUserByExternalIdentity(ctx, identityParams)
+	if err == nil {
+		if err := recordAuditEvent(ctx, m.q, securityEntry(user.ID, AuditActionLoggedInWithOIDC)); err != nil {
+			return User{}, err
+		}
+
+		return User{ID: user.ID}, nil
+	}
+
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return User{}, fmt.Errorf("searching for linked user: %v", err)
+	}
+
+	if !identity.EmailVerified {
+		m.logger.InfoContext(ctx, "External identity has no verified email to link with.", "provider", identity.Provider)
+
+		return User{}, ErrExternalIdentityNotLinked
+	}
+
+	user, err = m.q.GetUserByVerifiedEmail(ctx, strings.TrimSpace(identity.Email))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			m.logger.InfoContext(ctx, "No user to link external identity with.", "provider", identity.Provider)
+
+			return User{}, ErrExternalIdentityNotLinked
+		}
+
+		return User{}, fmt.Errorf("searching for user by email: %v", err)
+	}
+
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return User{}, fmt.Errorf("starting transaction: %v", err)
+	}
+
+	defer func() {
+		if txErr := tx.Rollback(ctx); txErr != nil && !errors.Is(txErr, pgx.ErrTxClosed) {
+			retErr = errors.Join(retErr, txErr)
+		}
+	}()
+
+	txQueries := m.q.WithTx(tx)
+
+	linkParams := queries.InsertExternalIdentityParams{
+		UserID:   user.ID,
+		Issuer:   identity.Issuer,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+	}
+	if err := txQueries.InsertExternalIdentity(ctx, linkParams); err != nil {
+		return User{}, fmt.Errorf("linking external identity: %v", err)
+	}
+
+	linkEntry := securityEntry(user.ID, AuditActionExternalIdentityLinked)
+	linkEntry.changes = map[string]FieldChange{
+		"issuer":   {New: identity.Issuer},
+		"provider": {New: identity.Provider},
+	}
+	if err := recordAuditEvent(ctx, txQueries, linkEntry); err != nil {
+		return User{}, err
+	}
+
+	if err := recordAuditEvent(ctx, txQueries, securityEntry(user.ID, AuditActionLoggedInWithOIDC)); err != nil {
+		return User{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return User{}, fmt.Errorf("committing transaction: %v", err)
+	}
+
+	m.logger.InfoContext(ctx, "Linked external identity to user.", "userID", user.ID, "provider", identity.Provider)
+
 	return User{ID: user.ID}, nil
 }
 
@@ -203,6 +520,12 @@ func (m *UserModel) Register(ctx context.Context, user NewUser) (retErr error) {
 
 	m.logger.DebugContext(ctx, "Persisted new user.", "userID", userID)
 
+	entry := securityEntry(userID, AuditActionCreated)
+	entry.changes = map[string]FieldChange{"email": {New: user.Email}}
+	if err := recordAuditEvent(ctx, txQueries, entry); err != nil {
+		return err
+	}
+
 	verificationToken := m.tokenGenerator.Generate()
 
 	emailVerificationParams := queries.InsertEmailVerificationKeyParams{
@@ -277,6 +600,12 @@ func (m *UserModel) VerifyEmail(ctx context.Context, token string) (retErr error
 		return fmt.Errorf("deleting duplicate unverified users: %v", err)
 	}
 
+	entry := securityEntry(verification.UserID, AuditActionEmailVerified)
+	entry.changes = map[string]FieldChange{"email": {New: verification.Email}}
+	if err := recordAuditEvent(ctx, txQueries, entry); err != nil {
+		return err
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("committing transaction: %v", err)
 	}
@@ -289,3 +618,249 @@ func (m *UserModel) VerifyEmail(ctx context.Context, token string) (retErr error
 
 	return nil
 }
+
+var ErrInvalidDataExportToken = errors.New("invalid data export token")
+
+// RequestDataExport creates a new data export for the user and emails them a link to download it.
+func (m *UserModel) RequestDataExport(ctx context.Context, userID uuid.UUID) (retErr error) {
+	user, err := m.q.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("retrieving user %s: %v", userID.String(), err)
+	}
+
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("starting transaction: %v", err)
+	}
+
+	defer func() {
+		if txErr := tx.Rollback(ctx); txErr != nil && !errors.Is(txErr, pgx.ErrTxClosed) {
+			retErr = errors.Join(retErr, txErr)
+		}
+	}()
+
+	txQueries := m.q.WithTx(tx)
+
+	token := m.tokenGenerator.Generate()
+
+	exportParams := queries.InsertDataExportParams{
+		UserID: userID,
+		Token:  token,
+	}
+	if err := txQueries.InsertDataExport(ctx, exportParams); err != nil {
+		return fmt.Errorf("inserting data export: %v", err)
+	}
+
+	if err := recordAuditEvent(ctx, txQueries, securityEntry(userID, AuditActionDataExportRequested)); err != nil {
+		return err
+	}
+
+	if err := m.emailVerifier.DataExport(ctx, user.Email, token); err != nil {
+		return fmt.Errorf("sending data export link: %v", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("committing transaction: %v", err)
+	}
+
+	m.logger.InfoContext(ctx, "Created data export.", "userID", userID)
+
+	return nil
+}
+
+// GetDataExport collects the data for the export identified by the given token. The export must
+// belong to the given user and must not have expired.
+func (m *UserModel) GetDataExport(ctx context.Context, userID uuid.UUID, token string) (DataExport, error) {
+	export, err := m.q.GetDataExportByToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			m.logger.DebugContext(ctx, "Data export token does not exist.")
+
+			return DataExport{}, ErrInvalidDataExportToken
+		}
+
+		return DataExport{}, fmt.Errorf("retrieving data export: %v", err)
+	}
+
+	if export.UserID != userID {
+		m.logger.WarnContext(ctx, "Data export requested by a different user.", "userID", userID, "exportUserID", export.UserID)
+
+		return DataExport{}, ErrInvalidDataExportToken
+	}
+
+	if export.CreatedAt.Time.Add(m.exportLifetime).Before(time.Now()) {
+		m.logger.DebugContext(ctx, "Data export token is expired.")
+
+		return DataExport{}, ErrInvalidDataExportToken
+	}
+
+	user, err := m.q.GetUserByID(ctx, userID)
+	if err != nil {
+		return DataExport{}, fmt.Errorf("retrieving user %s: %v", userID.String(), err)
+	}
+
+	data := DataExport{User: makeUserData(user)}
+
+	tokens, err := m.q.ListAPITokensForUser(ctx, userID)
+	if err != nil {
+		return DataExport{}, fmt.Errorf("listing API tokens: %v", err)
+	}
+
+	data.APITokens = make([]APITokenData, len(tokens))
+	for i, token := range tokens {
+		data.APITokens[i] = makeAPITokenData(token)
+	}
+
+	identities, err := m.q.ListExternalIdentitiesForUser(ctx, userID)
+	if err != nil {
+		return DataExport{}, fmt.Errorf("listing external identities: %v", err)
+	}
+
+	data.ExternalIdentities = make([]ExternalIdentityData, len(identities))
+	for i, identity := range identities {
+		data.ExternalIdentities[i] = ExternalIdentityData{
+			Issuer:    identity.Issuer,
+			Provider:  identity.Provider,
+			Subject:   identity.Subject,
+			CreatedAt: identity.CreatedAt.Time,
+		}
+	}
+
+	memberships, err := m.q.ListHouseholdMembershipsForUser(ctx, userID)
+	if err != nil {
+		return DataExport{}, fmt.Errorf("listing household memberships: %v", err)
+	}
+
+	data.HouseholdMemberships = make([]HouseholdMembershipData, len(memberships))
+	for i, membership := range memberships {
+		data.HouseholdMemberships[i] = HouseholdMembershipData{
+			HouseholdID:   membership.ID,
+			HouseholdName: membership.Name,
+			Role:          HouseholdRole(membership.Role),
+			JoinedAt:      membership.CreatedAt.Time,
+		}
+	}
+
+	invitationParams := queries.ListHouseholdInvitationsForUserParams{
+		UserID: userID,
+		Email:  user.Email,
+	}
+	invitations, err := m.q.ListHouseholdInvitationsForUser(ctx, invitationParams)
+	if err != nil {
+		return DataExport{}, fmt.Errorf("listing household invitations: %v", err)
+	}
+
+	data.HouseholdInvitations = make([]HouseholdInvitationData, len(invitations))
+	for i, invitation := range invitations {
+		data.HouseholdInvitations[i] = HouseholdInvitationData{
+			ID:            invitation.ID,
+			HouseholdID:   invitation.HouseholdID,
+			HouseholdName: invitation.HouseholdName,
+			Email:         invitation.Email,
+			Role:          HouseholdRole(invitation.Role),
+			InvitedBy:     invitation.InvitedBy,
+			CreatedAt:     invitation.CreatedAt.Time,
+		}
+	}
+
+	events, err := m.q.ListAllAuditEventsForUser(ctx, userID)
+	if err != nil {
+		return DataExport{}, fmt.Errorf("listing security events: %v", err)
+	}
+
+	data.SecurityLog = make([]SecurityEventData, len(events))
+	for i, row := range events {
+		event, err := makeAuditEvent(row.ID, row.ActorID, row.ActorEmail, row.EntityType, row.EntityID, row.Action, row.Changes, row.CreatedAt)
+		if err != nil {
+			return DataExport{}, err
+		}
+
+		data.SecurityLog[i] = SecurityEventData{
+			ID:         event.ID,
+			ActorEmail: event.ActorEmail,
+			Action:     event.Action,
+			Changes:    event.Changes,
+			CreatedAt:  event.CreatedAt,
+		}
+	}
+
+	return data, nil
+}
+
+// Delete permanently removes a user after confirming their password. Households the user is the
+// last member of are deleted with them. In households where they are the only owner, another
+// member is made an owner so the household can still be managed. Pending invitations to the user's
+// email are deleted, and everything else owned by the user is removed by the database through
+// cascading deletes.
+func (m *UserModel) Delete(ctx context.Context, userID uuid.UUID, password string) (retErr error) {
+	user, err := m.q.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("retrieving user %s: %v", userID.String(), err)
+	}
+
+	passwordMatches, err := m.hasher.ComparePasswordAndHash(password, user.PasswordHash)
+	if err != nil {
+		return fmt.Errorf("comparing password to hash: %v", err)
+	}
+
+	if !passwordMatches {
+		return ErrInvalidCredentials
+	}
+
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("starting transaction: %v", err)
+	}
+
+	defer func() {
+		if txErr := tx.Rollback(ctx); txErr != nil && !errors.Is(txErr, pgx.ErrTxClosed) {
+			retErr = errors.Join(retErr, txErr)
+		}
+	}()
+
+	txQueries := m.q.WithTx(tx)
+
+	promoted, err := txQueries.PromoteSuccessorOwners(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("promoting successor owners: %v", err)
+	}
+
+	for _, member := range promoted {
+		entry := auditEntry{
+			actorID:     userID,
+			householdID: member.HouseholdID,
+			entityType:  AuditEntityHousehold,
+			entityID:    member.HouseholdID,
+			action:      AuditActionMemberPromoted,
+			changes: map[string]FieldChange{
+				"member_id": {New: member.UserID.String()},
+				"role":      {Old: member.PreviousRole, New: string(HouseholdRoleOwner)},
+			},
+		}
+		if err := recordAuditEvent(ctx, txQueries, entry); err != nil {
+			return err
+		}
+	}
+
+	if err := txQueries.DeleteHouseholdsWithOnlyMember(ctx, userID); err != nil {
+		return fmt.Errorf("deleting households with only member %s: %v", userID.String(), err)
+	}
+
+	// Invitations sent by the user are deleted with them, but invitations sent to them are only
+	// linked by their email address.
+	if err := txQueries.DeleteHouseholdInvitationsForEmail(ctx, user.Email); err != nil {
+		return fmt.Errorf("deleting household invitations for user %s: %v", userID.String(), err)
+	}
+
+	if err := txQueries.DeleteUserByID(ctx, userID); err != nil {
+		return fmt.Errorf("deleting user %s: %v", userID.String(), err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("committing transaction: %v", err)
+	}
+
+	m.logger.InfoContext(ctx, "Deleted user.", "userID", userID, "promotedOwners", len(promoted))
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -198,19 +199,41 @@ func (g *ConstantTokenGenerator) Generate() string {
 }
 
 type MockEmailVerifier struct {
+	dataExportEmail string
+	dataExportToken string
+	dataExportError error
+
 	duplicateRegistrationEmail string
 	duplicateRegistrationError error
 
+	loginLinkEmail string
+	loginLinkToken string
+	loginLinkError error
+
 	newEmailEmail string
 	newEmailToken string
 	newEmailError error
 }
 
+func (v *MockEmailVerifier) DataExport(ctx context.Context, email string, token string) error {
+	v.dataExportEmail = email
+	v.dataExportToken = token
+
+	return v.dataExportError
+}
+
 func (v *MockEmailVerifier) DuplicateRegistration(ctx context.Context, email string) error {
 	v.duplicateRegistrationEmail = email
 	return v.duplicateRegistrationError
 }
 
+func (v *MockEmailVerifier) LoginLink(ctx context.Context, email string, token string) error {
+	v.loginLinkEmail = email
+	v.loginLinkToken = token
+
+	return v.loginLinkError
+}
+
 func (v *MockEmailVerifier) NewEmail(ctx context.Context, email string, token string) error {
 	v.newEmailEmail = email
 	v.newEmailToken = token
@@ -219,27 +242,87 @@ func (v *MockEmailVerifier) NewEmail(ctx context.Context, email string, token st
 }
 
 type MockUserQueries struct {
+	MockAuditEvents
+
 	deletedEmailVerificationID          int32
 	deleteEmailVerificationKeyByIDError error
 
+	deletedHouseholdInvitationsEmail        string
+	deleteHouseholdInvitationsForEmailError error
+
+	deletedHouseholdsOnlyMemberID       uuid.UUID
+	deleteHouseholdsWithOnlyMemberError error
+
+	deletedLoginLinkToken        string
+	deleteLoginLinkByTokenReturn queries.LoginLink
+	deleteLoginLinkByTokenError  error
+
 	deleteUnverifiedEmailsEmail string
 	deleteUnverifiedEmailsError error
 
+	deletedUserID       uuid.UUID
+	deleteUserByIDError error
+
+	getDataExportByTokenToken  string
+	getDataExportByTokenReturn queries.DataExport
+	getDataExportByTokenError  error
+
 	getEmailVerificationKeyByTokenToken  string
 	getEmailVerificationKeyByTokenReturn queries.EmailVerificationKey
 	getEmailVerificationKeyByTokenError  error
 
+	gotUserByExternalIdentity      queries.GetUserByExternalIdentityParams
+	getUserByExternalIdentityUser  queries.User
+	getUserByExternalIdentityError error
+
+	gotUserByID      uuid.UUID
+	getUserByIDUser  queries.User
+	getUserByIDError error
+
 	gotUserByVerifiedEmail      string
 	getUserByVerifiedEmailUser  queries.User
 	getUserByVerifiedEmailError error
 
+	insertDataExportParams queries.InsertDataExportParams
+	insertDataExportError  error
+
 	insertEmailVerificationKeyError error
 	insertEmailVerificationParams   queries.InsertEmailVerificationKeyParams
 
+	insertExternalIdentityParams queries.InsertExternalIdentityParams
+	insertExternalIdentityError  error
+
+	insertLoginLinkParams queries.InsertLoginLinkParams
+	insertLoginLinkError  error
+
 	insertNewUserReturnUser  queries.User
 	insertNewUserReturnError error
 	insertNewUserParams      queries.InsertNewUserParams
 
+	listedAllAuditEventsForUserID   uuid.UUID
+	listAllAuditEventsForUserReturn []queries.ListAllAuditEventsForUserRow
+	listAllAuditEventsForUserError  error
+
+	listedAPITokensForUserID   uuid.UUID
+	listAPITokensForUserReturn []queries.APIToken
+	listAPITokensForUserError  error
+
+	listedExternalIdentitiesForUserID   uuid.UUID
+	listExternalIdentitiesForUserReturn []queries.ExternalIdentity
+	listExternalIdentitiesForUserError  error
+
+	listHouseholdInvitationsForUserParams queries.ListHouseholdInvitationsForUserParams
+	listHouseholdInvitationsForUserReturn []queries.ListHouseholdInvitationsForUserRow
+	listHouseholdInvitationsForUserError  error
+
+	listedHouseholdMembershipsForUserID   uuid.UUID
+	listHouseholdMembershipsForUserReturn []queries.ListHouseholdMembershipsForUserRow
+	listHouseholdMembershipsForUserError  error
+
+	promotedSuccessorsForUserID  uuid.UUID
+	promoteSuccessorOwnersReturn []queries.PromoteSuccessorOwnersRow
+	promoteSuccessorOwnersError  error
+
 	verifiedEmailExistsEmail  string
 	verifiedEmailExistsReturn bool
 	verifiedEmailExistsError  error
@@ -258,36 +341,132 @@ func (q *MockUserQueries) DeleteEmailVerificationKeyByID(ctx context.Context, id
 	return q.deleteEmailVerificationKeyByIDError
 }
 
+func (q *MockUserQueries) DeleteHouseholdInvitationsForEmail(ctx context.Context, email string) error {
+	q.deletedHouseholdInvitationsEmail = email
+
+	return q.deleteHouseholdInvitationsForEmailError
+}
+
+func (q *MockUserQueries) DeleteHouseholdsWithOnlyMember(ctx context.Context, userID uuid.UUID) error {
+	q.deletedHouseholdsOnlyMemberID = userID
+
+	return q.deleteHouseholdsWithOnlyMemberError
+}
+
+func (q *MockUserQueries) DeleteLoginLinkByToken(ctx context.Context, token string) (queries.LoginLink, error) {
+	q.deletedLoginLinkToken = token
+
+	return q.deleteLoginLinkByTokenReturn, q.deleteLoginLinkByTokenError
+}
+
 func (q *MockUserQueries) DeleteUnverifiedEmails(ctx context.Context, email string) error {
 	q.deleteUnverifiedEmailsEmail = email
 
 	return q.deleteUnverifiedEmailsError
 }
 
+func (q *MockUserQueries) DeleteUserByID(ctx context.Context, id uuid.UUID) error {
+	q.deletedUserID = id
+
+	return q.deleteUserByIDError
+}
+
+func (q *MockUserQueries) GetDataExportByToken(ctx context.Context, token string) (queries.DataExport, error) {
+	q.getDataExportByTokenToken = token
+
+	return q.getDataExportByTokenReturn, q.getDataExportByTokenError
+}
+
 func (q *MockUserQueries) GetEmailVerificationKeyByToken(ctx context.Context, token string) (queries.EmailVerificationKey, error) {
 	q.getEmailVerificationKeyByTokenToken = token
 
 	return q.getEmailVerificationKeyByTokenReturn, q.getEmailVerificationKeyByTokenError
 }
 
+func (q *MockUserQueries) GetUserByExternalIdentity(ctx context.Context, params queries.GetUserByExternalIdentityParams) (queries.User, error) {
+	q.gotUserByExternalIdentity = params
+
+	return q.getUserByExternalIdentityUser, q.getUserByExternalIdentityError
+}
+
+func (q *MockUserQueries) GetUserByID(ctx context.Context, id uuid.UUID) (queries.User, error) {
+	q.gotUserByID = id
+
+	return q.getUserByIDUser, q.getUserByIDError
+}
+
 func (q *MockUserQueries) GetUserByVerifiedEmail(ctx context.Context, email string) (queries.User, error) {
 	q.gotUserByVerifiedEmail = email
 
 	return q.getUserByVerifiedEmailUser, q.getUserByVerifiedEmailError
 }
 
+func (q *MockUserQueries) InsertDataExport(ctx context.Context, params queries.InsertDataExportParams) error {
+	q.insertDataExportParams = params
+
+	return q.insertDataExportError
+}
+
 func (q *MockUserQueries) InsertEmailVerificationKey(ctx context.Context, params queries.InsertEmailVerificationKeyParams) error {
 	q.insertEmailVerificationParams = params
 
 	return q.insertEmailVerificationKeyError
 }
 
+func (q *MockUserQueries) InsertExternalIdentity(ctx context.Context, params queries.InsertExternalIdentityParams) error {
+	q.insertExternalIdentityParams = params
+
+	return q.insertExternalIdentityError
+}
+
+func (q *MockUserQueries) InsertLoginLink(ctx context.Context, params queries.InsertLoginLinkParams) error {
+	q.insertLoginLinkParams = params
+
+	return q.insertLoginLinkError
+}
+
 func (q *MockUserQueries) InsertNewUser(ctx context.Context, params queries.InsertNewUserParams) (queries.User, error) {
 	q.insertNewUserParams = params
 
 	return q.insertNewUserReturnUser, q.insertNewUserReturnError
 }
 
+func (q *MockUserQueries) ListAllAuditEventsForUser(ctx context.Context, userID uuid.UUID) ([]queries.ListAllAuditEventsForUserRow, error) {
+	q.listedAllAuditEventsForUserID = userID
+
+	return q.listAllAuditEventsForUserReturn, q.listAllAuditEventsForUserError
+}
+
+func (q *MockUserQueries) ListAPITokensForUser(ctx context.Context, userID uuid.UUID) ([]queries.APIToken, error) {
+	q.listedAPITokensForUserID = userID
+
+	return q.listAPITokensForUserReturn, q.listAPITokensForUserError
+}
+
+func (q *MockUserQueries) ListExternalIdentitiesForUser(ctx context.Context, userID uuid.UUID) ([]queries.ExternalIdentity, error) {
+	q.listedExternalIdentitiesForUserID = userID
+
+	return q.listExternalIdentitiesForUserReturn, q.listExternalIdentitiesForUserError
+}
+
+func (q *MockUserQueries) ListHouseholdInvitationsForUser(ctx context.Context, params queries.ListHouseholdInvitationsForUserParams) ([]queries.ListHouseholdInvitationsForUserRow, error) {
+	q.listHouseholdInvitationsForUserParams = params
+
+	return q.listHouseholdInvitationsForUserReturn, q.listHouseholdInvitationsForUserError
+}
+
+func (q *MockUserQueries) ListHouseholdMembershipsForUser(ctx context.Context, userID uuid.UUID) ([]queries.ListHouseholdMembershipsForUserRow, error) {
+	q.listedHouseholdMembershipsForUserID = userID
+
+	return q.listHouseholdMembershipsForUserReturn, q.listHouseholdMembershipsForUserError
+}
+
+func (q *MockUserQueries) PromoteSuccessorOwners(ctx context.Context, userID uuid.UUID) ([]queries.PromoteSuccessorOwnersRow, error) {
+	q.promotedSuccessorsForUserID = userID
+
+	return q.promoteSuccessorOwnersReturn, q.promoteSuccessorOwnersError
+}
+
 func (q *MockUserQueries) VerifiedEmailExists(ctx context.Context, email string) (bool, error) {
 	q.verifiedEmailExistsEmail = email
 
@@ -315,6 +494,7 @@ func TestUserModel_Authenticate(t *testing.T) {
 		wantComparedPassword   string
 		wantComparedHash       string
 		wantUser               models.User
+		wantAudit              bool
 		wantErr                bool
 		wantInvalidCredentials bool
 	}{
@@ -325,6 +505,24 @@ func TestUserModel_Authenticate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "error recording audit event",
+			queries: MockUserQueries{
+				MockAuditEvents: MockAuditEvents{insertAuditError: genericDBError},
+				getUserByVerifiedEmailUser: queries.User{
+					ID:           defaultUserID,
+					PasswordHash: "password",
+				},
+			},
+			email:                  "exists@example.com",
+			password:               "password",
+			wantEmail:              "exists@example.com",
+			wantPasswordComparison: true,
+			wantComparedPassword:   "password",
+			wantComparedHash:       "password",
+			wantAudit:              true,
+			wantErr:                true,
+		},
 		{
 			name: "email not found",
 			queries: MockUserQueries{
@@ -384,6 +582,7 @@ func TestUserModel_Authenticate(t *testing.T) {
 			wantComparedPassword:   "password",
 			wantComparedHash:       "password",
 			wantUser:               models.User{ID: defaultUserID},
+			wantAudit:              true,
 		},
 		{
 			name: "valid credentials trimmed",
@@ -400,6 +599,7 @@ func TestUserModel_Authenticate(t *testing.T) {
 			wantComparedPassword:   " not trimmed ",
 			wantComparedHash:       " not trimmed ",
 			wantUser:               models.User{ID: defaultUserID},
+			wantAudit:              true,
 		},
 	}
 
@@ -411,6 +611,8 @@ func TestUserModel_Authenticate(t *testing.T) {
 				&tt.hasher,
 				&ConstantTokenGenerator{},
 				time.Minute,
+				time.Minute,
+				time.Minute,
 				&MockDB{},
 				&tt.queries,
 			)
@@ -453,6 +655,19 @@ func TestUserModel_Authenticate(t *testing.T) {
 			}
 
 			assertUsersEqual(t, tt.wantUser, user)
+
+			var wantEvents []wantAuditEvent
+			if tt.wantAudit {
+				wantEvents = append(wantEvents, wantAuditEvent{
+					actorID:    defaultUserID,
+					userID:     defaultUserID,
+					entityType: models.AuditEntityUser,
+					entityID:   defaultUserID,
+					action:     models.AuditActionLoggedInWithPassword,
+				})
+			}
+
+			assertAuditEvents(t, wantEvents, tt.queries.insertedAuditEvents)
 		})
 	}
 }
@@ -653,6 +868,8 @@ func TestUserModel_Register(t *testing.T) {
 				&tt.hasher,
 				&tt.tokenGenerator,
 				time.Minute,
+				time.Minute,
+				time.Minute,
 				&tt.db,
 				&tt.queries,
 			)
@@ -718,6 +935,21 @@ func TestUserModel_Register(t *testing.T) {
 			if got := tt.emailVerifier.newEmailToken; got != tt.wantNewEmailToken {
 				t.Errorf("Expected email verification token %q, got %q", tt.wantNewEmailToken, got)
 			}
+
+			var wantEvents []wantAuditEvent
+			if tt.wantInsertedEmailVerificationKey.Token != "" {
+				userID := tt.queries.insertNewUserParams.ID
+				wantEvents = append(wantEvents, wantAuditEvent{
+					actorID:    userID,
+					userID:     userID,
+					entityType: models.AuditEntityUser,
+					entityID:   userID,
+					action:     models.AuditActionCreated,
+					changes:    map[string]models.FieldChange{"email": {New: tt.newUser.Email}},
+				})
+			}
+
+			assertAuditEvents(t, wantEvents, tt.queries.insertedAuditEvents)
 		})
 	}
 }
@@ -907,6 +1139,8 @@ func TestUserModel_VerifyEmail(t *testing.T) {
 				&ConstantHasher{},
 				&ConstantTokenGenerator{},
 				tt.tokenLifetime,
+				time.Minute,
+				time.Minute,
 				&tt.db,
 				&tt.queries,
 			)
@@ -956,6 +1190,1302 @@ func TestUserModel_VerifyEmail(t *testing.T) {
 	}
 }
 
+func TestUserModel_RequestDataExport(t *testing.T) {
+	genericDBError := errors.New("generic DB error")
+	defaultUserID := uuid.New()
+
+	testCases := []struct {
+		name              string
+		db                MockDB
+		tx                MockTX
+		emailVerifier     MockEmailVerifier
+		queries           MockUserQueries
+		userID            uuid.UUID
+		wantInsertedToken string
+		wantExportEmail   string
+		wantAudit         bool
+		wantTxCommit      bool
+		wantErr           bool
+	}{
+		{
+			name: "error retrieving user",
+			queries: MockUserQueries{
+				getUserByIDError: genericDBError,
+			},
+			userID:  defaultUserID,
+			wantErr: true,
+		},
+		{
+			name: "error starting transaction",
+			db:   MockDB{beginError: genericDBError},
+			queries: MockUserQueries{
+				getUserByIDUser: queries.User{ID: defaultUserID, Email: "test@example.com"},
+			},
+			userID:  defaultUserID,
+			wantErr: true,
+		},
+		{
+			name: "error inserting export",
+			queries: MockUserQueries{
+				getUserByIDUser:       queries.User{ID: defaultUserID, Email: "test@example.com"},
+				insertDataExportError: genericDBError,
+			},
+			userID:            defaultUserID,
+			wantInsertedToken: mockToken,
+			wantErr:           true,
+		},
+		{
+			name: "error recording audit event",
+			queries: MockUserQueries{
+				MockAuditEvents: MockAuditEvents{insertAuditError: genericDBError},
+				getUserByIDUser: queries.User{ID: defaultUserID, Email: "test@example.com"},
+			},
+			userID:            defaultUserID,
+			wantInsertedToken: mockToken,
+			wantAudit:         true,
+			wantErr:           true,
+		},
+		{
+			name: "error sending export link",
+			emailVerifier: MockEmailVerifier{
+				dataExportError: errors.New("send failed"),
+			},
+			queries: MockUserQueries{
+				getUserByIDUser: queries.User{ID: defaultUserID, Email: "test@example.com"},
+			},
+			userID:            defaultUserID,
+			wantInsertedToken: mockToken,
+			wantExportEmail:   "test@example.com",
+			wantAudit:         true,
+			wantErr:           true,
+		},
+		{
+			name: "error committing",
+			tx:   MockTX{commitError: genericDBError},
+			queries: MockUserQueries{
+				getUserByIDUser: queries.User{ID: defaultUserID, Email: "test@example.com"},
+			},
+			userID:            defaultUserID,
+			wantInsertedToken: mockToken,
+			wantExportEmail:   "test@example.com",
+			wantAudit:         true,
+			wantErr:           true,
+		},
+		{
+			name: "success",
+			queries: MockUserQueries{
+				getUserByIDUser: queries.User{ID: defaultUserID, Email: "test@example.com"},
+			},
+			userID:            defaultUserID,
+			wantInsertedToken: mockToken,
+			wantExportEmail:   "test@example.com",
+			wantAudit:         true,
+			wantTxCommit:      true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.db.txFactory == nil {
+				tt.db.txFactory = func() models.Transaction { return &tt.tx }
+			}
+
+			users := models.NewUserModel(
+				slog.New(slog.DiscardHandler),
+				&tt.emailVerifier,
+				&ConstantHasher{},
+				&ConstantTokenGenerator{token: mockToken},
+				time.Minute,
+				time.Minute,
+				time.Minute,
+				&tt.db,
+				&tt.queries,
+			)
+
+			err := users.RequestDataExport(t.Context(), tt.userID)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error presence %v, got error %#v", tt.wantErr, err)
+			}
+
+			if got := tt.queries.gotUserByID; got != tt.userID {
+				t.Errorf("Expected query for user %v, got %v", tt.userID, got)
+			}
+
+			if got := tt.queries.insertDataExportParams.Token; got != tt.wantInsertedToken {
+				t.Errorf("Expected inserted export token %q, got %q", tt.wantInsertedToken, got)
+			}
+
+			if got := tt.queries.insertDataExportParams.UserID; tt.wantInsertedToken != "" && got != tt.userID {
+				t.Errorf("Expected export for user %v, got %v", tt.userID, got)
+			}
+
+			if got := tt.emailVerifier.dataExportEmail; got != tt.wantExportEmail {
+				t.Errorf("Expected export link sent to %q, got %q", tt.wantExportEmail, got)
+			}
+
+			if tt.wantExportEmail != "" && tt.emailVerifier.dataExportToken != tt.wantInsertedToken {
+				t.Errorf("Expected export link token %q, got %q", tt.wantInsertedToken, tt.emailVerifier.dataExportToken)
+			}
+
+			if tt.tx.committed != tt.wantTxCommit {
+				t.Errorf("Expected transaction commit %v, got %v", tt.wantTxCommit, tt.tx.committed)
+			}
+
+			var wantEvents []wantAuditEvent
+			if tt.wantAudit {
+				wantEvents = append(wantEvents, wantAuditEvent{
+					actorID:    tt.userID,
+					userID:     tt.userID,
+					entityType: models.AuditEntityUser,
+					entityID:   tt.userID,
+					action:     models.AuditActionDataExportRequested,
+				})
+			}
+
+			assertAuditEvents(t, wantEvents, tt.queries.insertedAuditEvents)
+		})
+	}
+}
+
+func TestUserModel_RequestLoginLink(t *testing.T) {
+	genericDBError := errors.New("generic DB error")
+	defaultUserID := uuid.New()
+
+	testCases := []struct {
+		name              string
+		emailVerifier     MockEmailVerifier
+		queries           MockUserQueries
+		email             string
+		wantQueriedEmail  string
+		wantInsertedToken string
+		wantLinkEmail     string
+		wantErr           bool
+	}{
+		{
+			name: "unknown email",
+			queries: MockUserQueries{
+				getUserByVerifiedEmailError: pgx.ErrNoRows,
+			},
+			email:            "unknown@example.com",
+			wantQueriedEmail: "unknown@example.com",
+		},
+		{
+			name: "error retrieving user",
+			queries: MockUserQueries{
+				getUserByVerifiedEmailError: genericDBError,
+			},
+			email:            "test@example.com",
+			wantQueriedEmail: "test@example.com",
+			wantErr:          true,
+		},
+		{
+			name: "error inserting link",
+			queries: MockUserQueries{
+				getUserByVerifiedEmailUser: queries.User{ID: defaultUserID},
+				insertLoginLinkError:       genericDBError,
+			},
+			email:             "test@example.com",
+			wantQueriedEmail:  "test@example.com",
+			wantInsertedToken: mockToken,
+			wantErr:           true,
+		},
+		{
+			name: "error sending link",
+			emailVerifier: MockEmailVerifier{
+				loginLinkError: errors.New("send failed"),
+			},
+			queries: MockUserQueries{
+				getUserByVerifiedEmailUser: queries.User{ID: defaultUserID},
+			},
+			email:             "test@example.com",
+			wantQueriedEmail:  "test@example.com",
+			wantInsertedToken: mockToken,
+			wantLinkEmail:     "test@example.com",
+			wantErr:           true,
+		},
+		{
+			name: "success",
+			queries: MockUserQueries{
+				getUserByVerifiedEmailUser: queries.User{ID: defaultUserID},
+			},
+			email:             "  test@example.com ",
+			wantQueriedEmail:  "test@example.com",
+			wantInsertedToken: mockToken,
+			wantLinkEmail:     "test@example.com",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			users := models.NewUserModel(
+				slog.New(slog.DiscardHandler),
+				&tt.emailVerifier,
+				&ConstantHasher{},
+				&ConstantTokenGenerator{token: mockToken},
+				time.Minute,
+				time.Minute,
+				time.Minute,
+				&MockDB{},
+				&tt.queries,
+			)
+
+			err := users.RequestLoginLink(t.Context(), tt.email)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error presence %v, got error %#v", tt.wantErr, err)
+			}
+
+			if got := tt.queries.gotUserByVerifiedEmail; got != tt.wantQueriedEmail {
+				t.Errorf("Expected query for email %q, got %q", tt.wantQueriedEmail, got)
+			}
+
+			if got := tt.queries.insertLoginLinkParams.Token; got != tt.wantInsertedToken {
+				t.Errorf("Expected inserted login token %q, got %q", tt.wantInsertedToken, got)
+			}
+
+			if got := tt.queries.insertLoginLinkParams.UserID; tt.wantInsertedToken != "" && got != defaultUserID {
+				t.Errorf("Expected login link for user %v, got %v", defaultUserID, got)
+			}
+
+			if got := tt.emailVerifier.loginLinkEmail; got != tt.wantLinkEmail {
+				t.Errorf("Expected login link sent to %q, got %q", tt.wantLinkEmail, got)
+			}
+
+			if tt.wantLinkEmail != "" && tt.emailVerifier.loginLinkToken != tt.wantInsertedToken {
+				t.Errorf("Expected login link token %q, got %q", tt.wantInsertedToken, tt.emailVerifier.loginLinkToken)
+			}
+		})
+	}
+}
+
+func TestUserModel_AuthenticateLoginLink(t *testing.T) {
+	genericDBError := errors.New("generic DB error")
+	defaultUserID := uuid.New()
+
+	testCases := []struct {
+		name         string
+		db           MockDB
+		tx           MockTX
+		queries      MockUserQueries
+		token        string
+		wantConsumed bool
+		wantAudit    bool
+		wantUser     models.User
+		wantTxCommit bool
+		wantErr      bool
+		wantErrors   []error
+	}{
+		{
+			name:       "error starting transaction",
+			db:         MockDB{beginError: genericDBError},
+			token:      "valid",
+			wantErr:    true,
+			wantErrors: []error{genericDBError},
+		},
+		{
+			name: "missing token",
+			queries: MockUserQueries{
+				deleteLoginLinkByTokenError: pgx.ErrNoRows,
+			},
+			token:        "missing",
+			wantConsumed: true,
+			wantErr:      true,
+			wantErrors:   []error{models.ErrInvalidLoginLink},
+		},
+		{
+			name: "error consuming token",
+			queries: MockUserQueries{
+				deleteLoginLinkByTokenError: genericDBError,
+			},
+			token:        "causes-error",
+			wantConsumed: true,
+			wantErr:      true,
+			wantErrors:   []error{genericDBError},
+		},
+		{
+			name: "expired token",
+			queries: MockUserQueries{
+				deleteLoginLinkByTokenReturn: queries.LoginLink{
+					UserID:    defaultUserID,
+					CreatedAt: pgtype.Timestamptz{Time: time.Now().Add(-2 * time.Minute)},
+				},
+			},
+			token:        "expired",
+			wantConsumed: true,
+			wantTxCommit: true,
+			wantErr:      true,
+			wantErrors:   []error{models.ErrInvalidLoginLink},
+		},
+		{
+			name: "error recording audit event",
+			queries: MockUserQueries{
+				MockAuditEvents: MockAuditEvents{insertAuditError: genericDBError},
+				deleteLoginLinkByTokenReturn: queries.LoginLink{
+					UserID:    defaultUserID,
+					CreatedAt: pgtype.Timestamptz{Time: time.Now()},
+				},
+			},
+			token:        "valid",
+			wantConsumed: true,
+			wantAudit:    true,
+			wantErr:      true,
+			wantErrors:   []error{genericDBError},
+		},
+		{
+			name: "error committing",
+			tx:   MockTX{commitError: genericDBError},
+			queries: MockUserQueries{
+				deleteLoginLinkByTokenReturn: queries.LoginLink{
+					UserID:    defaultUserID,
+					CreatedAt: pgtype.Timestamptz{Time: time.Now()},
+				},
+			},
+			token:        "valid",
+			wantConsumed: true,
+			wantAudit:    true,
+			wantErr:      true,
+			wantErrors:   []error{genericDBError},
+		},
+		{
+			name: "success",
+			queries: MockUserQueries{
+				deleteLoginLinkByTokenReturn: queries.LoginLink{
+					UserID:    defaultUserID,
+					CreatedAt: pgtype.Timestamptz{Time: time.Now()},
+				},
+			},
+			token:        "valid",
+			wantConsumed: true,
+			wantAudit:    true,
+			wantUser:     models.User{ID: defaultUserID},
+			wantTxCommit: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.db.txFactory == nil {
+				tt.db.txFactory = func() models.Transaction { return &tt.tx }
+			}
+
+			users := models.NewUserModel(
+				slog.New(slog.DiscardHandler),
+				&MockEmailVerifier{},
+				&ConstantHasher{},
+				&ConstantTokenGenerator{},
+				time.Minute,
+				time.Minute,
+				time.Minute,
+				&tt.db,
+				&tt.queries,
+			)
+
+			user, err := users.AuthenticateLoginLink(t.Context(), tt.token)
+
+			if err == nil && tt.wantErr {
+				t.Fatal("Expected AuthenticateLoginLink to error.")
+			}
+
+			if err != nil && !tt.wantErr {
+				t.Fatalf("AuthenticateLoginLink returned an error: %#v", err)
+			}
+
+			for _, wantErr := range tt.wantErrors {
+				if !strings.Contains(err.Error(), wantErr.Error()) {
+					t.Errorf("Expected error to include %q, got %q", wantErr.Error(), err.Error())
+				}
+			}
+
+			var wantConsumedToken string
+			if tt.wantConsumed {
+				wantConsumedToken = tt.token
+			}
+
+			if got := tt.queries.deletedLoginLinkToken; got != wantConsumedToken {
+				t.Errorf("Expected login link %q to be consumed, got %q", wantConsumedToken, got)
+			}
+
+			if user != tt.wantUser {
+				t.Errorf("Expected user %v, got %v", tt.wantUser, user)
+			}
+
+			if tt.tx.committed != tt.wantTxCommit {
+				t.Errorf("Expected transaction commit %v, got %v", tt.wantTxCommit, tt.tx.committed)
+			}
+
+			var wantEvents []wantAuditEvent
+			if tt.wantAudit {
+				wantEvents = append(wantEvents, wantAuditEvent{
+					actorID:    defaultUserID,
+					userID:     defaultUserID,
+					entityType: models.AuditEntityUser,
+					entityID:   defaultUserID,
+					action:     models.AuditActionLoggedInWithLink,
+				})
+			}
+
+			assertAuditEvents(t, wantEvents, tt.queries.insertedAuditEvents)
+		})
+	}
+}
+
+func TestUserModel_AuthenticateExternalIdentity(t *testing.T) {
+	genericDBError := errors.New("generic DB error")
+	defaultUserID := uuid.New()
+	identity := models.ExternalIdentity{
+		Issuer:        "https://id.example.com",
+		Provider:      "corp",
+		Subject:       "subject",
+		Email:         "test@example.com",
+		EmailVerified: true,
+	}
+
+	testCases := []struct {
+		name             string
+		db               MockDB
+		tx               MockTX
+		queries          MockUserQueries
+		identity         models.ExternalIdentity
+		wantQueriedEmail string
+		wantLinked       bool
+		wantLinkAudit    bool
+		wantLoginAudit   bool
+		wantTxCommit     bool
+		wantUser         models.User
+		wantErr          bool
+		wantErrors       []error
+	}{
+		{
+			name: "already linked",
+			queries: MockUserQueries{
+				getUserByExternalIdentityUser: queries.User{ID: defaultUserID},
+			},
+			identity:       identity,
+			wantLoginAudit: true,
+			wantUser:       models.User{ID: defaultUserID},
+		},
+		{
+			name: "error retrieving linked user",
+			queries: MockUserQueries{
+				getUserByExternalIdentityError: genericDBError,
+			},
+			identity:   identity,
+			wantErr:    true,
+			wantErrors: []error{genericDBError},
+		},
+		{
+			name: "unverified external email",
+			queries: MockUserQueries{
+				getUserByExternalIdentityError: pgx.ErrNoRows,
+			},
+			identity: models.ExternalIdentity{
+				Issuer:   "https://id.example.com",
+				Provider: "corp",
+				Subject:  "subject",
+				Email:    "test@example.com",
+			},
+			wantErr:    true,
+			wantErrors: []error{models.ErrExternalIdentityNotLinked},
+		},
+		{
+			name: "no user with email",
+			queries: MockUserQueries{
+				getUserByExternalIdentityError: pgx.ErrNoRows,
+				getUserByVerifiedEmailError:    pgx.ErrNoRows,
+			},
+			identity:         identity,
+			wantQueriedEmail: "test@example.com",
+			wantErr:          true,
+			wantErrors:       []error{models.ErrExternalIdentityNotLinked},
+		},
+		{
+			name: "error retrieving user by email",
+			queries: MockUserQueries{
+				getUserByExternalIdentityError: pgx.ErrNoRows,
+				getUserByVerifiedEmailError:    genericDBError,
+			},
+			identity:         identity,
+			wantQueriedEmail: "test@example.com",
+			wantErr:          true,
+			wantErrors:       []error{genericDBError},
+		},
+		{
+			name: "error starting transaction",
+			db:   MockDB{beginError: genericDBError},
+			queries: MockUserQueries{
+				getUserByExternalIdentityError: pgx.ErrNoRows,
+				getUserByVerifiedEmailUser:     queries.User{ID: defaultUserID},
+			},
+			identity:         identity,
+			wantQueriedEmail: "test@example.com",
+			wantErr:          true,
+			wantErrors:       []error{genericDBError},
+		},
+		{
+			name: "error linking identity",
+			queries: MockUserQueries{
+				getUserByExternalIdentityError: pgx.ErrNoRows,
+				getUserByVerifiedEmailUser:     queries.User{ID: defaultUserID},
+				insertExternalIdentityError:    genericDBError,
+			},
+			identity:         identity,
+			wantQueriedEmail: "test@example.com",
+			wantLinked:       true,
+			wantErr:          true,
+			wantErrors:       []error{genericDBError},
+		},
+		{
+			name: "error recording audit event",
+			queries: MockUserQueries{
+				MockAuditEvents:                MockAuditEvents{insertAuditError: genericDBError},
+				getUserByExternalIdentityError: pgx.ErrNoRows,
+				getUserByVerifiedEmailUser:     queries.User{ID: defaultUserID},
+			},
+			identity:         identity,
+			wantQueriedEmail: "test@example.com",
+			wantLinked:       true,
+			wantLinkAudit:    true,
+			wantErr:          true,
+			wantErrors:       []error{genericDBError},
+		},
+		{
+			name: "error committing",
+			tx:   MockTX{commitError: genericDBError},
+			queries: MockUserQueries{
+				getUserByExternalIdentityError: pgx.ErrNoRows,
+				getUserByVerifiedEmailUser:     queries.User{ID: defaultUserID},
+			},
+			identity:         identity,
+			wantQueriedEmail: "test@example.com",
+			wantLinked:       true,
+			wantLinkAudit:    true,
+			wantLoginAudit:   true,
+			wantErr:          true,
+			wantErrors:       []error{genericDBError},
+		},
+		{
+			name: "links by verified email",
+			queries: MockUserQueries{
+				getUserByExternalIdentityError: pgx.ErrNoRows,
+				getUserByVerifiedEmailUser:     queries.User{ID: defaultUserID},
+			},
+			identity:         identity,
+			wantQueriedEmail: "test@example.com",
+			wantLinked:       true,
+			wantLinkAudit:    true,
+			wantLoginAudit:   true,
+			wantTxCommit:     true,
+			wantUser:         models.User{ID: defaultUserID},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.db.txFactory == nil {
+				tt.db.txFactory = func() models.Transaction { return &tt.tx }
+			}
+
+			users := models.NewUserModel(
+				slog.New(slog.DiscardHandler),
+				&MockEmailVerifier{},
+				&ConstantHasher{},
+				&ConstantTokenGenerator{},
+				time.Minute,
+				time.Minute,
+				time.Minute,
+				&tt.db,
+				&tt.queries,
+			)
+
+			user, err := users.AuthenticateExternalIdentity(t.Context(), tt.identity)
+
+			if err == nil && tt.wantErr {
+				t.Fatal("Expected AuthenticateExternalIdentity to error.")
+			}
+
+			if err != nil && !tt.wantErr {
+				t.Fatalf("AuthenticateExternalIdentity returned an error: %#v", err)
+			}
+
+			for _, wantErr := range tt.wantErrors {
+				if !strings.Contains(err.Error(), wantErr.Error()) {
+					t.Errorf("Expected error to include %q, got %q", wantErr.Error(), err.Error())
+				}
+			}
+
+			wantLookup := queries.GetUserByExternalIdentityParams{Issuer: tt.identity.Issuer, Subject: tt.identity.Subject}
+			if got := tt.queries.gotUserByExternalIdentity; got != wantLookup {
+				t.Errorf("Expected lookup of identity %v, got %v", wantLookup, got)
+			}
+
+			if got := tt.queries.gotUserByVerifiedEmail; got != tt.wantQueriedEmail {
+				t.Errorf("Expected query for email %q, got %q", tt.wantQueriedEmail, got)
+			}
+
+			wantLink := queries.InsertExternalIdentityParams{}
+			if tt.wantLinked {
+				wantLink = queries.InsertExternalIdentityParams{
+					UserID:   defaultUserID,
+					Issuer:   tt.identity.Issuer,
+					Provider: tt.identity.Provider,
+					Subject:  tt.identity.Subject,
+				}
+			}
+
+			if got := tt.queries.insertExternalIdentityParams; got != wantLink {
+				t.Errorf("Expected linked identity %v, got %v", wantLink, got)
+			}
+
+			if user != tt.wantUser {
+				t.Errorf("Expected user %v, got %v", tt.wantUser, user)
+			}
+
+			if tt.tx.committed != tt.wantTxCommit {
+				t.Errorf("Expected transaction commit %v, got %v", tt.wantTxCommit, tt.tx.committed)
+			}
+
+			var wantEvents []wantAuditEvent
+			if tt.wantLinkAudit {
+				wantEvents = append(wantEvents, wantAuditEvent{
+					actorID:    defaultUserID,
+					userID:     defaultUserID,
+					entityType: models.AuditEntityUser,
+					entityID:   defaultUserID,
+					action:     models.AuditActionExternalIdentityLinked,
+					changes: map[string]models.FieldChange{
+						"issuer":   {New: tt.identity.Issuer},
+						"provider": {New: tt.identity.Provider},
+					},
+				})
+			}
+
+			if tt.wantLoginAudit {
+				wantEvents = append(wantEvents, wantAuditEvent{
+					actorID:    defaultUserID,
+					userID:     defaultUserID,
+					entityType: models.AuditEntityUser,
+					entityID:   defaultUserID,
+					action:     models.AuditActionLoggedInWithOIDC,
+				})
+			}
+
+			assertAuditEvents(t, wantEvents, tt.queries.insertedAuditEvents)
+		})
+	}
+}
+
+func TestUserModel_GetDataExport(t *testing.T) {
+	genericDBError := errors.New("generic DB error")
+	defaultUserID := uuid.New()
+	householdID := uuid.New()
+	inviterID := uuid.New()
+	createdAt := time.Now().Add(-time.Hour)
+	timestamp := pgtype.Timestamptz{Time: createdAt, Valid: true}
+	validExport := queries.DataExport{
+		UserID:    defaultUserID,
+		CreatedAt: pgtype.Timestamptz{Time: time.Now()},
+	}
+	user := queries.User{
+		ID:              defaultUserID,
+		Email:           "test@example.com",
+		EmailVerifiedAt: timestamp,
+		PasswordHash:    "secret",
+		CreatedAt:       timestamp,
+		UpdatedAt:       timestamp,
+	}
+
+	testCases := []struct {
+		name           string
+		queries        MockUserQueries
+		exportLifetime time.Duration
+		userID         uuid.UUID
+		token          string
+		wantExport     models.DataExport
+		wantErr        bool
+		wantErrors     []error
+	}{
+		{
+			name: "missing token",
+			queries: MockUserQueries{
+				getDataExportByTokenError: pgx.ErrNoRows,
+			},
+			exportLifetime: time.Minute,
+			userID:         defaultUserID,
+			token:          "missing",
+			wantErr:        true,
+			wantErrors:     []error{models.ErrInvalidDataExportToken},
+		},
+		{
+			name: "error retrieving token",
+			queries: MockUserQueries{
+				getDataExportByTokenError: genericDBError,
+			},
+			exportLifetime: time.Minute,
+			userID:         defaultUserID,
+			token:          "causes-error",
+			wantErr:        true,
+			wantErrors:     []error{genericDBError},
+		},
+		{
+			name: "export owned by another user",
+			queries: MockUserQueries{
+				getDataExportByTokenReturn: queries.DataExport{
+					UserID:    uuid.New(),
+					CreatedAt: pgtype.Timestamptz{Time: time.Now()},
+				},
+			},
+			exportLifetime: time.Minute,
+			userID:         defaultUserID,
+			token:          "other-user",
+			wantErr:        true,
+			wantErrors:     []error{models.ErrInvalidDataExportToken},
+		},
+		{
+			name: "expired token",
+			queries: MockUserQueries{
+				getDataExportByTokenReturn: queries.DataExport{
+					UserID:    defaultUserID,
+					CreatedAt: pgtype.Timestamptz{Time: time.Now().Add(-2 * time.Minute)},
+				},
+			},
+			exportLifetime: time.Minute,
+			userID:         defaultUserID,
+			token:          "expired",
+			wantErr:        true,
+			wantErrors:     []error{models.ErrInvalidDataExportToken},
+		},
+		{
+			name: "error retrieving user",
+			queries: MockUserQueries{
+				getDataExportByTokenReturn: queries.DataExport{
+					UserID:    defaultUserID,
+					CreatedAt: pgtype.Timestamptz{Time: time.Now()},
+				},
+				getUserByIDError: genericDBError,
+			},
+			exportLifetime: time.Minute,
+			userID:         defaultUserID,
+			token:          "valid",
+			wantErr:        true,
+			wantErrors:     []error{genericDBError},
+		},
+		{
+			name: "error listing API tokens",
+			queries: MockUserQueries{
+				getDataExportByTokenReturn: validExport,
+				getUserByIDUser:            user,
+				listAPITokensForUserError:  genericDBError,
+			},
+			exportLifetime: time.Minute,
+			userID:         defaultUserID,
+			token:          "valid",
+			wantErr:        true,
+			wantErrors:     []error{genericDBError},
+		},
+		{
+			name: "error listing external identities",
+			queries: MockUserQueries{
+				getDataExportByTokenReturn:         validExport,
+				getUserByIDUser:                    user,
+				listExternalIdentitiesForUserError: genericDBError,
+			},
+			exportLifetime: time.Minute,
+			userID:         defaultUserID,
+			token:          "valid",
+			wantErr:        true,
+			wantErrors:     []error{genericDBError},
+		},
+		{
+			name: "error listing household memberships",
+			queries: MockUserQueries{
+				getDataExportByTokenReturn:           validExport,
+				getUserByIDUser:                      user,
+				listHouseholdMembershipsForUserError: genericDBError,
+			},
+			exportLifetime: time.Minute,
+			userID:         defaultUserID,
+			token:          "valid",
+			wantErr:        true,
+			wantErrors:     []error{genericDBError},
+		},
+		{
+			name: "error listing household invitations",
+			queries: MockUserQueries{
+				getDataExportByTokenReturn:           validExport,
+				getUserByIDUser:                      user,
+				listHouseholdInvitationsForUserError: genericDBError,
+			},
+			exportLifetime: time.Minute,
+			userID:         defaultUserID,
+			token:          "valid",
+			wantErr:        true,
+			wantErrors:     []error{genericDBError},
+		},
+		{
+			name: "error listing security events",
+			queries: MockUserQueries{
+				getDataExportByTokenReturn:     validExport,
+				getUserByIDUser:                user,
+				listAllAuditEventsForUserError: genericDBError,
+			},
+			exportLifetime: time.Minute,
+			userID:         defaultUserID,
+			token:          "valid",
+			wantErr:        true,
+			wantErrors:     []error{genericDBError},
+		},
+		{
+			name: "success",
+			queries: MockUserQueries{
+				getDataExportByTokenReturn: validExport,
+				getUserByIDUser:            user,
+				listAPITokensForUserReturn: []queries.APIToken{
+					{
+						ID:         1,
+						UserID:     defaultUserID,
+						Name:       "Reports",
+						TokenHash:  "hashed-token",
+						Scopes:     []string{models.ScopeItemsRead},
+						ExpiresAt:  timestamp,
+						LastUsedAt: timestamp,
+						CreatedAt:  timestamp,
+					},
+					{
+						ID:        2,
+						UserID:    defaultUserID,
+						Name:      "Unused",
+						TokenHash: "other-hashed-token",
+						Scopes:    []string{models.ScopeItemsRead},
+						ExpiresAt: timestamp,
+						CreatedAt: timestamp,
+					},
+				},
+				listExternalIdentitiesForUserReturn: []queries.ExternalIdentity{
+					{
+						ID:        3,
+						UserID:    defaultUserID,
+						Issuer:    "https://id.example.com",
+						Provider:  "corp",
+						Subject:   "subject",
+						CreatedAt: timestamp,
+					},
+				},
+				listHouseholdMembershipsForUserReturn: []queries.ListHouseholdMembershipsForUserRow{
+					{ID: householdID, Name: "Home", Role: "owner", CreatedAt: timestamp},
+				},
+				listHouseholdInvitationsForUserReturn: []queries.ListHouseholdInvitationsForUserRow{
+					{
+						ID:            4,
+						HouseholdID:   householdID,
+						HouseholdName: "Home",
+						Email:         "test@example.com",
+						Role:          "viewer",
+						InvitedBy:     inviterID,
+						CreatedAt:     timestamp,
+					},
+				},
+				listAllAuditEventsForUserReturn: []queries.ListAllAuditEventsForUserRow{
+					{
+						ID:         5,
+						ActorID:    uuid.NullUUID{UUID: defaultUserID, Valid: true},
+						ActorEmail: pgtype.Text{String: "test@example.com", Valid: true},
+						UserID:     uuid.NullUUID{UUID: defaultUserID, Valid: true},
+						EntityType: models.AuditEntityUser,
+						EntityID:   defaultUserID,
+						Action:     string(models.AuditActionAPITokenCreated),
+						Changes:    []byte(`{"name":{"new":"Reports"}}`),
+						CreatedAt:  timestamp,
+					},
+				},
+			},
+			exportLifetime: time.Minute,
+			userID:         defaultUserID,
+			token:          "valid",
+			wantExport: models.DataExport{
+				User: models.UserData{
+					ID:              defaultUserID,
+					Email:           "test@example.com",
+					EmailVerifiedAt: &createdAt,
+					CreatedAt:       createdAt,
+					UpdatedAt:       createdAt,
+				},
+				APITokens: []models.APITokenData{
+					{
+						ID:         1,
+						Name:       "Reports",
+						Scopes:     []string{models.ScopeItemsRead},
+						ExpiresAt:  createdAt,
+						LastUsedAt: &createdAt,
+						CreatedAt:  createdAt,
+					},
+					{
+						ID:        2,
+						Name:      "Unused",
+						Scopes:    []string{models.ScopeItemsRead},
+						ExpiresAt: createdAt,
+						CreatedAt: createdAt,
+					},
+				},
+				ExternalIdentities: []models.ExternalIdentityData{
+					{Issuer: "https://id.example.com", Provider: "corp", Subject: "subject", CreatedAt: createdAt},
+				},
+				HouseholdMemberships: []models.HouseholdMembershipData{
+					{HouseholdID: householdID, HouseholdName: "Home", Role: models.HouseholdRoleOwner, JoinedAt: createdAt},
+				},
+				HouseholdInvitations: []models.HouseholdInvitationData{
+					{
+						ID:            4,
+						HouseholdID:   householdID,
+						HouseholdName: "Home",
+						Email:         "test@example.com",
+						Role:          models.HouseholdRoleViewer,
+						InvitedBy:     inviterID,
+						CreatedAt:     createdAt,
+					},
+				},
+				SecurityLog: []models.SecurityEventData{
+					{
+						ID:         5,
+						ActorEmail: "test@example.com",
+						Action:     models.AuditActionAPITokenCreated,
+						Changes:    map[string]models.FieldChange{"name": {New: "Reports"}},
+						CreatedAt:  createdAt,
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			users := models.NewUserModel(
+				slog.New(slog.DiscardHandler),
+				&MockEmailVerifier{},
+				&ConstantHasher{},
+				&ConstantTokenGenerator{},
+				time.Minute,
+				tt.exportLifetime,
+				time.Minute,
+				&MockDB{},
+				&tt.queries,
+			)
+
+			export, err := users.GetDataExport(t.Context(), tt.userID, tt.token)
+
+			if err == nil && tt.wantErr {
+				t.Fatal("Expected GetDataExport to error.")
+			}
+
+			if err != nil && !tt.wantErr {
+				t.Fatalf("GetDataExport returned an error: %#v", err)
+			}
+
+			for _, wantErr := range tt.wantErrors {
+				if !strings.Contains(err.Error(), wantErr.Error()) {
+					t.Errorf("Expected error to include %q, got %q", wantErr.Error(), err.Error())
+				}
+			}
+
+			if got := tt.queries.getDataExportByTokenToken; got != tt.token {
+				t.Errorf("Expected query for export token %q, got %q", tt.token, got)
+			}
+
+			if got := export.User; got.ID != tt.wantExport.User.ID || got.Email != tt.wantExport.User.Email {
+				t.Errorf("Expected exported user %v, got %v", tt.wantExport.User, got)
+			}
+
+			if want, got := tt.wantExport.User.EmailVerifiedAt, export.User.EmailVerifiedAt; (want == nil) != (got == nil) || (want != nil && !want.Equal(*got)) {
+				t.Errorf("Expected exported verification time %v, got %v", want, got)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			wantInvitationParams := queries.ListHouseholdInvitationsForUserParams{UserID: tt.userID, Email: "test@example.com"}
+			if got := tt.queries.listHouseholdInvitationsForUserParams; got != wantInvitationParams {
+				t.Errorf("Expected invitations listed for %v, got %v", wantInvitationParams, got)
+			}
+
+			if want, got := tt.wantExport.APITokens, export.APITokens; !reflect.DeepEqual(want, got) {
+				t.Errorf("Expected exported API tokens %v, got %v", want, got)
+			}
+
+			if want, got := tt.wantExport.ExternalIdentities, export.ExternalIdentities; !reflect.DeepEqual(want, got) {
+				t.Errorf("Expected exported external identities %v, got %v", want, got)
+			}
+
+			if want, got := tt.wantExport.HouseholdMemberships, export.HouseholdMemberships; !reflect.DeepEqual(want, got) {
+				t.Errorf("Expected exported household memberships %v, got %v", want, got)
+			}
+
+			if want, got := tt.wantExport.HouseholdInvitations, export.HouseholdInvitations; !reflect.DeepEqual(want, got) {
+				t.Errorf("Expected exported household invitations %v, got %v", want, got)
+			}
+
+			if want, got := tt.wantExport.SecurityLog, export.SecurityLog; !reflect.DeepEqual(want, got) {
+				t.Errorf("Expected exported security log %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestUserModel_Delete(t *testing.T) {
+	genericDBError := errors.New("generic DB error")
+	defaultUserID := uuid.New()
+	sharedHouseholdID := uuid.New()
+	successorID := uuid.New()
+
+	testCases := []struct {
+		name                   string
+		db                     MockDB
+		tx                     MockTX
+		queries                MockUserQueries
+		hasher                 ConstantHasher
+		userID                 uuid.UUID
+		password               string
+		wantTransaction        bool
+		wantDeletedUserID      uuid.UUID
+		wantAuditEvents        []wantAuditEvent
+		wantTxCommit           bool
+		wantErr                bool
+		wantInvalidCredentials bool
+	}{
+		{
+			name: "error retrieving user",
+			queries: MockUserQueries{
+				getUserByIDError: genericDBError,
+			},
+			userID:   defaultUserID,
+			password: "password",
+			wantErr:  true,
+		},
+		{
+			name: "hash comparison error",
+			queries: MockUserQueries{
+				getUserByIDUser: queries.User{ID: defaultUserID, PasswordHash: "malformed"},
+			},
+			hasher: ConstantHasher{
+				compareError: errors.New("malformed hash"),
+			},
+			userID:   defaultUserID,
+			password: "password",
+			wantErr:  true,
+		},
+		{
+			name: "incorrect password",
+			queries: MockUserQueries{
+				getUserByIDUser: queries.User{ID: defaultUserID, PasswordHash: "not-password"},
+			},
+			userID:                 defaultUserID,
+			password:               "password",
+			wantErr:                true,
+			wantInvalidCredentials: true,
+		},
+		{
+			name: "error starting transaction",
+			db:   MockDB{beginError: genericDBError},
+			queries: MockUserQueries{
+				getUserByIDUser: queries.User{ID: defaultUserID, Email: "test@example.com", PasswordHash: "password"},
+			},
+			userID:   defaultUserID,
+			password: "password",
+			wantErr:  true,
+		},
+		{
+			name: "error promoting successor owners",
+			queries: MockUserQueries{
+				getUserByIDUser:             queries.User{ID: defaultUserID, Email: "test@example.com", PasswordHash: "password"},
+				promoteSuccessorOwnersError: genericDBError,
+			},
+			userID:          defaultUserID,
+			password:        "password",
+			wantTransaction: true,
+			wantErr:         true,
+		},
+		{
+			name: "error recording promotion",
+			queries: MockUserQueries{
+				MockAuditEvents: MockAuditEvents{insertAuditError: genericDBError},
+				getUserByIDUser: queries.User{ID: defaultUserID, Email: "test@example.com", PasswordHash: "password"},
+				promoteSuccessorOwnersReturn: []queries.PromoteSuccessorOwnersRow{
+					{HouseholdID: sharedHouseholdID, UserID: successorID, PreviousRole: "editor"},
+				},
+			},
+			userID:          defaultUserID,
+			password:        "password",
+			wantTransaction: true,
+			wantAuditEvents: []wantAuditEvent{
+				{
+					actorID:     defaultUserID,
+					householdID: sharedHouseholdID,
+					entityType:  models.AuditEntityHousehold,
+					entityID:    sharedHouseholdID,
+					action:      models.AuditActionMemberPromoted,
+					changes: map[string]models.FieldChange{
+						"member_id": {New: successorID.String()},
+						"role":      {Old: "editor", New: "owner"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error deleting households",
+			queries: MockUserQueries{
+				getUserByIDUser:                     queries.User{ID: defaultUserID, Email: "test@example.com", PasswordHash: "password"},
+				deleteHouseholdsWithOnlyMemberError: genericDBError,
+			},
+			userID:          defaultUserID,
+			password:        "password",
+			wantTransaction: true,
+			wantErr:         true,
+		},
+		{
+			name: "error deleting invitations",
+			queries: MockUserQueries{
+				getUserByIDUser:                         queries.User{ID: defaultUserID, Email: "test@example.com", PasswordHash: "password"},
+				deleteHouseholdInvitationsForEmailError: genericDBError,
+			},
+			userID:          defaultUserID,
+			password:        "password",
+			wantTransaction: true,
+			wantErr:         true,
+		},
+		{
+			name: "error deleting user",
+			queries: MockUserQueries{
+				getUserByIDUser:     queries.User{ID: defaultUserID, Email: "test@example.com", PasswordHash: "password"},
+				deleteUserByIDError: genericDBError,
+			},
+			userID:            defaultUserID,
+			password:          "password",
+			wantTransaction:   true,
+			wantDeletedUserID: defaultUserID,
+			wantErr:           true,
+		},
+		{
+			name: "error committing",
+			tx:   MockTX{commitError: genericDBError},
+			queries: MockUserQueries{
+				getUserByIDUser: queries.User{ID: defaultUserID, Email: "test@example.com", PasswordHash: "password"},
+			},
+			userID:            defaultUserID,
+			password:          "password",
+			wantTransaction:   true,
+			wantDeletedUserID: defaultUserID,
+			wantErr:           true,
+		},
+		{
+			name: "success",
+			queries: MockUserQueries{
+				getUserByIDUser: queries.User{ID: defaultUserID, Email: "test@example.com", PasswordHash: "password"},
+			},
+			userID:            defaultUserID,
+			password:          "password",
+			wantTransaction:   true,
+			wantDeletedUserID: defaultUserID,
+			wantTxCommit:      true,
+		},
+		{
+			name: "success promoting successor owners",
+			queries: MockUserQueries{
+				getUserByIDUser: queries.User{ID: defaultUserID, Email: "test@example.com", PasswordHash: "password"},
+				promoteSuccessorOwnersReturn: []queries.PromoteSuccessorOwnersRow{
+					{HouseholdID: sharedHouseholdID, UserID: successorID, PreviousRole: "viewer"},
+				},
+			},
+			userID:            defaultUserID,
+			password:          "password",
+			wantTransaction:   true,
+			wantDeletedUserID: defaultUserID,
+			wantAuditEvents: []wantAuditEvent{
+				{
+					actorID:     defaultUserID,
+					householdID: sharedHouseholdID,
+					entityType:  models.AuditEntityHousehold,
+					entityID:    sharedHouseholdID,
+					action:      models.AuditActionMemberPromoted,
+					changes: map[string]models.FieldChange{
+						"member_id": {New: successorID.String()},
+						"role":      {Old: "viewer", New: "owner"},
+					},
+				},
+			},
+			wantTxCommit: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.db.txFactory == nil {
+				tt.db.txFactory = func() models.Transaction { return &tt.tx }
+			}
+
+			users := models.NewUserModel(
+				slog.New(slog.DiscardHandler),
+				&MockEmailVerifier{},
+				&tt.hasher,
+				&ConstantTokenGenerator{},
+				time.Minute,
+				time.Minute,
+				time.Minute,
+				&tt.db,
+				&tt.queries,
+			)
+
+			err := users.Delete(t.Context(), tt.userID, tt.password)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error presence %v, got error %#v", tt.wantErr, err)
+			}
+
+			if tt.wantInvalidCredentials != errors.Is(err, models.ErrInvalidCredentials) {
+				t.Errorf("Expected invalid credentials %v, got error %#v", tt.wantInvalidCredentials, err)
+			}
+
+			if got := tt.queries.gotUserByID; got != tt.userID {
+				t.Errorf("Expected query for user %v, got %v", tt.userID, got)
+			}
+
+			var wantTransactionUserID uuid.UUID
+			if tt.wantTransaction {
+				wantTransactionUserID = tt.userID
+			}
+
+			if got := tt.queries.promotedSuccessorsForUserID; got != wantTransactionUserID {
+				t.Errorf("Expected successor owners promoted for user %v, got %v", wantTransactionUserID, got)
+			}
+
+			// Households can only be deleted once any successor owners were recorded.
+			wantHouseholdsDeleted := tt.wantTransaction && tt.queries.promoteSuccessorOwnersError == nil && tt.queries.insertAuditError == nil
+			if got := tt.queries.deletedHouseholdsOnlyMemberID; wantHouseholdsDeleted != (got == tt.userID) {
+				t.Errorf("Expected households with only member deleted %v, got deletion for %v", wantHouseholdsDeleted, got)
+			}
+
+			var wantInvitationsEmail string
+			if wantHouseholdsDeleted && tt.queries.deleteHouseholdsWithOnlyMemberError == nil {
+				wantInvitationsEmail = "test@example.com"
+			}
+
+			if got := tt.queries.deletedHouseholdInvitationsEmail; got != wantInvitationsEmail {
+				t.Errorf("Expected invitations deleted for %q, got %q", wantInvitationsEmail, got)
+			}
+
+			if got := tt.queries.deletedUserID; got != tt.wantDeletedUserID {
+				t.Errorf("Expected deleted user %v, got %v", tt.wantDeletedUserID, got)
+			}
+
+			if tt.tx.committed != tt.wantTxCommit {
+				t.Errorf("Expected transaction commit %v, got %v", tt.wantTxCommit, tt.tx.committed)
+			}
+
+			assertAuditEvents(t, tt.wantAuditEvents, tt.queries.insertedAuditEvents)
+		})
+	}
+}
+
 func assertUsersEqual(t *testing.T, expected models.User, got models.User) {
 	if expected.ID != got.ID {
 		t.Errorf("Expected ID %v, got %v", expected.ID, got.ID)
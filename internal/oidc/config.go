@@ -0,0 +1,67 @@
+package oidc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// Config describes a single OpenID Connect provider that users can sign in with.
+type Config struct {
+	// Name identifies the provider in URLs, so it must be a short slug like "corp".
+	Name string `json:"name"`
+
+	// DisplayName is shown to users on the login page. It defaults to the name.
+	DisplayName string `json:"displayName"`
+
+	// Issuer is the provider's issuer identifier. Its discovery document is expected at
+	// "{issuer}/.well-known/openid-configuration".
+	Issuer string `json:"issuer"`
+
+	ClientID     string `json:"clientID"`
+	ClientSecret string `json:"clientSecret"`
+
+	// Scopes are requested in addition to "openid" and "email".
+	Scopes []string `json:"scopes"`
+}
+
+var namePattern = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// LoadConfig reads a JSON array of provider configurations.
+func LoadConfig(r io.Reader) ([]Config, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	var configs []Config
+	if err := decoder.Decode(&configs); err != nil {
+		return nil, fmt.Errorf("decoding provider config: %v", err)
+	}
+
+	names := make(map[string]bool, len(configs))
+	for i, config := range configs {
+		if !namePattern.MatchString(config.Name) {
+			return nil, fmt.Errorf("provider %d: name %q must only contain lowercase letters, digits, and dashes", i, config.Name)
+		}
+
+		if names[config.Name] {
+			return nil, fmt.Errorf("provider %d: duplicate name %q", i, config.Name)
+		}
+
+		names[config.Name] = true
+
+		if config.Issuer == "" {
+			return nil, fmt.Errorf("provider %q: issuer is required", config.Name)
+		}
+
+		if config.ClientID == "" {
+			return nil, fmt.Errorf("provider %q: client ID is required", config.Name)
+		}
+
+		if config.DisplayName == "" {
+			configs[i].DisplayName = config.Name
+		}
+	}
+
+	return configs, nil
+}
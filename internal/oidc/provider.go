@@ -0,0 +1,218 @@
+// Package oidc implements the relying party side of the OpenID Connect authorization code flow
+// with PKCE.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// metadata is the subset of a provider's discovery document that the authorization code flow
+// needs.
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider is an OpenID Connect provider that has been discovered and can authenticate users.
+type Provider struct {
+	config      Config
+	redirectURL string
+	client      *http.Client
+	metadata    metadata
+	keys        *keySet
+}
+
+// Discover fetches the provider's discovery document. The redirect URL must be the callback that
+// the provider sends users back to, and it must be registered with the provider.
+func Discover(ctx context.Context, client *http.Client, config Config, redirectURL string) (*Provider, error) {
+	discoveryURL := strings.TrimSuffix(config.Issuer, "/") + "/.well-known/openid-configuration"
+
+	var meta metadata
+	if err := getJSON(ctx, client, discoveryURL, &meta); err != nil {
+		return nil, fmt.Errorf("fetching discovery document: %v", err)
+	}
+
+	// The issuer in the document must exactly match the configured issuer, otherwise one provider
+	// could impersonate another.
+	if meta.Issuer != config.Issuer {
+		return nil, fmt.Errorf("discovery document issuer %q does not match configured issuer %q", meta.Issuer, config.Issuer)
+	}
+
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document is missing required endpoints")
+	}
+
+	provider := Provider{
+		config:      config,
+		redirectURL: redirectURL,
+		client:      client,
+		metadata:    meta,
+		keys:        &keySet{client: client, uri: meta.JWKSURI},
+	}
+
+	return &provider, nil
+}
+
+func (p *Provider) Name() string {
+	return p.config.Name
+}
+
+func (p *Provider) DisplayName() string {
+	if p.config.DisplayName == "" {
+		return p.config.Name
+	}
+
+	return p.config.DisplayName
+}
+
+// AuthCodeURL returns the URL to send the user to so they can sign in with the provider. The
+// state, nonce, and PKCE verifier must be kept by the caller and passed to [Provider.Exchange]
+// when the user returns.
+func (p *Provider) AuthCodeURL(state string, nonce string, verifier string) string {
+	scopes := []string{"openid", "email"}
+	for _, scope := range p.config.Scopes {
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	authURL, err := url.Parse(p.metadata.AuthorizationEndpoint)
+	if err != nil {
+		// Endpoints come from the provider's discovery document, so the best we can do is let the
+		// provider reject the request.
+		authURL = &url.URL{Path: p.metadata.AuthorizationEndpoint}
+	}
+
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", p.redirectURL)
+	query.Set("scope", strings.Join(scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", challengeS256(verifier))
+	query.Set("code_challenge_method", "S256")
+
+	authURL.RawQuery = query.Encode()
+
+	return authURL.String()
+}
+
+// ErrTokenRequestRejected is returned when the provider's token endpoint refuses to redeem an
+// authorization code, for example because the code has expired or was already used.
+var ErrTokenRequestRejected = errors.New("token request rejected")
+
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Exchange redeems an authorization code for an ID token and returns the verified claims from it.
+// The nonce must match the one passed to [Provider.AuthCodeURL].
+func (p *Provider) Exchange(ctx context.Context, code string, verifier string, nonce string) (Claims, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.redirectURL},
+		"code_verifier": {verifier},
+		"client_id":     {p.config.ClientID},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Claims{}, fmt.Errorf("creating token request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return Claims{}, fmt.Errorf("sending token request: %v", err)
+	}
+
+	defer res.Body.Close()
+
+	body := io.LimitReader(res.Body, maxResponseSize)
+
+	var token tokenResponse
+	if res.StatusCode != http.StatusOK {
+		// Error responses aren't always JSON, for example when a proxy in front of the provider
+		// fails, so the error details are only included if they can be read.
+		json.NewDecoder(body).Decode(&token)
+
+		return Claims{}, fmt.Errorf("%w: status %d: %s %s", ErrTokenRequestRejected, res.StatusCode, token.Error, token.ErrorDescription)
+	}
+
+	if err := json.NewDecoder(body).Decode(&token); err != nil {
+		return Claims{}, fmt.Errorf("decoding token response: %v", err)
+	}
+
+	if token.IDToken == "" {
+		return Claims{}, fmt.Errorf("%w: token response has no ID token", ErrInvalidIDToken)
+	}
+
+	return p.verify(ctx, token.IDToken, nonce)
+}
+
+// NewVerifier generates a PKCE code verifier.
+func NewVerifier() string {
+	// 32 bytes encodes to 43 characters, the minimum verifier length allowed by RFC 7636.
+	b := make([]byte, 32)
+	rand.Read(b)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func challengeS256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// maxResponseSize bounds how much of a provider's response is read.
+const maxResponseSize = 1 << 20
+
+func getJSON(ctx context.Context, client *http.Client, target string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %v", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %v", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxResponseSize)).Decode(v); err != nil {
+		return fmt.Errorf("decoding response: %v", err)
+	}
+
+	return nil
+}
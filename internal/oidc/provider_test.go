@@ -0,0 +1,433 @@
+package oidc_test
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cdriehuys/stuff2/internal/oidc"
+	"github.com/cdriehuys/stuff2/internal/oidc/oidctest"
+)
+
+const (
+	testClientID     = "stuff"
+	testClientSecret = "client-secret"
+	testRedirectURL  = "https://stuff.example.com/login/oidc/test/callback"
+)
+
+func TestLoadConfig(t *testing.T) {
+	testCases := []struct {
+		name    string
+		config  string
+		want    []oidc.Config
+		wantErr bool
+	}{
+		{
+			name:   "valid",
+			config: `[{"name": "corp", "issuer": "https://id.example.com", "clientID": "stuff", "clientSecret": "secret", "scopes": ["profile"]}]`,
+			want: []oidc.Config{
+				{
+					Name:         "corp",
+					DisplayName:  "corp",
+					Issuer:       "https://id.example.com",
+					ClientID:     "stuff",
+					ClientSecret: "secret",
+					Scopes:       []string{"profile"},
+				},
+			},
+		},
+		{
+			name:   "display name",
+			config: `[{"name": "corp", "displayName": "Corp SSO", "issuer": "https://id.example.com", "clientID": "stuff"}]`,
+			want: []oidc.Config{
+				{Name: "corp", DisplayName: "Corp SSO", Issuer: "https://id.example.com", ClientID: "stuff"},
+			},
+		},
+		{
+			name:    "invalid name",
+			config:  `[{"name": "Corp SSO", "issuer": "https://id.example.com", "clientID": "stuff"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "duplicate name",
+			config:  `[{"name": "corp", "issuer": "https://a.example.com", "clientID": "a"}, {"name": "corp", "issuer": "https://b.example.com", "clientID": "b"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "missing issuer",
+			config:  `[{"name": "corp", "clientID": "stuff"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "missing client ID",
+			config:  `[{"name": "corp", "issuer": "https://id.example.com"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			config:  `[{"name": "corp", "issuer": "https://id.example.com", "clientID": "stuff", "secret": "typo"}]`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			configs, err := oidc.LoadConfig(strings.NewReader(tt.config))
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error presence %v, got error %#v", tt.wantErr, err)
+			}
+
+			if len(configs) != len(tt.want) {
+				t.Fatalf("Expected %d configs, got %d", len(tt.want), len(configs))
+			}
+
+			for i, want := range tt.want {
+				got := configs[i]
+				if got.Name != want.Name || got.DisplayName != want.DisplayName || got.Issuer != want.Issuer || got.ClientID != want.ClientID || got.ClientSecret != want.ClientSecret || strings.Join(got.Scopes, " ") != strings.Join(want.Scopes, " ") {
+					t.Errorf("Expected config %d to be %+v, got %+v", i, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	fake := oidctest.NewProvider(t, testClientID, testClientSecret)
+
+	testCases := []struct {
+		name    string
+		issuer  string
+		wantErr bool
+	}{
+		{
+			name:   "matching issuer",
+			issuer: fake.Issuer(),
+		},
+		{
+			name:    "mismatched issuer",
+			issuer:  fake.Issuer() + "/",
+			wantErr: true,
+		},
+		{
+			name:    "missing discovery document",
+			issuer:  fake.Issuer() + "/missing",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			config := oidc.Config{Name: "test", Issuer: tt.issuer, ClientID: testClientID}
+
+			_, err := oidc.Discover(t.Context(), fake.Client(), config, testRedirectURL)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error presence %v, got error %#v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestProvider_AuthCodeURL(t *testing.T) {
+	fake := oidctest.NewProvider(t, testClientID, testClientSecret)
+	provider := discover(t, fake, []string{"profile", "email"})
+
+	rawURL := provider.AuthCodeURL("the-state", "the-nonce", "the-verifier")
+
+	authURL, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("Invalid authorization URL %q: %v", rawURL, err)
+	}
+
+	if want := fake.Issuer() + "/authorize"; !strings.HasPrefix(rawURL, want+"?") {
+		t.Errorf("Expected authorization URL to start with %q, got %q", want, rawURL)
+	}
+
+	wantParams := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          testRedirectURL,
+		"scope":                 "openid email profile",
+		"state":                 "the-state",
+		"nonce":                 "the-nonce",
+		"code_challenge_method": "S256",
+		// SHA-256 of "the-verifier"
+		"code_challenge": "sP6XQ2T7IHwj7eBkdcI9xyC8WxEik0RMQk0tVGDKZPI",
+	}
+
+	query := authURL.Query()
+	for name, want := range wantParams {
+		if got := query.Get(name); got != want {
+			t.Errorf("Expected %s to be %q, got %q", name, want, got)
+		}
+	}
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	testCases := []struct {
+		name          string
+		user          oidctest.User
+		claims        map[string]any
+		forge         bool
+		tokenError    int
+		nonce         string
+		verifier      string
+		wantClaims    oidc.Claims
+		wantErr       bool
+		wantTokenErr  bool
+		wantRejectErr bool
+	}{
+		{
+			name:  "valid token",
+			user:  oidctest.User{Subject: "user-1", Email: "user@example.com", EmailVerified: true},
+			nonce: "the-nonce",
+			wantClaims: oidc.Claims{
+				Subject:       "user-1",
+				Email:         "user@example.com",
+				EmailVerified: true,
+			},
+		},
+		{
+			name:  "unverified email",
+			user:  oidctest.User{Subject: "user-1", Email: "user@example.com"},
+			nonce: "the-nonce",
+			wantClaims: oidc.Claims{
+				Subject: "user-1",
+				Email:   "user@example.com",
+			},
+		},
+		{
+			name:   "email verified as string",
+			user:   oidctest.User{Subject: "user-1", Email: "user@example.com"},
+			claims: map[string]any{"email_verified": "true"},
+			nonce:  "the-nonce",
+			wantClaims: oidc.Claims{
+				Subject:       "user-1",
+				Email:         "user@example.com",
+				EmailVerified: true,
+			},
+		},
+		{
+			name:          "wrong verifier",
+			nonce:         "the-nonce",
+			verifier:      "not-the-verifier",
+			wantErr:       true,
+			wantRejectErr: true,
+		},
+		{
+			name:          "non-JSON error page",
+			tokenError:    http.StatusBadGateway,
+			nonce:         "the-nonce",
+			wantErr:       true,
+			wantRejectErr: true,
+		},
+		{
+			name:         "wrong nonce",
+			nonce:        "other-nonce",
+			wantErr:      true,
+			wantTokenErr: true,
+		},
+		{
+			name:         "forged signature",
+			forge:        true,
+			nonce:        "the-nonce",
+			wantErr:      true,
+			wantTokenErr: true,
+		},
+		{
+			name:         "wrong issuer",
+			claims:       map[string]any{"iss": "https://evil.example.com"},
+			nonce:        "the-nonce",
+			wantErr:      true,
+			wantTokenErr: true,
+		},
+		{
+			name:         "wrong audience",
+			claims:       map[string]any{"aud": "other-client"},
+			nonce:        "the-nonce",
+			wantErr:      true,
+			wantTokenErr: true,
+		},
+		{
+			name:         "multiple audiences without authorized party",
+			claims:       map[string]any{"aud": []string{testClientID, "other-client"}},
+			nonce:        "the-nonce",
+			wantErr:      true,
+			wantTokenErr: true,
+		},
+		{
+			name: "multiple audiences with authorized party",
+			user: oidctest.User{Subject: "user-1"},
+			claims: map[string]any{
+				"aud": []string{testClientID, "other-client"},
+				"azp": testClientID,
+			},
+			nonce:      "the-nonce",
+			wantClaims: oidc.Claims{Subject: "user-1"},
+		},
+		{
+			name:         "expired",
+			claims:       map[string]any{"exp": time.Now().Add(-time.Hour).Unix()},
+			nonce:        "the-nonce",
+			wantErr:      true,
+			wantTokenErr: true,
+		},
+		{
+			name:         "issued in the future",
+			claims:       map[string]any{"iat": time.Now().Add(time.Hour).Unix()},
+			nonce:        "the-nonce",
+			wantErr:      true,
+			wantTokenErr: true,
+		},
+		{
+			name:         "missing subject",
+			claims:       map[string]any{"sub": ""},
+			nonce:        "the-nonce",
+			wantErr:      true,
+			wantTokenErr: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := oidctest.NewProvider(t, testClientID, testClientSecret)
+			// Cases that expect an error still need a subject so they fail for the intended reason.
+			user := tt.user
+			if user.Subject == "" {
+				user.Subject = "user-1"
+			}
+
+			fake.SetUser(user)
+			fake.SetClaims(tt.claims)
+			fake.ForgeSignatures(tt.forge)
+			fake.FailTokenRequests(tt.tokenError)
+
+			provider := discover(t, fake, nil)
+
+			verifier := oidc.NewVerifier()
+			code := authorize(t, fake, provider.AuthCodeURL("state", "the-nonce", verifier))
+
+			if tt.verifier != "" {
+				verifier = tt.verifier
+			}
+
+			claims, err := provider.Exchange(t.Context(), code, verifier, tt.nonce)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error presence %v, got error %#v", tt.wantErr, err)
+			}
+
+			if tt.wantTokenErr && !errors.Is(err, oidc.ErrInvalidIDToken) {
+				t.Errorf("Expected error %v, got %v", oidc.ErrInvalidIDToken, err)
+			}
+
+			if tt.wantRejectErr && !errors.Is(err, oidc.ErrTokenRequestRejected) {
+				t.Errorf("Expected error %v, got %v", oidc.ErrTokenRequestRejected, err)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			tt.wantClaims.Issuer = fake.Issuer()
+			if claims != tt.wantClaims {
+				t.Errorf("Expected claims %+v, got %+v", tt.wantClaims, claims)
+			}
+		})
+	}
+}
+
+func TestProvider_Exchange_KeyRotation(t *testing.T) {
+	fake := oidctest.NewProvider(t, testClientID, testClientSecret)
+	provider := discover(t, fake, nil)
+
+	for i := range 2 {
+		verifier := oidc.NewVerifier()
+		code := authorize(t, fake, provider.AuthCodeURL("state", "nonce", verifier))
+
+		if _, err := provider.Exchange(t.Context(), code, verifier, "nonce"); err != nil {
+			t.Fatalf("Exchange %d failed: %v", i, err)
+		}
+
+		// The second exchange must pick up the new key even though the first one cached the
+		// original key set.
+		fake.RotateKey(t)
+	}
+}
+
+func TestProvider_Exchange_CodeReuse(t *testing.T) {
+	fake := oidctest.NewProvider(t, testClientID, testClientSecret)
+	provider := discover(t, fake, nil)
+
+	verifier := oidc.NewVerifier()
+	code := authorize(t, fake, provider.AuthCodeURL("state", "nonce", verifier))
+
+	if _, err := provider.Exchange(t.Context(), code, verifier, "nonce"); err != nil {
+		t.Fatalf("First exchange failed: %v", err)
+	}
+
+	if _, err := provider.Exchange(t.Context(), code, verifier, "nonce"); !errors.Is(err, oidc.ErrTokenRequestRejected) {
+		t.Errorf("Expected reusing an authorization code to fail with %v, got %v", oidc.ErrTokenRequestRejected, err)
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	verifier := oidc.NewVerifier()
+
+	// RFC 7636 requires 43 to 128 characters.
+	if len(verifier) < 43 || len(verifier) > 128 {
+		t.Errorf("Expected verifier length between 43 and 128, got %d", len(verifier))
+	}
+
+	if other := oidc.NewVerifier(); other == verifier {
+		t.Errorf("Expected unique verifiers, got %q twice", verifier)
+	}
+}
+
+func discover(t *testing.T, fake *oidctest.Provider, scopes []string) *oidc.Provider {
+	t.Helper()
+
+	config := oidc.Config{
+		Name:         "test",
+		Issuer:       fake.Issuer(),
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		Scopes:       scopes,
+	}
+
+	provider, err := oidc.Discover(t.Context(), fake.Client(), config, testRedirectURL)
+	if err != nil {
+		t.Fatalf("Failed to discover provider: %v", err)
+	}
+
+	return provider
+}
+
+// authorize visits the authorization URL and returns the code that the provider redirects back
+// with.
+func authorize(t *testing.T, fake *oidctest.Provider, authURL string) string {
+	t.Helper()
+
+	client := *fake.Client()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	res, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("Failed to authorize: %v", err)
+	}
+
+	defer res.Body.Close()
+
+	location, err := url.Parse(res.Header.Get("Location"))
+	if err != nil || res.StatusCode != http.StatusFound {
+		t.Fatalf("Expected redirect from authorization endpoint, got status %d and location %q", res.StatusCode, res.Header.Get("Location"))
+	}
+
+	return location.Query().Get("code")
+}
@@ -0,0 +1,369 @@
+package oidc
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrInvalidIDToken = errors.New("invalid ID token")
+
+// clockSkew is how far the provider's clock may differ from ours when checking token times.
+const clockSkew = time.Minute
+
+// Claims are the verified claims about a user from an ID token.
+type Claims struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+type tokenClaims struct {
+	Issuer          string      `json:"iss"`
+	Subject         string      `json:"sub"`
+	Audience        audience    `json:"aud"`
+	AuthorizedParty string      `json:"azp"`
+	Expiry          numericDate `json:"exp"`
+	IssuedAt        numericDate `json:"iat"`
+	Nonce           string      `json:"nonce"`
+	Email           string      `json:"email"`
+	EmailVerified   boolClaim   `json:"email_verified"`
+}
+
+// audience is either a single string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return fmt.Errorf("audience must be a string or array of strings: %v", err)
+	}
+
+	*a = multiple
+
+	return nil
+}
+
+// numericDate is a number of seconds since the epoch. It may have a fractional part.
+type numericDate float64
+
+func (d numericDate) Time() time.Time {
+	return time.UnixMilli(int64(float64(d) * 1000))
+}
+
+// boolClaim accepts booleans and the strings "true" and "false", since some providers send
+// email_verified as a string.
+type boolClaim bool
+
+func (b *boolClaim) UnmarshalJSON(data []byte) error {
+	switch string(bytes.Trim(data, `"`)) {
+	case "true":
+		*b = true
+	case "false", "null":
+		*b = false
+	default:
+		return fmt.Errorf("invalid boolean %s", data)
+	}
+
+	return nil
+}
+
+// verify checks the ID token's signature against the provider's keys and validates its claims.
+func (p *Provider) verify(ctx context.Context, rawToken string, nonce string) (Claims, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return Claims{}, fmt.Errorf("%w: malformed token", ErrInvalidIDToken)
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Claims{}, fmt.Errorf("%w: decoding header: %v", ErrInvalidIDToken, err)
+	}
+
+	// Checking the algorithm up front rejects unsigned "none" tokens before any keys are fetched.
+	if header.Algorithm != "RS256" && header.Algorithm != "ES256" {
+		return Claims{}, fmt.Errorf("%w: unsupported signing algorithm %q", ErrInvalidIDToken, header.Algorithm)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: decoding signature: %v", ErrInvalidIDToken, err)
+	}
+
+	key, err := p.keys.find(ctx, header.KeyID, header.Algorithm)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	signed := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := verifySignature(header.Algorithm, key, signed[:], signature); err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	var claims tokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Claims{}, fmt.Errorf("%w: decoding claims: %v", ErrInvalidIDToken, err)
+	}
+
+	if err := p.validateClaims(claims, nonce); err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	verified := Claims{
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+	}
+
+	return verified, nil
+}
+
+func (p *Provider) validateClaims(claims tokenClaims, nonce string) error {
+	if claims.Issuer != p.metadata.Issuer {
+		return fmt.Errorf("issuer %q does not match %q", claims.Issuer, p.metadata.Issuer)
+	}
+
+	if claims.Subject == "" {
+		return errors.New("missing subject")
+	}
+
+	if !slices.Contains(claims.Audience, p.config.ClientID) {
+		return fmt.Errorf("audience %v does not include client", claims.Audience)
+	}
+
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.config.ClientID {
+		return fmt.Errorf("authorized party %q is not the client", claims.AuthorizedParty)
+	}
+
+	now := time.Now()
+
+	if claims.Expiry == 0 || !now.Before(claims.Expiry.Time().Add(clockSkew)) {
+		return errors.New("token is expired")
+	}
+
+	if claims.IssuedAt.Time().After(now.Add(clockSkew)) {
+		return errors.New("token was issued in the future")
+	}
+
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return errors.New("nonce does not match")
+	}
+
+	return nil
+}
+
+func decodeSegment(segment string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, v)
+}
+
+func verifySignature(algorithm string, key crypto.PublicKey, digest []byte, signature []byte) error {
+	switch algorithm {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("RS256 requires an RSA key")
+		}
+
+		return rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest, signature)
+
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("ES256 requires an EC key")
+		}
+
+		// JWS encodes ECDSA signatures as the fixed-size concatenation of r and s.
+		if len(signature) != 64 {
+			return errors.New("malformed ES256 signature")
+		}
+
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return errors.New("invalid ES256 signature")
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("unsupported signing algorithm %q", algorithm)
+}
+
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+
+	// RSA keys
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC keys
+	Curve string `json:"crv"`
+	X     string `json:"x"`
+	Y     string `json:"y"`
+}
+
+type publicKey struct {
+	id  string
+	key crypto.PublicKey
+}
+
+// keySet caches a provider's signing keys. The keys are fetched again when a token is signed with
+// a key we haven't seen, which happens when the provider rotates its keys.
+type keySet struct {
+	client *http.Client
+	uri    string
+
+	mu   sync.Mutex
+	keys []publicKey
+}
+
+func (s *keySet) find(ctx context.Context, keyID string, algorithm string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.match(keyID, algorithm); ok {
+		return key, nil
+	}
+
+	if err := s.refresh(ctx); err != nil {
+		return nil, fmt.Errorf("fetching signing keys: %v", err)
+	}
+
+	if key, ok := s.match(keyID, algorithm); ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("%w: no %s signing key with ID %q", ErrInvalidIDToken, algorithm, keyID)
+}
+
+func (s *keySet) match(keyID string, algorithm string) (crypto.PublicKey, bool) {
+	for _, key := range s.keys {
+		if keyID != "" && key.id != keyID {
+			continue
+		}
+
+		switch key.key.(type) {
+		case *rsa.PublicKey:
+			if algorithm == "RS256" {
+				return key.key, true
+			}
+		case *ecdsa.PublicKey:
+			if algorithm == "ES256" {
+				return key.key, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func (s *keySet) refresh(ctx context.Context) error {
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	if err := getJSON(ctx, s.client, s.uri, &document); err != nil {
+		return err
+	}
+
+	keys := make([]publicKey, 0, len(document.Keys))
+	for _, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := parseKey(jwk)
+		if err != nil {
+			// An unusable key shouldn't prevent using the others.
+			continue
+		}
+
+		keys = append(keys, publicKey{id: jwk.KeyID, key: key})
+	}
+
+	s.keys = keys
+
+	return nil
+}
+
+func parseKey(jwk jsonWebKey) (crypto.PublicKey, error) {
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("decoding modulus: %v", err)
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("decoding exponent: %v", err)
+		}
+
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 || exponent.Int64() < 3 {
+			return nil, errors.New("invalid exponent")
+		}
+
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+
+	case "EC":
+		if jwk.Curve != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Curve)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, fmt.Errorf("decoding x coordinate: %v", err)
+		}
+
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, fmt.Errorf("decoding y coordinate: %v", err)
+		}
+
+		if len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("invalid coordinate length")
+		}
+
+		point := append([]byte{4}, append(x, y...)...)
+
+		return ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+	}
+
+	return nil, fmt.Errorf("unsupported key type %q", jwk.KeyType)
+}
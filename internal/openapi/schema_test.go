@@ -0,0 +1,176 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cdriehuys/stuff2/internal/openapi"
+	"github.com/google/uuid"
+)
+
+type embedded struct {
+	Embedded string `json:"embedded"`
+}
+
+type example struct {
+	embedded
+
+	ID        uuid.UUID         `json:"id"`
+	Name      string            `json:"name"`
+	Count     int               `json:"count"`
+	Total     int64             `json:"total"`
+	Price     float64           `json:"price"`
+	Active    bool              `json:"active"`
+	Tags      []string          `json:"tags"`
+	Labels    map[string]string `json:"labels"`
+	CreatedAt time.Time         `json:"created_at"`
+	DeletedAt *time.Time        `json:"deleted_at"`
+	Notes     string            `json:"notes,omitempty"`
+	Untagged  string
+	Ignored   string `json:"-"`
+	private   string
+}
+
+type recursive struct {
+	Children []recursive `json:"children"`
+}
+
+func TestSchemaFor(t *testing.T) {
+	testCases := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{
+			name:  "nil",
+			value: nil,
+			want:  `{}`,
+		},
+		{
+			name:  "string",
+			value: "",
+			want:  `{"type":"string"}`,
+		},
+		{
+			name:  "slice",
+			value: []int{},
+			want:  `{"type":"array","items":{"type":"integer","format":"int64"}}`,
+		},
+		{
+			name:  "int",
+			value: int(0),
+			want:  `{"type":"integer","format":"int64"}`,
+		},
+		{
+			name:  "int8",
+			value: int8(0),
+			want:  `{"type":"integer","format":"int32"}`,
+		},
+		{
+			name:  "int16",
+			value: int16(0),
+			want:  `{"type":"integer","format":"int32"}`,
+		},
+		{
+			name:  "int32",
+			value: int32(0),
+			want:  `{"type":"integer","format":"int32"}`,
+		},
+		{
+			name:  "int64",
+			value: int64(0),
+			want:  `{"type":"integer","format":"int64"}`,
+		},
+		{
+			name:  "uint",
+			value: uint(0),
+			want:  `{"type":"integer","format":"int64"}`,
+		},
+		{
+			name:  "uint8",
+			value: uint8(0),
+			want:  `{"type":"integer","format":"int32"}`,
+		},
+		{
+			name:  "uint16",
+			value: uint16(0),
+			want:  `{"type":"integer","format":"int32"}`,
+		},
+		{
+			name:  "uint32",
+			value: uint32(0),
+			want:  `{"type":"integer","format":"int64"}`,
+		},
+		{
+			name:  "uint64",
+			value: uint64(0),
+			want:  `{"type":"integer","format":"int64"}`,
+		},
+		{
+			name:  "struct",
+			value: example{},
+			want: `{
+				"type": "object",
+				"properties": {
+					"embedded": {"type": "string"},
+					"id": {"type": "string", "format": "uuid"},
+					"name": {"type": "string"},
+					"count": {"type": "integer", "format": "int64"},
+					"total": {"type": "integer", "format": "int64"},
+					"price": {"type": "number"},
+					"active": {"type": "boolean"},
+					"tags": {"type": "array", "items": {"type": "string"}},
+					"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+					"created_at": {"type": "string", "format": "date-time"},
+					"deleted_at": {"type": ["string", "null"], "format": "date-time"},
+					"notes": {"type": "string"},
+					"Untagged": {"type": "string"}
+				},
+				"required": ["embedded", "id", "name", "count", "total", "price", "active", "tags", "labels", "created_at", "deleted_at", "Untagged"]
+			}`,
+		},
+		{
+			name:  "recursive struct",
+			value: recursive{},
+			want: `{
+				"type": "object",
+				"properties": {
+					"children": {"type": "array", "items": {"type": "object"}}
+				},
+				"required": ["children"]
+			}`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(openapi.SchemaFor(tt.value))
+			if err != nil {
+				t.Fatalf("Failed to encode schema: %v", err)
+			}
+
+			assertJSONEqual(t, tt.want, string(got))
+		})
+	}
+}
+
+func assertJSONEqual(t *testing.T, want string, got string) {
+	t.Helper()
+
+	var wantValue, gotValue any
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("Invalid expected JSON: %v", err)
+	}
+
+	if err := json.Unmarshal([]byte(got), &gotValue); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+
+	wantNormalized, _ := json.Marshal(wantValue)
+	gotNormalized, _ := json.Marshal(gotValue)
+
+	if string(wantNormalized) != string(gotNormalized) {
+		t.Errorf("Expected schema %s, got %s", wantNormalized, gotNormalized)
+	}
+}
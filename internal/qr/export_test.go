@@ -0,0 +1,7 @@
+package qr
+
+// ErrorCorrectionCodewords computes the given number of error correction codewords for a block of
+// data.
+func ErrorCorrectionCodewords(data []byte, count int) []byte {
+	return reedSolomonRemainder(data, reedSolomonDivisor(count))
+}
@@ -0,0 +1,458 @@
+// Package qr encodes short text, such as URLs, as QR codes.
+//
+// Only what labels need is implemented: byte mode, error correction level M, and versions 1
+// through 10, which hold up to 213 bytes.
+package qr
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var ErrTooLong = errors.New("text is too long to encode")
+
+// version describes the codewords of a QR code version at error correction level M.
+type version struct {
+	totalCodewords int
+	ecPerBlock     int
+	blocks         int
+	alignment      []int
+}
+
+var versions = []version{
+	1:  {totalCodewords: 26, ecPerBlock: 10, blocks: 1},
+	2:  {totalCodewords: 44, ecPerBlock: 16, blocks: 1, alignment: []int{6, 18}},
+	3:  {totalCodewords: 70, ecPerBlock: 26, blocks: 1, alignment: []int{6, 22}},
+	4:  {totalCodewords: 100, ecPerBlock: 18, blocks: 2, alignment: []int{6, 26}},
+	5:  {totalCodewords: 134, ecPerBlock: 24, blocks: 2, alignment: []int{6, 30}},
+	6:  {totalCodewords: 172, ecPerBlock: 16, blocks: 4, alignment: []int{6, 34}},
+	7:  {totalCodewords: 196, ecPerBlock: 18, blocks: 4, alignment: []int{6, 22, 38}},
+	8:  {totalCodewords: 242, ecPerBlock: 22, blocks: 4, alignment: []int{6, 24, 42}},
+	9:  {totalCodewords: 292, ecPerBlock: 22, blocks: 5, alignment: []int{6, 26, 46}},
+	10: {totalCodewords: 346, ecPerBlock: 26, blocks: 5, alignment: []int{6, 28, 50}},
+}
+
+func (v version) dataCodewords() int {
+	return v.totalCodewords - v.ecPerBlock*v.blocks
+}
+
+// Code is an encoded QR code.
+type Code struct {
+	// Size is the number of modules along each side, not including the quiet zone.
+	Size int
+
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+// Dark reports whether the module at the given position is dark.
+func (c *Code) Dark(x int, y int) bool {
+	return c.modules[y][x]
+}
+
+// Encode encodes the text using the smallest version that fits it.
+func Encode(text string) (*Code, error) {
+	data := []byte(text)
+
+	for number := 1; number < len(versions); number++ {
+		v := versions[number]
+
+		countBits := 8
+		if number >= 10 {
+			countBits = 16
+		}
+
+		if 4+countBits+8*len(data) > v.dataCodewords()*8 {
+			continue
+		}
+
+		var bits bitBuffer
+		bits.append(0b0100, 4)
+		bits.append(len(data), countBits)
+		for _, b := range data {
+			bits.append(int(b), 8)
+		}
+
+		codewords := bits.codewords(v.dataCodewords())
+
+		return newCode(number, addErrorCorrection(v, codewords)), nil
+	}
+
+	return nil, fmt.Errorf("%w: %d bytes", ErrTooLong, len(data))
+}
+
+// SVG renders the code as an SVG image with a quiet zone around it. The image scales to fill its
+// container.
+func (c *Code) SVG() string {
+	const quietZone = 4
+
+	var path strings.Builder
+	for y := range c.Size {
+		for x := range c.Size {
+			if c.Dark(x, y) {
+				fmt.Fprintf(&path, "M%d,%dh1v1h-1z", x+quietZone, y+quietZone)
+			}
+		}
+	}
+
+	size := c.Size + 2*quietZone
+
+	return fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges"><rect width="100%%" height="100%%" fill="#fff"/><path d="%s" fill="#000"/></svg>`,
+		size, size, path.String(),
+	)
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value int, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, (value>>i)&1 == 1)
+	}
+}
+
+// codewords terminates and pads the buffer to fill the given number of codewords.
+func (b bitBuffer) codewords(capacity int) []byte {
+	b.append(0, min(4, capacity*8-len(b)))
+	b.append(0, (8-len(b)%8)%8)
+
+	codewords := make([]byte, 0, capacity)
+	for i := 0; i < len(b); i += 8 {
+		var codeword byte
+		for _, bit := range b[i : i+8] {
+			codeword <<= 1
+			if bit {
+				codeword |= 1
+			}
+		}
+
+		codewords = append(codewords, codeword)
+	}
+
+	for pad := byte(0xEC); len(codewords) < capacity; pad ^= 0xEC ^ 0x11 {
+		codewords = append(codewords, pad)
+	}
+
+	return codewords
+}
+
+// addErrorCorrection splits the data into blocks, computes each block's error correction
+// codewords, and interleaves the result.
+func addErrorCorrection(v version, data []byte) []byte {
+	// Later blocks are one codeword longer when the data doesn't divide evenly.
+	shortBlocks := v.blocks - v.dataCodewords()%v.blocks
+	shortLength := v.dataCodewords() / v.blocks
+
+	divisor := reedSolomonDivisor(v.ecPerBlock)
+
+	dataBlocks := make([][]byte, 0, v.blocks)
+	ecBlocks := make([][]byte, 0, v.blocks)
+	for i := range v.blocks {
+		length := shortLength
+		if i >= shortBlocks {
+			length++
+		}
+
+		block := data[:length]
+		data = data[length:]
+
+		dataBlocks = append(dataBlocks, block)
+		ecBlocks = append(ecBlocks, reedSolomonRemainder(block, divisor))
+	}
+
+	result := make([]byte, 0, v.totalCodewords)
+	for i := range shortLength + 1 {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+
+	for i := range v.ecPerBlock {
+		for _, block := range ecBlocks {
+			result = append(result, block[i])
+		}
+	}
+
+	return result
+}
+
+func newCode(number int, codewords []byte) *Code {
+	size := number*4 + 17
+
+	c := Code{
+		Size:       size,
+		modules:    make([][]bool, size),
+		isFunction: make([][]bool, size),
+	}
+
+	for i := range size {
+		c.modules[i] = make([]bool, size)
+		c.isFunction[i] = make([]bool, size)
+	}
+
+	c.drawFunctionPatterns(number)
+	c.drawCodewords(codewords)
+
+	bestMask, bestPenalty := 0, -1
+	for mask := range 8 {
+		c.applyMask(mask)
+		c.drawFormatBits(mask)
+
+		if penalty := c.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			bestMask, bestPenalty = mask, penalty
+		}
+
+		// Masking is its own inverse.
+		c.applyMask(mask)
+	}
+
+	c.applyMask(bestMask)
+	c.drawFormatBits(bestMask)
+
+	return &c
+}
+
+func (c *Code) setFunction(x int, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.isFunction[y][x] = true
+}
+
+func (c *Code) drawFunctionPatterns(number int) {
+	for i := range c.Size {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+
+	c.drawFinder(3, 3)
+	c.drawFinder(c.Size-4, 3)
+	c.drawFinder(3, c.Size-4)
+
+	alignment := versions[number].alignment
+	last := len(alignment) - 1
+	for i, x := range alignment {
+		for j, y := range alignment {
+			// Skip the corners occupied by finder patterns.
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					c.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// Reserve the format areas until the mask is chosen.
+	c.drawFormatBits(0)
+
+	if number >= 7 {
+		remainder := number
+		for range 12 {
+			remainder = (remainder << 1) ^ ((remainder >> 11) * 0x1F25)
+		}
+
+		bits := number<<12 | remainder
+		for i := range 18 {
+			dark := (bits>>i)&1 == 1
+			a, b := c.Size-11+i%3, i/3
+
+			c.setFunction(a, b, dark)
+			c.setFunction(b, a, dark)
+		}
+	}
+}
+
+// drawFinder draws a finder pattern and its separator centered on the given module.
+func (c *Code) drawFinder(x int, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= c.Size || yy < 0 || yy >= c.Size {
+				continue
+			}
+
+			distance := max(abs(dx), abs(dy))
+			c.setFunction(xx, yy, distance != 2 && distance != 4)
+		}
+	}
+}
+
+func (c *Code) drawFormatBits(mask int) {
+	// Error correction level M is encoded as 0, so only the mask contributes to the data bits.
+	data := mask
+
+	remainder := data
+	for range 10 {
+		remainder = (remainder << 1) ^ ((remainder >> 9) * 0x537)
+	}
+
+	bits := (data<<10 | remainder) ^ 0x5412
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+
+	for i := range 6 {
+		c.setFunction(8, i, bit(i))
+	}
+
+	c.setFunction(8, 7, bit(6))
+	c.setFunction(8, 8, bit(7))
+	c.setFunction(7, 8, bit(8))
+
+	for i := 9; i < 15; i++ {
+		c.setFunction(14-i, 8, bit(i))
+	}
+
+	for i := range 8 {
+		c.setFunction(c.Size-1-i, 8, bit(i))
+	}
+
+	for i := 8; i < 15; i++ {
+		c.setFunction(8, c.Size-15+i, bit(i))
+	}
+
+	c.setFunction(8, c.Size-8, true)
+}
+
+// drawCodewords places the codewords in the zigzag pattern that runs up and down pairs of columns
+// from the right edge.
+func (c *Code) drawCodewords(codewords []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		// The vertical timing pattern shifts the column pairs over by one.
+		if right == 6 {
+			right = 5
+		}
+
+		upward := (right+1)&2 == 0
+		for vertical := range c.Size {
+			y := vertical
+			if upward {
+				y = c.Size - 1 - vertical
+			}
+
+			for j := range 2 {
+				x := right - j
+				if c.isFunction[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+
+				c.modules[y][x] = (codewords[i/8]>>(7-i%8))&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+func (c *Code) applyMask(mask int) {
+	for y := range c.Size {
+		for x := range c.Size {
+			if c.isFunction[y][x] {
+				continue
+			}
+
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+
+			if invert {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the code would be to scan. Lower is better.
+func (c *Code) penalty() int {
+	penalty := 0
+
+	// Rows and columns are scored the same way, so score the columns by transposing.
+	transposed := make([][]bool, c.Size)
+	for x := range c.Size {
+		transposed[x] = make([]bool, c.Size)
+		for y := range c.Size {
+			transposed[x][y] = c.modules[y][x]
+		}
+	}
+
+	finderLike := [][]bool{
+		{true, false, true, true, true, false, true, false, false, false, false},
+		{false, false, false, false, true, false, true, true, true, false, true},
+	}
+
+	for _, lines := range [][][]bool{c.modules, transposed} {
+		for _, line := range lines {
+			run := 1
+			for i := 1; i <= len(line); i++ {
+				if i < len(line) && line[i] == line[i-1] {
+					run++
+					continue
+				}
+
+				if run >= 5 {
+					penalty += run - 2
+				}
+
+				run = 1
+			}
+
+			for i := 0; i+11 <= len(line); i++ {
+				for _, pattern := range finderLike {
+					if slices.Equal(line[i:i+11], pattern) {
+						penalty += 40
+					}
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := range c.Size {
+		for x := range c.Size {
+			if c.modules[y][x] {
+				dark++
+			}
+
+			if x+1 < c.Size && y+1 < c.Size {
+				color := c.modules[y][x]
+				if color == c.modules[y][x+1] && color == c.modules[y+1][x] && color == c.modules[y+1][x+1] {
+					penalty += 3
+				}
+			}
+		}
+	}
+
+	// Penalize every 5% that the proportion of dark modules strays from 50%.
+	total := c.Size * c.Size
+	penalty += (abs(dark*20-total*10)+total-1)/total*10 - 10
+
+	return penalty
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
@@ -0,0 +1,317 @@
+package qr_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cdriehuys/stuff2/internal/qr"
+)
+
+func TestErrorCorrectionCodewords(t *testing.T) {
+	// The "HELLO WORLD" example from the specification, encoded as version 1-M.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	got := qr.ErrorCorrectionCodewords(data, 10)
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected error correction codewords %v, got %v", want, got)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		wantSize int
+	}{
+		{
+			name:     "empty",
+			text:     "",
+			wantSize: 21,
+		},
+		{
+			name:     "version 1",
+			text:     strings.Repeat("a", 14),
+			wantSize: 21,
+		},
+		{
+			name:     "version 2",
+			text:     strings.Repeat("a", 15),
+			wantSize: 25,
+		},
+		{
+			name:     "url",
+			text:     "https://example.com/l/3HbkHUR7R4mZ2MH6Og4LrQ",
+			wantSize: 33,
+		},
+		{
+			name:     "multiple blocks",
+			text:     strings.Repeat("0123456789", 10),
+			wantSize: 41,
+		},
+		{
+			name:     "version 7",
+			text:     strings.Repeat("b", 122),
+			wantSize: 45,
+		},
+		{
+			name:     "version 10",
+			text:     strings.Repeat("c", 213),
+			wantSize: 57,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := qr.Encode(tt.text)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if code.Size != tt.wantSize {
+				t.Fatalf("Expected size %d, got %d", tt.wantSize, code.Size)
+			}
+
+			assertFinderPatterns(t, code)
+
+			// Larger versions have alignment patterns in places the test decoder doesn't know
+			// about.
+			if code.Size > 41 {
+				return
+			}
+
+			if got := decode(t, code); got != tt.text {
+				t.Errorf("Expected decoded text %q, got %q", tt.text, got)
+			}
+		})
+	}
+}
+
+func TestEncode_TooLong(t *testing.T) {
+	_, err := qr.Encode(strings.Repeat("a", 214))
+	if !errors.Is(err, qr.ErrTooLong) {
+		t.Errorf("Expected error %v, got %v", qr.ErrTooLong, err)
+	}
+}
+
+func TestEncode_VersionInformation(t *testing.T) {
+	code, err := qr.Encode(strings.Repeat("b", 122))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The version information for version 7 from the specification, least significant bit first.
+	want := "001010010011111000"
+
+	var topRight, bottomLeft strings.Builder
+	for i := range 18 {
+		a, b := code.Size-11+i%3, i/3
+		topRight.WriteString(moduleBit(code.Dark(a, b)))
+		bottomLeft.WriteString(moduleBit(code.Dark(b, a)))
+	}
+
+	if topRight.String() != want {
+		t.Errorf("Expected top right version information %s, got %s", want, topRight.String())
+	}
+
+	if bottomLeft.String() != want {
+		t.Errorf("Expected bottom left version information %s, got %s", want, bottomLeft.String())
+	}
+}
+
+func TestCode_SVG(t *testing.T) {
+	code, err := qr.Encode("hello")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	svg := code.SVG()
+
+	// Version 1 is 21 modules wide, plus a quiet zone of 4 on each side.
+	if !strings.Contains(svg, `viewBox="0 0 29 29"`) {
+		t.Errorf("Expected SVG to include the quiet zone in its view box, got %s", svg)
+	}
+
+	// The top left module of the finder pattern is offset by the quiet zone.
+	if !strings.Contains(svg, "M4,4h1v1h-1z") {
+		t.Errorf("Expected SVG to draw the top left module, got %s", svg)
+	}
+}
+
+func moduleBit(dark bool) string {
+	if dark {
+		return "1"
+	}
+
+	return "0"
+}
+
+func assertFinderPatterns(t *testing.T, code *qr.Code) {
+	t.Helper()
+
+	corners := [][2]int{{0, 0}, {code.Size - 7, 0}, {0, code.Size - 7}}
+	for _, corner := range corners {
+		for dy := range 7 {
+			for dx := range 7 {
+				distance := max(abs(dx-3), abs(dy-3))
+				want := distance != 2
+
+				if got := code.Dark(corner[0]+dx, corner[1]+dy); got != want {
+					t.Errorf("Expected finder module (%d, %d) dark=%v, got %v", corner[0]+dx, corner[1]+dy, want, got)
+				}
+			}
+		}
+	}
+}
+
+// formats maps the format information for error correction level M to its mask.
+var formats = map[string]int{
+	"101010000010010": 0,
+	"101000100100101": 1,
+	"101111001111100": 2,
+	"101101101001011": 3,
+	"100010111111001": 4,
+	"100000011001110": 5,
+	"100111110010111": 6,
+	"100101010100000": 7,
+}
+
+// blockLayouts gives the number of data codewords in each block for versions 1 through 6.
+var blockLayouts = [][]int{
+	1: {16},
+	2: {28},
+	3: {44},
+	4: {32, 32},
+	5: {43, 43},
+	6: {27, 27, 27, 27},
+}
+
+// decode reads the text from a byte mode code of version 1 through 6.
+func decode(t *testing.T, code *qr.Code) string {
+	t.Helper()
+
+	version := (code.Size - 17) / 4
+
+	// Format information is stored most significant bit first, starting next to the top left
+	// finder pattern.
+	var format strings.Builder
+	for x := range 6 {
+		format.WriteString(moduleBit(code.Dark(x, 8)))
+	}
+
+	format.WriteString(moduleBit(code.Dark(7, 8)))
+	format.WriteString(moduleBit(code.Dark(8, 8)))
+	format.WriteString(moduleBit(code.Dark(8, 7)))
+	for y := 5; y >= 0; y-- {
+		format.WriteString(moduleBit(code.Dark(8, y)))
+	}
+
+	mask, ok := formats[format.String()]
+	if !ok {
+		t.Fatalf("Unknown format information %s", format.String())
+	}
+
+	isFunction := func(x int, y int) bool {
+		switch {
+		case x == 6 || y == 6:
+			return true
+		case x <= 8 && y <= 8, x >= code.Size-8 && y <= 8, x <= 8 && y >= code.Size-8:
+			return true
+		case version >= 2:
+			center := code.Size - 7
+			return abs(x-center) <= 2 && abs(y-center) <= 2
+		}
+
+		return false
+	}
+
+	masks := []func(x int, y int) bool{
+		func(x, y int) bool { return (x+y)%2 == 0 },
+		func(x, y int) bool { return y%2 == 0 },
+		func(x, y int) bool { return x%3 == 0 },
+		func(x, y int) bool { return (x+y)%3 == 0 },
+		func(x, y int) bool { return (x/3+y/2)%2 == 0 },
+		func(x, y int) bool { return x*y%2+x*y%3 == 0 },
+		func(x, y int) bool { return (x*y%2+x*y%3)%2 == 0 },
+		func(x, y int) bool { return ((x+y)%2+x*y%3)%2 == 0 },
+	}
+
+	var bits []bool
+	for right := code.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+
+		upward := (right+1)&2 == 0
+		for vertical := range code.Size {
+			y := vertical
+			if upward {
+				y = code.Size - 1 - vertical
+			}
+
+			for _, x := range []int{right, right - 1} {
+				if !isFunction(x, y) {
+					bits = append(bits, code.Dark(x, y) != masks[mask](x, y))
+				}
+			}
+		}
+	}
+
+	codewords := make([]byte, len(bits)/8)
+	for i := range codewords {
+		for _, bit := range bits[i*8 : i*8+8] {
+			codewords[i] <<= 1
+			if bit {
+				codewords[i] |= 1
+			}
+		}
+	}
+
+	layout := blockLayouts[version]
+	blockLength := layout[0]
+
+	blocks := make([][]byte, len(layout))
+	for i := range blockLength * len(layout) {
+		blocks[i%len(layout)] = append(blocks[i%len(layout)], codewords[i])
+	}
+
+	ecCodewords := codewords[blockLength*len(layout):]
+	ecLength := len(ecCodewords) / len(layout)
+
+	var data []byte
+	for i, block := range blocks {
+		var ec []byte
+		for j := range ecLength {
+			ec = append(ec, ecCodewords[j*len(layout)+i])
+		}
+
+		if want := qr.ErrorCorrectionCodewords(block, ecLength); !slices.Equal(ec, want) {
+			t.Fatalf("Expected block %d error correction %v, got %v", i, want, ec)
+		}
+
+		data = append(data, block...)
+	}
+
+	if mode := data[0] >> 4; mode != 0b0100 {
+		t.Fatalf("Expected byte mode, got %04b", mode)
+	}
+
+	length := int(data[0]&0x0F)<<4 | int(data[1]>>4)
+
+	text := make([]byte, length)
+	for i := range text {
+		text[i] = data[i+1]<<4 | data[i+2]>>4
+	}
+
+	return string(text)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
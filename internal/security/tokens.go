@@ -1,9 +1,24 @@
 package security
 
-import "crypto/rand"
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
 
 type TokenGenerator struct{}
 
 func (g TokenGenerator) Generate() string {
 	return rand.Text()
 }
+
+// SHA256TokenHasher hashes randomly generated tokens for storage. Unlike passwords, generated tokens
+// have enough entropy that a fast, unsalted hash is safe, and a deterministic hash allows looking
+// tokens up by their hash.
+type SHA256TokenHasher struct{}
+
+func (h SHA256TokenHasher) Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
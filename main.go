@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"flag"
+	"fmt"
 	"io/fs"
 	"log/slog"
 	"net/http"
@@ -18,6 +19,7 @@ import (
 	"github.com/cdriehuys/stuff2/internal/i18n"
 	"github.com/cdriehuys/stuff2/internal/models"
 	"github.com/cdriehuys/stuff2/internal/models/queries"
+	"github.com/cdriehuys/stuff2/internal/oidc"
 	"github.com/cdriehuys/stuff2/internal/security"
 	"github.com/cdriehuys/stuff2/internal/templating"
 	"github.com/cdriehuys/stuff2/translations"
@@ -27,16 +29,21 @@ import (
 
 const (
 	emailVerificationTokenLifetime time.Duration = 15 * time.Minute
+	dataExportLifetime             time.Duration = 24 * time.Hour
+	loginLinkLifetime              time.Duration = 15 * time.Minute
+	householdInvitationLifetime    time.Duration = 7 * 24 * time.Hour
 )
 
 var (
 	liveEmailTemplatePath string
 	liveTemplatePath      string
+	oidcConfigPath        string
 )
 
 func main() {
 	flag.StringVar(&liveEmailTemplatePath, "live-email-templates", "", "load email templates from this path for each request instead of using the embedded templates")
 	flag.StringVar(&liveTemplatePath, "live-templates", "", "load UI templates from this path for each request instead of using the embedded templates")
+	flag.StringVar(&oidcConfigPath, "oidc-config", "", "load OpenID Connect providers to sign in with from this JSON file")
 	flag.Parse()
 
 	logger := slog.New(
@@ -82,6 +89,16 @@ func main() {
 		panic(err)
 	}
 
+	var oidcProviders []application.OIDCProvider
+	if oidcConfigPath != "" {
+		oidcProviders, err = loadOIDCProviders(context.Background(), oidcConfigPath, baseDomain)
+		if err != nil {
+			panic(err)
+		}
+
+		logger.Info("Loaded OIDC providers.", "count", len(oidcProviders))
+	}
+
 	emailVerifier := application.NewEmailVerifier(logger, emailer, emailTemplates, baseDomain, sender)
 
 	ut, err := i18n.LoadTranslations(logger, translations.FS)
@@ -105,10 +122,37 @@ func main() {
 		security.Argon2IDHasher{},
 		security.TokenGenerator{},
 		emailVerificationTokenLifetime,
+		dataExportLifetime,
+		loginLinkLifetime,
 		models.PoolWrapper{Pool: dbPool},
 		models.UserQueriesWrapper{Queries: queries},
 	)
 
+	apiTokens := models.NewAPITokenModel(
+		logger,
+		security.SHA256TokenHasher{},
+		security.TokenGenerator{},
+		models.PoolWrapper{Pool: dbPool},
+		models.APITokenQueriesWrapper{Queries: queries},
+	)
+
+	households := models.NewHouseholdModel(
+		logger,
+		emailVerifier,
+		security.TokenGenerator{},
+		householdInvitationLifetime,
+		models.PoolWrapper{Pool: dbPool},
+		models.HouseholdQueriesWrapper{Queries: queries},
+	)
+
+	locations := models.NewLocationModel(
+		logger,
+		models.PoolWrapper{Pool: dbPool},
+		models.LocationQueriesWrapper{Queries: queries},
+	)
+
+	audit := models.NewAuditModel(logger, queries)
+
 	sessionManager := scs.New()
 	sessionManager.Store = pgxstore.New(dbPool)
 
@@ -118,11 +162,18 @@ func main() {
 
 	app := application.Application{
 		Logger:     logger,
+		BaseURL:    baseDomain,
 		Session:    sessionManager,
 		Templates:  uiTemplates,
 		Translator: ut,
 
-		Users: users,
+		OIDCProviders: oidcProviders,
+
+		APITokens:  apiTokens,
+		Audit:      audit,
+		Households: households,
+		Locations:  locations,
+		Users:      users,
 	}
 
 	s := http.Server{
@@ -136,3 +187,35 @@ func main() {
 		logger.Error("Server stopped.", "error", err)
 	}
 }
+
+// loadOIDCProviders discovers each provider in the config file. The callback for each provider is
+// served relative to the base domain.
+func loadOIDCProviders(ctx context.Context, path string, baseDomain *url.URL) ([]application.OIDCProvider, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening OIDC config: %v", err)
+	}
+
+	defer file.Close()
+
+	configs, err := oidc.LoadConfig(file)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	providers := make([]application.OIDCProvider, 0, len(configs))
+	for _, config := range configs {
+		redirectURL := baseDomain.JoinPath("login", "oidc", config.Name, "callback").String()
+
+		provider, err := oidc.Discover(ctx, client, config, redirectURL)
+		if err != nil {
+			return nil, fmt.Errorf("discovering OIDC provider %q: %v", config.Name, err)
+		}
+
+		providers = append(providers, provider)
+	}
+
+	return providers, nil
+}
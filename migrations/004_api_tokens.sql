@@ -0,0 +1,19 @@
+CREATE TABLE api_tokens(
+    id INT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    user_id uuid NOT NULL REFERENCES users(id)
+        ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    -- Only a hash of the token is stored. The plaintext token is shown to the
+    -- user once when it is created.
+    token_hash TEXT UNIQUE NOT NULL,
+    scopes TEXT[] NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX api_tokens_user_id_idx ON api_tokens(user_id);
+
+---- create above / drop below ----
+
+DROP TABLE api_tokens;
@@ -0,0 +1,18 @@
+CREATE TABLE external_identities(
+    id INT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    user_id uuid NOT NULL REFERENCES users(id)
+        ON DELETE CASCADE,
+    -- Subjects are only unique per issuer. The provider is the name of the configuration the
+    -- identity was linked through, which can be renamed, so it is informational only.
+    issuer TEXT NOT NULL,
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (issuer, subject)
+);
+
+CREATE INDEX external_identities_user_id_idx ON external_identities (user_id);
+
+---- create above / drop below ----
+
+DROP TABLE external_identities;
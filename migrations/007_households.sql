@@ -0,0 +1,38 @@
+CREATE TABLE households(
+    id uuid PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+SELECT _manage_updated_at('households');
+
+CREATE TABLE household_members(
+    household_id uuid NOT NULL REFERENCES households(id)
+        ON DELETE CASCADE,
+    user_id uuid NOT NULL REFERENCES users(id)
+        ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (household_id, user_id)
+);
+
+CREATE INDEX household_members_user_id_idx ON household_members (user_id);
+
+CREATE TABLE household_invitations(
+    id INT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    household_id uuid NOT NULL REFERENCES households(id)
+        ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    token TEXT UNIQUE NOT NULL,
+    invited_by uuid NOT NULL REFERENCES users(id)
+        ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+---- create above / drop below ----
+
+DROP TABLE household_invitations;
+DROP TABLE household_members;
+DROP TABLE households;
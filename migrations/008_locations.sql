@@ -0,0 +1,23 @@
+CREATE TABLE locations(
+    id uuid PRIMARY KEY,
+    household_id uuid NOT NULL REFERENCES households(id)
+        ON DELETE CASCADE,
+    parent_id uuid,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    -- Referencing the parent through the household ensures a location can only be nested inside
+    -- another location from the same household.
+    UNIQUE (household_id, id),
+    FOREIGN KEY (household_id, parent_id) REFERENCES locations(household_id, id)
+        ON DELETE CASCADE,
+    CHECK (parent_id <> id)
+);
+
+SELECT _manage_updated_at('locations');
+
+CREATE INDEX locations_household_id_parent_id_idx ON locations (household_id, parent_id);
+
+---- create above / drop below ----
+
+DROP TABLE locations;
@@ -0,0 +1,69 @@
+-- Audit events record who changed what. They are only ever inserted. Updates
+-- are rejected, except for forgetting an actor whose account was deleted.
+-- Deletes are rejected, except when cascading from the deletion of the
+-- household or user the events belong to.
+CREATE TABLE audit_events(
+    id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    -- Events outlive their actor so that a household's history stays intact
+    -- when one of its members deletes their account.
+    actor_id uuid REFERENCES users(id)
+        ON DELETE SET NULL,
+    -- Household events are shown to the household's members. User events make
+    -- up the user's security log and are deleted along with their account.
+    household_id uuid REFERENCES households(id)
+        ON DELETE CASCADE,
+    user_id uuid REFERENCES users(id)
+        ON DELETE CASCADE,
+    entity_type TEXT NOT NULL,
+    entity_id uuid NOT NULL,
+    action TEXT NOT NULL,
+    -- Field level changes, keyed by field, with the "old" and "new" values.
+    changes jsonb NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((household_id IS NULL) <> (user_id IS NULL))
+);
+
+CREATE FUNCTION _prevent_audit_event_changes() RETURNS trigger AS $$
+BEGIN
+    IF (
+        NEW.actor_id IS NULL AND
+        to_jsonb(NEW) - 'actor_id' = to_jsonb(OLD) - 'actor_id'
+    ) THEN
+        RETURN NEW;
+    END IF;
+
+    RAISE EXCEPTION 'audit events cannot be changed';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER prevent_changes BEFORE UPDATE ON audit_events
+    FOR EACH ROW EXECUTE PROCEDURE _prevent_audit_event_changes();
+
+-- By the time a cascading delete reaches an event, the household or user it
+-- belongs to is already gone.
+CREATE FUNCTION _prevent_audit_event_deletes() RETURNS trigger AS $$
+BEGIN
+    IF (
+        NOT EXISTS (SELECT 1 FROM households WHERE id = OLD.household_id) AND
+        NOT EXISTS (SELECT 1 FROM users WHERE id = OLD.user_id)
+    ) THEN
+        RETURN OLD;
+    END IF;
+
+    RAISE EXCEPTION 'audit events cannot be deleted';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER prevent_deletes BEFORE DELETE ON audit_events
+    FOR EACH ROW EXECUTE PROCEDURE _prevent_audit_event_deletes();
+
+CREATE INDEX audit_events_household_entity_idx
+    ON audit_events (household_id, entity_type, entity_id, created_at);
+CREATE INDEX audit_events_user_id_idx ON audit_events (user_id, created_at);
+
+---- create above / drop below ----
+
+DROP TABLE audit_events;
+
+DROP FUNCTION _prevent_audit_event_changes;
+DROP FUNCTION _prevent_audit_event_deletes;